@@ -12,7 +12,9 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm/factory"
 	"github.com/openshift/odo/pkg/log"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ktemplates "k8s.io/kubectl/pkg/util/templates"
 
@@ -24,6 +26,8 @@ import (
 	"github.com/redhat-developer/kam/pkg/pipelines/argocd"
 	"github.com/redhat-developer/kam/pkg/pipelines/imagerepo"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/pkg/pipelines/scm"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 const (
@@ -35,6 +39,7 @@ const (
 	serviceRepoURLFlag     = "service-repo-url"
 	gitHostAccessTokenFlag = "git-host-access-token"
 	imageRepoFlag          = "image-repo"
+	cosignPasswordEnv      = "COSIGN_PASSWORD"
 	gitopsOperatorName     = "OpenShift GitOps Operator"
 	pipelinesOperatorName  = "OpenShift Pipelines Operator"
 )
@@ -45,6 +50,8 @@ var (
 	supportedDrivers = drivers{
 		"github",
 		"gitlab",
+		"stash",
+		"gitea",
 	}
 )
 
@@ -72,7 +79,8 @@ var (
 // BootstrapParameters encapsulates the parameters for the kam pipelines init command.
 type BootstrapParameters struct {
 	*pipelines.BootstrapOptions
-	Interactive bool
+	Interactive        bool
+	CosignPasswordFile string
 }
 
 // NewBootstrapParameters bootsraps a Bootstrap Parameters instance.
@@ -86,6 +94,19 @@ func NewBootstrapParameters() *BootstrapParameters {
 // If the prefix provided doesn't have a "-" then one is added, this makes the
 // generated environment names nicer to read.
 func (io *BootstrapParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	if len(io.SOPSAgeRecipients) > 0 {
+		io.SOPS = true
+	}
+	if io.SupplyChainTransparency {
+		io.SupplyChain = true
+	}
+	if io.CosignKey != "" {
+		password, err := cosignPassword(ioutils.NewFilesystem(), io.CosignPasswordFile, os.LookupEnv, term.IsTerminal(int(os.Stdin.Fd())), ui.EnterCosignPassword)
+		if err != nil {
+			return err
+		}
+		io.CosignPassword = password
+	}
 	client, err := utility.NewClient()
 	if err != nil {
 		return err
@@ -111,6 +132,27 @@ func (io *BootstrapParameters) Complete(name string, cmd *cobra.Command, args []
 	return nonInteractiveMode(io, client)
 }
 
+// cosignPassword returns the password of the cosign private key from the
+// password file, or the COSIGN_PASSWORD environment variable, as cosign does,
+// the password is prompted for if neither is provided and the input is a
+// terminal, otherwise the key must not have a password.
+func cosignPassword(fs afero.Fs, passwordFile string, lookupEnv func(string) (string, bool), isTerminal bool, prompt func() string) (string, error) {
+	if passwordFile != "" {
+		body, err := afero.ReadFile(fs, passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the cosign password file: %w", err)
+		}
+		return strings.TrimRight(string(body), "\r\n"), nil
+	}
+	if password, ok := lookupEnv(cosignPasswordEnv); ok {
+		return password, nil
+	}
+	if isTerminal {
+		return prompt(), nil
+	}
+	return "", nil
+}
+
 func addGitURLSuffixIfNecessary(io *BootstrapParameters) {
 	io.GitOpsRepoURL = utility.AddGitSuffixIfNecessary(io.GitOpsRepoURL)
 	io.ServiceRepoURL = utility.AddGitSuffixIfNecessary(io.ServiceRepoURL)
@@ -312,15 +354,26 @@ func (io *BootstrapParameters) Validate() error {
 	}
 
 	// TODO: this may not work with GitLab as the repo can have more path elements.
-	if len(utility.RemoveEmptyStrings(strings.Split(gr.Path, "/"))) != 2 {
+	components := utility.RemoveEmptyStrings(strings.Split(gr.Path, "/"))
+	// Bitbucket Server clone URLs are of the form /scm/<project>/<repo>.git
+	if io.PrivateRepoDriver == "stash" && len(components) > 0 && components[0] == "scm" {
+		components = components[1:]
+	}
+	if len(components) != 2 {
 		return fmt.Errorf("repo must be org/repo: %s", strings.Trim(gr.Path, ".git"))
 	}
 
+	if io.PrivateRepoDriver == "gogs" {
+		return scm.ErrGogsUnsupported
+	}
 	if io.PrivateRepoDriver != "" {
 		if !supportedDrivers.supported(io.PrivateRepoDriver) {
 			return fmt.Errorf("invalid driver type: %q", io.PrivateRepoDriver)
 		}
 	}
+	if !tekton.Version(io.TektonAPIVersion).IsValid() {
+		return fmt.Errorf("invalid Tekton API version: %q, must be one of %v", io.TektonAPIVersion, tekton.Versions)
+	}
 	if io.SaveTokenKeyRing && io.GitHostAccessToken == "" {
 		return errors.New("--git-host-access-token is required if --save-token-keyring is enabled")
 	}
@@ -343,7 +396,7 @@ func (io *BootstrapParameters) Run() error {
 		}
 		log.Successf("Created repository")
 	}
-	nextSteps()
+	nextSteps(io.SealedSecretsCert != "" || io.SOPS)
 	return nil
 }
 
@@ -371,17 +424,28 @@ func NewCmdBootstrap(name, fullName string) *cobra.Command {
 	bootstrapCmd.Flags().StringVar(&o.ServiceRepoURL, "service-repo-url", "", "Provide the URL for your Service repository e.g. https://github.com/organisation/service.git")
 	bootstrapCmd.Flags().StringVar(&o.ServiceWebhookSecret, "service-webhook-secret", "", "Provide a secret that we can use to authenticate incoming hooks from your Git hosting service for the Service repository. (if not provided, it will be auto-generated)")
 	bootstrapCmd.Flags().BoolVar(&o.SaveTokenKeyRing, "save-token-keyring", false, "Explicitly pass this flag to update the git-host-access-token in the keyring on your local machine")
-	bootstrapCmd.Flags().StringVar(&o.PrivateRepoDriver, "private-repo-driver", "", "If your Git repositories are on a custom domain, please indicate which driver to use github or gitlab")
+	bootstrapCmd.Flags().StringVar(&o.PrivateRepoDriver, "private-repo-driver", "", "If your Git repositories are on a custom domain, please indicate which driver to use github, gitlab, stash (Bitbucket Server) or gitea")
 	bootstrapCmd.Flags().BoolVar(&o.PushToGit, "push-to-git", false, "If true, automatically creates and populates the gitops-repo-url with the generated resources")
+	bootstrapCmd.Flags().StringVar(&o.SealedSecretsCert, "sealed-secrets-cert", "", "Path to a Sealed Secrets certificate, if provided the generated secrets are sealed and written to the GitOps repository")
+	bootstrapCmd.Flags().BoolVar(&o.SOPS, "sops", false, "If true, the generated secrets are encrypted with SOPS, for the age recipients in the .sops.yaml in the output folder, and written to the GitOps repository")
+	bootstrapCmd.Flags().StringSliceVar(&o.SOPSAgeRecipients, "sops-age-recipients", nil, "The age recipients to encrypt the generated secrets for with SOPS, implies --sops")
+	bootstrapCmd.Flags().StringVar(&o.CosignKey, "cosign-key", "", "Path to a cosign private key, if provided the images built by the pipelines are signed with it, and an SPDX SBOM is attested for them")
+	bootstrapCmd.Flags().StringVar(&o.CosignPasswordFile, "cosign-password-file", "", "Path to a file with the password of the cosign private key, if not provided the password is read from the COSIGN_PASSWORD environment variable, or prompted for")
+	bootstrapCmd.Flags().BoolVar(&o.SupplyChain, "supply-chain", false, "If true, Tekton Chains is configured to generate SLSA provenance for the images built by the pipelines")
+	bootstrapCmd.Flags().BoolVar(&o.SupplyChainTransparency, "supply-chain-transparency", false, "If true, Tekton Chains also records the signatures and provenance in the public Rekor transparency log, which discloses the image names and build details, implies --supply-chain")
+	bootstrapCmd.Flags().StringVar(&o.TektonAPIVersion, "tekton-api-version", string(tekton.V1Beta1), "The version of the Tekton APIs to generate resources for, v1beta1 generates tekton.dev/v1beta1 and triggers.tekton.dev/v1alpha1 resources, and v1 generates tekton.dev/v1 and triggers.tekton.dev/v1beta1 resources")
 	bootstrapCmd.Flags().BoolVar(&o.Interactive, "interactive", false, "If true, enable prompting for most options if not already specified on the command line")
 	return bootstrapCmd
 }
 
-func nextSteps() {
+func nextSteps(encrypted bool) {
 	log.Success("Bootstrapped OpenShift resources successfully\n\n",
 		"Next Steps:\n",
 		"Please refer to https://github.com/redhat-developer/kam/tree/master/docs to get started.\n",
 	)
+	if encrypted {
+		return
+	}
 	log.Info(" WARNING: Generated secrets are not encrypted. Deploying the GitOps configuration without encrypting secrets is insecure and is not recommended.\n For more information on secret management see: https://github.com/redhat-developer/kam/tree/master/docs/journey/day1#secrets\n")
 }
 
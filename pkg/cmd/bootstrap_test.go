@@ -17,6 +17,9 @@ import (
 	"github.com/redhat-developer/kam/pkg/cmd/utility"
 	"github.com/redhat-developer/kam/pkg/pipelines"
 	"github.com/redhat-developer/kam/pkg/pipelines/argocd"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/test"
+	"github.com/spf13/afero"
 	appv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
@@ -100,15 +103,22 @@ func TestAddSuffixWithBootstrap(t *testing.T) {
 
 func TestValidateBootstrapParameter(t *testing.T) {
 	optionTests := []struct {
-		name    string
-		gitRepo string
-		driver  string
-		errMsg  string
+		name             string
+		gitRepo          string
+		driver           string
+		tektonAPIVersion string
+		errMsg           string
 	}{
-		{"invalid repo", "test", "", "repo must be org/repo"},
-		{"valid repo", "test/repo", "", ""},
-		{"invalid driver", "test/repo", "unknown", "invalid"},
-		{"valid driver gitlab", "test/repo", "gitlab", ""},
+		{"invalid repo", "test", "", "", "repo must be org/repo"},
+		{"valid repo", "test/repo", "", "", ""},
+		{"invalid driver", "test/repo", "unknown", "", "invalid"},
+		{"unsupported driver gogs", "test/repo", "gogs", "", "unsupported Git repository type: gogs"},
+		{"valid driver gitlab", "test/repo", "gitlab", "", ""},
+		{"valid driver stash", "https://bitbucket.example.com/scm/test/repo.git", "stash", "", ""},
+		{"valid driver gitea", "https://gitea.example.com/test/repo.git", "gitea", "", ""},
+		{"invalid stash repo", "https://bitbucket.example.com/scm/test.git", "stash", "", "repo must be org/repo"},
+		{"valid tekton api version", "test/repo", "", "v1", ""},
+		{"invalid tekton api version", "test/repo", "", "v1alpha1", "invalid Tekton API version"},
 	}
 
 	for _, tt := range optionTests {
@@ -116,6 +126,7 @@ func TestValidateBootstrapParameter(t *testing.T) {
 			BootstrapOptions: &pipelines.BootstrapOptions{
 				GitOpsRepoURL:     tt.gitRepo,
 				PrivateRepoDriver: tt.driver,
+				TektonAPIVersion:  tt.tektonAPIVersion,
 				Prefix:            "test",
 			},
 		}
@@ -353,3 +364,48 @@ func TestMissingFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestCosignPassword(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	if err := afero.WriteFile(fakeFs, "/cosign.password", []byte("file-password\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	env := func(values map[string]string) func(string) (string, bool) {
+		return func(k string) (string, bool) {
+			v, ok := values[k]
+			return v, ok
+		}
+	}
+	prompt := func() string {
+		return "prompted-password"
+	}
+
+	passwordTests := []struct {
+		name         string
+		passwordFile string
+		env          map[string]string
+		isTerminal   bool
+		want         string
+	}{
+		{"password file", "/cosign.password", map[string]string{"COSIGN_PASSWORD": "env-password"}, true, "file-password"},
+		{"environment", "", map[string]string{"COSIGN_PASSWORD": "env-password"}, true, "env-password"},
+		{"empty environment", "", map[string]string{"COSIGN_PASSWORD": ""}, true, ""},
+		{"prompt", "", nil, true, "prompted-password"},
+		{"no terminal", "", nil, false, ""},
+	}
+
+	for _, tt := range passwordTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			got, err := cosignPassword(fakeFs, tt.passwordFile, env(tt.env), tt.isTerminal, prompt)
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if got != tt.want {
+				rt.Fatalf("cosignPassword() got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	_, err := cosignPassword(fakeFs, "/missing.password", env(nil), false, prompt)
+	test.AssertErrorMatch(t, "failed to read the cosign password file", err)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
 	"github.com/redhat-developer/kam/pkg/pipelines"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
 	ktemplates "k8s.io/kubectl/pkg/util/templates"
@@ -21,6 +22,15 @@ var (
 	buildExample = ktemplates.Examples(`
 	# Build files from pipelines
 	%[1]s 
+
+	# List the generated files that are no longer generated from the pipelines
+	%[1]s --dry-run
+
+	# Build files from pipelines, removing the files that are no longer generated
+	%[1]s --prune
+
+	# Build files from pipelines, and check that the kustomizations render
+	%[1]s --verify
 	`)
 
 	buildLongDesc  = ktemplates.LongDesc(`Build GitOps pipelines files, generating the ArgoCD applications and OpenShift Pipelines EventListener`)
@@ -31,6 +41,9 @@ var (
 type BuildParameters struct {
 	pipelinesFolderPath string
 	output              string // path to add Gitops resources
+	prune               bool   // remove generated files that are no longer generated
+	dryRun              bool   // list the files that would be pruned
+	verify              bool   // render the kustomizations after building
 }
 
 // NewBuildParameters bootstraps a BuildParameters instance.
@@ -45,6 +58,9 @@ func (io *BuildParameters) Complete(name string, cmd *cobra.Command, args []stri
 
 // Validate validates the parameters of the BuildParameters.
 func (io *BuildParameters) Validate() error {
+	if io.verify && io.dryRun {
+		return fmt.Errorf("--verify can't be used with --dry-run, as no files are built")
+	}
 	return nil
 }
 
@@ -53,12 +69,49 @@ func (io *BuildParameters) Run() error {
 	options := pipelines.BuildParameters{
 		PipelinesFolderPath: io.pipelinesFolderPath,
 		OutputPath:          io.output,
+		Prune:               io.prune,
+		DryRun:              io.dryRun,
 	}
-	err := pipelines.BuildResources(&options, ioutils.NewFilesystem())
+	appFs := ioutils.NewFilesystem()
+	stale, err := pipelines.BuildResources(&options, appFs)
 	if err != nil {
 		return err
 	}
+	if io.dryRun {
+		if len(stale) == 0 {
+			log.Info("No files would be pruned.")
+		}
+		for _, f := range stale {
+			log.Infof("Would prune %s", f)
+		}
+		return nil
+	}
+	if io.prune {
+		for _, f := range stale {
+			log.Infof("Pruned %s", f)
+		}
+	} else if len(stale) > 0 {
+		log.Warningf("%d previously generated file(s) are no longer generated, rerun with --prune to remove them", len(stale))
+	}
 	log.Success("Built successfully.")
+	if io.verify {
+		return verifyResources(io.pipelinesFolderPath, io.output, appFs)
+	}
+	return nil
+}
+
+func verifyResources(pipelinesFolderPath, output string, appFs afero.Fs) error {
+	renderErrs, err := pipelines.VerifyResources(&pipelines.VerifyParameters{PipelinesFolderPath: pipelinesFolderPath, OutputPath: output}, appFs)
+	if err != nil {
+		return err
+	}
+	if len(renderErrs) > 0 {
+		for _, e := range renderErrs {
+			log.Errorf("Failed to render %s", e)
+		}
+		return fmt.Errorf("%d kustomization(s) failed to render", len(renderErrs))
+	}
+	log.Success("Verified that the kustomizations render.")
 	return nil
 }
 
@@ -77,5 +130,8 @@ func NewCmdBuild(name, fullName string) *cobra.Command {
 
 	buildCmd.Flags().StringVar(&o.output, "output", ".", "Folder path to add GitOps resources")
 	buildCmd.Flags().StringVar(&o.pipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	buildCmd.Flags().BoolVar(&o.prune, "prune", false, "Remove previously generated files that are no longer generated from the pipelines")
+	buildCmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "List the previously generated files that would be pruned, without writing any files")
+	buildCmd.Flags().BoolVar(&o.verify, "verify", false, "Render the generated kustomizations with kustomize, reporting the kustomizations that fail to render")
 	return buildCmd
 }
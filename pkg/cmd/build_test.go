@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/test"
+)
+
+func TestBuildValidate(t *testing.T) {
+	o := &BuildParameters{verify: true, dryRun: true}
+	test.AssertErrorMatch(t, "--verify can't be used with --dry-run", o.Validate())
+}
+
+func TestVerifyResources(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	manifest := "gitops_url: " + gitOpsURL + "\nenvironments:\n- name: test-dev\n"
+	if err := afero.WriteFile(fakeFs, "/pipelines.yaml", []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fakeFs, "/environments/test-dev/env/overlays/kustomization.yaml", []byte("bases:\n- ../base\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := verifyResources("/", "/", fakeFs)
+	test.AssertErrorMatch(t, `1 kustomization\(s\) failed to render`, err)
+
+	if err := afero.WriteFile(fakeFs, "/environments/test-dev/env/base/kustomization.yaml", []byte("resources: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyResources("/", "/", fakeFs); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// DiffRecommendedCommandName the recommended command name
+	DiffRecommendedCommandName = "diff"
+)
+
+var (
+	diffExample = ktemplates.Examples(`
+	# Show the changes a build would make to the GitOps repository
+	%[1]s
+
+	# Fail if the generated files are out of date
+	%[1]s --exit-code
+	`)
+
+	diffLongDesc  = ktemplates.LongDesc(`Build GitOps pipelines files in memory, and show the differences to the files on disk as a unified diff`)
+	diffShortDesc = `Show the changes a build would make`
+)
+
+// DiffParameters encapsulates the parameters for the kam diff command.
+type DiffParameters struct {
+	pipelinesFolderPath string
+	output              string // path to compare the Gitops resources with
+	exitCode            bool
+	prune               bool
+
+	fs  afero.Fs
+	out io.Writer
+}
+
+// NewDiffParameters bootstraps a DiffParameters instance.
+func NewDiffParameters() *DiffParameters {
+	return &DiffParameters{fs: ioutils.NewFilesystem(), out: os.Stdout}
+}
+
+// Complete completes DiffParameters after they've been created.
+func (io *DiffParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the DiffParameters.
+func (io *DiffParameters) Validate() error {
+	return nil
+}
+
+// Run runs the diff command.
+func (io *DiffParameters) Run() error {
+	options := pipelines.DiffParameters{
+		PipelinesFolderPath: io.pipelinesFolderPath,
+		OutputPath:          io.output,
+		Prune:               io.prune,
+	}
+	diffs, err := pipelines.DiffResources(&options, io.fs)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		log.Success("No differences found.")
+		return nil
+	}
+	for _, d := range diffs {
+		fmt.Fprint(io.out, d.Diff)
+	}
+	if io.exitCode {
+		return fmt.Errorf("found differences in %d file(s)", len(diffs))
+	}
+	return nil
+}
+
+// NewCmdDiff creates the pipelines diff command.
+func NewCmdDiff(name, fullName string) *cobra.Command {
+	o := NewDiffParameters()
+	diffCmd := &cobra.Command{
+		Use:     name,
+		Short:   diffShortDesc,
+		Long:    diffLongDesc,
+		Example: fmt.Sprintf(diffExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	diffCmd.Flags().StringVar(&o.output, "output", ".", "Folder path to compare the GitOps resources with")
+	diffCmd.Flags().StringVar(&o.pipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	diffCmd.Flags().BoolVar(&o.exitCode, "exit-code", false, "Exit with a non-zero status if the build would change any files")
+	diffCmd.Flags().BoolVar(&o.prune, "prune", false, "Show the removal of previously generated files that are no longer generated")
+	return diffCmd
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+func TestDiffRun(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	m := &config.Manifest{
+		GitOpsURL:    gitOpsURL,
+		Environments: []*config.Environment{{Name: "test-dev"}},
+	}
+	if _, err := yaml.WriteResources(fakeFs, "/", res.Resources{"pipelines.yaml": m}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		exitCode bool
+		wantErr  string
+	}{
+		{"differences are printed", false, ""},
+		{"differences fail with exit code", true, "found differences in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(rt *testing.T) {
+			var out bytes.Buffer
+			o := &DiffParameters{pipelinesFolderPath: "/", output: "/", exitCode: tt.exitCode, fs: fakeFs, out: &out}
+			err := o.Run()
+			if !matchError(rt, tt.wantErr, err) {
+				rt.Errorf("Run() got error %v, want %q", err, tt.wantErr)
+			}
+			if !strings.Contains(out.String(), "+++ b/environments/test-dev/env/base/kustomization.yaml") {
+				rt.Errorf("Run() output missing the new kustomization:\n%s", out.String())
+			}
+		})
+	}
+}
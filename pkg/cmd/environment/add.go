@@ -86,6 +86,6 @@ func NewCmdAddEnv(name, fullName string) *cobra.Command {
 	addEnvCmd.Flags().StringVar(&o.envName, "env-name", "", "Name of the environment/namespace")
 	_ = addEnvCmd.MarkFlagRequired("env-name")
 	addEnvCmd.Flags().StringVar(&o.pipelinesFolder, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
-	addEnvCmd.Flags().StringVar(&o.cluster, "cluster", "", "Deployment cluster e.g. https://kubernetes.local.svc")
+	addEnvCmd.Flags().StringVar(&o.cluster, "cluster", "", "Deployment cluster, either the name of a cluster declared in the manifest, or the API server URL e.g. https://kubernetes.local.svc")
 	return addEnvCmd
 }
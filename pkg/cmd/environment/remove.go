@@ -0,0 +1,86 @@
+package environment
+
+import (
+	"fmt"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/cobra"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// RemoveEnvRecommendedCommandName the recommended command name
+	RemoveEnvRecommendedCommandName = "remove"
+)
+
+var (
+	removeEnvExample = ktemplates.Examples(`
+	# Remove an environment from GitOps
+	# Example: kam environment remove --env-name new-env --pipelines-folder <path to GitOps folder>
+
+	%[1]s
+	`)
+
+	removeEnvLongDesc  = ktemplates.LongDesc(`Remove an environment, and its services, from the GitOps repository`)
+	removeEnvShortDesc = `Remove an environment`
+)
+
+// RemoveEnvParameters encapsulates the parameters for the kam environment
+// remove command.
+type RemoveEnvParameters struct {
+	envName         string
+	pipelinesFolder string
+}
+
+// NewRemoveEnvParameters bootstraps a RemoveEnvParameters instance.
+func NewRemoveEnvParameters() *RemoveEnvParameters {
+	return &RemoveEnvParameters{}
+}
+
+// Complete completes RemoveEnvParameters after they've been created.
+func (eo *RemoveEnvParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the RemoveEnvParameters.
+func (eo *RemoveEnvParameters) Validate() error {
+	return nil
+}
+
+// Run runs the environment remove command.
+func (eo *RemoveEnvParameters) Run() error {
+	options := pipelines.EnvParameters{
+		EnvName:             eo.envName,
+		PipelinesFolderPath: eo.pipelinesFolder,
+	}
+	err := pipelines.RemoveEnv(&options, ioutils.NewFilesystem())
+	if err != nil {
+		return err
+	}
+	log.Successf("Removed Environment %s successfully.", eo.envName)
+	return nil
+}
+
+// NewCmdRemoveEnv creates the project remove environment command.
+func NewCmdRemoveEnv(name, fullName string) *cobra.Command {
+	o := NewRemoveEnvParameters()
+
+	removeEnvCmd := &cobra.Command{
+		Use:     name,
+		Short:   removeEnvShortDesc,
+		Long:    removeEnvLongDesc,
+		Example: fmt.Sprintf(removeEnvExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	removeEnvCmd.Flags().StringVar(&o.envName, "env-name", "", "Name of the environment/namespace")
+	_ = removeEnvCmd.MarkFlagRequired("env-name")
+	removeEnvCmd.Flags().StringVar(&o.pipelinesFolder, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	return removeEnvCmd
+}
@@ -0,0 +1,23 @@
+package environment
+
+import "testing"
+
+func TestRemoveCommandWithMissingParams(t *testing.T) {
+	cmdTests := []struct {
+		desc    string
+		flags   []keyValuePair
+		wantErr string
+	}{
+		{"Missing env-name flag",
+			[]keyValuePair{flag("pipelines-folder", "~/pipelines.yaml")},
+			`required flag(s) "env-name" not set`},
+	}
+	for _, tt := range cmdTests {
+		t.Run(tt.desc, func(rt *testing.T) {
+			_, _, err := executeCommand(NewCmdRemoveEnv("remove", "kam pipelines environment"), tt.flags...)
+			if err.Error() != tt.wantErr {
+				rt.Errorf("got %s, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -37,6 +37,11 @@ func MakeRootCmd() *cobra.Command {
 		version.NewCmd(version.RecommendedCommandName, utility.GetFullName(fullName, version.RecommendedCommandName)),
 		webhook.NewCmdWebhook(webhook.RecommendedCommandName, utility.GetFullName(fullName, webhook.RecommendedCommandName)),
 		NewCmdBuild(BuildRecommendedCommandName, utility.GetFullName(fullName, BuildRecommendedCommandName)),
+		NewCmdDiff(DiffRecommendedCommandName, utility.GetFullName(fullName, DiffRecommendedCommandName)),
+		NewCmdPromote(PromoteRecommendedCommandName, utility.GetFullName(fullName, PromoteRecommendedCommandName)),
+		NewCmdMigrate(MigrateRecommendedCommandName, utility.GetFullName(fullName, MigrateRecommendedCommandName)),
+		NewCmdSchema(SchemaRecommendedCommandName, utility.GetFullName(fullName, SchemaRecommendedCommandName)),
+		NewCmdValidate(ValidateRecommendedCommandName, utility.GetFullName(fullName, ValidateRecommendedCommandName)),
 		completionCmd,
 		bootstrapnew.NewCmdBootstrapNew(bootstrapnew.BootstrapRecommendedCommandName, utility.GetFullName(fullName, bootstrapnew.BootstrapRecommendedCommandName)),
 		component.NewCmdComp(component.CompRecommendedCommandName, utility.GetFullName(fullName, component.CompRecommendedCommandName)),
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/cmd/ui"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// MigrateRecommendedCommandName the recommended command name
+	MigrateRecommendedCommandName = "migrate"
+)
+
+var (
+	migrateExample = ktemplates.Examples(`
+	# Upgrade the pipelines.yaml and the generated files to the latest version,
+	# confirming the changes before they're written
+	%[1]s
+
+	# Upgrade without confirming the changes
+	%[1]s --yes
+
+	# Show the changes that the upgrade would make, without writing them
+	%[1]s --dry-run
+	`)
+
+	migrateLongDesc  = ktemplates.LongDesc(`Upgrade the pipelines.yaml in the pipelines folder to the latest manifest version, and rebuild the GitOps files, showing the changes as a unified diff, and only writing them once they're confirmed, or with --yes`)
+	migrateShortDesc = `Upgrade the manifest to the latest version`
+)
+
+// MigrateParameters encapsulates the parameters for the kam migrate command.
+type MigrateParameters struct {
+	pipelinesFolderPath string
+	output              string // path to add the Gitops resources
+	dryRun              bool
+	yes                 bool
+	confirm             func() bool // Asks to write the changes, nil if there's no terminal to ask in.
+
+	fs  afero.Fs
+	out io.Writer
+}
+
+// NewMigrateParameters bootstraps a MigrateParameters instance.
+func NewMigrateParameters() *MigrateParameters {
+	return &MigrateParameters{fs: ioutils.NewFilesystem(), out: os.Stdout}
+}
+
+// Complete completes MigrateParameters after they've been created.
+func (io *MigrateParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		io.confirm = ui.SelectOptionWriteMigration
+	}
+	return nil
+}
+
+// Validate validates the parameters of the MigrateParameters.
+func (io *MigrateParameters) Validate() error {
+	return nil
+}
+
+// Run runs the migrate command.
+func (io *MigrateParameters) Run() error {
+	options := pipelines.MigrateParameters{
+		PipelinesFolderPath: io.pipelinesFolderPath,
+		OutputPath:          io.output,
+		DryRun:              true,
+	}
+	diffs, err := pipelines.Migrate(&options, io.fs)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		log.Success("The manifest is already at the latest version.")
+		return nil
+	}
+	for _, d := range diffs {
+		fmt.Fprint(io.out, d.Diff)
+	}
+	if io.dryRun {
+		log.Infof("The migration would change %d file(s), rerun without --dry-run to write them.", len(diffs))
+		return nil
+	}
+	if !io.yes {
+		if io.confirm == nil {
+			return fmt.Errorf("the migration would change %d file(s), rerun with --yes to write them", len(diffs))
+		}
+		if !io.confirm() {
+			log.Info("The migration was not written.")
+			return nil
+		}
+	}
+	options.DryRun = false
+	if _, err := pipelines.Migrate(&options, io.fs); err != nil {
+		return err
+	}
+	log.Successf("Migrated the manifest to the latest version, changed %d file(s).", len(diffs))
+	return nil
+}
+
+// NewCmdMigrate creates the migrate command.
+func NewCmdMigrate(name, fullName string) *cobra.Command {
+	o := NewMigrateParameters()
+	migrateCmd := &cobra.Command{
+		Use:     name,
+		Short:   migrateShortDesc,
+		Long:    migrateLongDesc,
+		Example: fmt.Sprintf(migrateExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	migrateCmd.Flags().StringVar(&o.output, "output", ".", "Folder path to add GitOps resources")
+	migrateCmd.Flags().StringVar(&o.pipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	migrateCmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Show the changes that the migration would make, without writing any files")
+	migrateCmd.Flags().BoolVar(&o.yes, "yes", false, "Write the changes that the migration makes without asking for confirmation")
+	return migrateCmd
+}
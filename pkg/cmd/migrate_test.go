@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/test"
+)
+
+func TestMigrateRun(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	manifest := "gitops_url: " + gitOpsURL + "\nenvironments:\n- name: test-dev\n"
+	if err := afero.WriteFile(fakeFs, "/pipelines.yaml", []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	o := &MigrateParameters{pipelinesFolderPath: "/", output: "/", yes: true, fs: fakeFs, out: &out}
+	if err := o.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "+version: 3") {
+		t.Fatalf("Run() output missing the version change:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := o.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("Run() of the latest version got output:\n%s", out.String())
+	}
+}
+
+func TestMigrateRunWithoutConfirmation(t *testing.T) {
+	manifest := "gitops_url: " + gitOpsURL + "\nenvironments:\n- name: test-dev\n"
+	confirmTests := []struct {
+		name    string
+		confirm func() bool
+		wantErr string
+	}{
+		{"no terminal", nil, "the migration would change .* file\\(s\\), rerun with --yes to write them"},
+		{"declined", func() bool { return false }, ""},
+	}
+
+	for _, tt := range confirmTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			fakeFs := ioutils.NewMemoryFilesystem()
+			if err := afero.WriteFile(fakeFs, "/pipelines.yaml", []byte(manifest), 0644); err != nil {
+				rt.Fatal(err)
+			}
+			var out bytes.Buffer
+			o := &MigrateParameters{pipelinesFolderPath: "/", output: "/", confirm: tt.confirm, fs: fakeFs, out: &out}
+
+			err := o.Run()
+			test.AssertErrorMatch(rt, tt.wantErr, err)
+			if !strings.Contains(out.String(), "+version: 3") {
+				rt.Fatalf("Run() didn't show the diff:\n%s", out.String())
+			}
+			body, err := afero.ReadFile(fakeFs, "/pipelines.yaml")
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if diff := cmp.Diff(manifest, string(body)); diff != "" {
+				rt.Fatalf("Run() wrote the manifest without confirmation:\n%s", diff)
+			}
+		})
+	}
+}
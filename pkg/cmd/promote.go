@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/openshift/odo/pkg/log"
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// PromoteRecommendedCommandName the recommended command name
+	PromoteRecommendedCommandName = "promote"
+)
+
+var (
+	promoteExample = ktemplates.Examples(`
+	# Promote a service's configuration from the dev environment to the stage environment
+	%[1]s --service taxi --from dev --to stage
+
+	# Promote the service, and open a pull request with the change in the GitOps repository
+	%[1]s --service taxi --from dev --to stage --pull-request
+	`)
+
+	promoteLongDesc  = ktemplates.LongDesc(`Copy a service's configuration, including the image and overlays, from one environment to another, adding the service to the target environment if it's not already there`)
+	promoteShortDesc = `Promote a service from one environment to another`
+)
+
+// PromoteParameters encapsulates the parameters for the kam promote command.
+type PromoteParameters struct {
+	*pipelines.PromoteOptions
+	*pipelines.PullRequestOptions
+	pullRequest bool
+
+	fs afero.Fs
+}
+
+// NewPromoteParameters bootstraps a PromoteParameters instance.
+func NewPromoteParameters() *PromoteParameters {
+	return &PromoteParameters{
+		PromoteOptions:     &pipelines.PromoteOptions{},
+		PullRequestOptions: &pipelines.PullRequestOptions{},
+		fs:                 ioutils.NewFilesystem(),
+	}
+}
+
+// Complete completes PromoteParameters after they've been created.
+func (io *PromoteParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the PromoteParameters.
+func (io *PromoteParameters) Validate() error {
+	if io.FromEnvName == io.ToEnvName {
+		return fmt.Errorf("the --from and --to environments must be different")
+	}
+	return nil
+}
+
+// Run runs the promote command.
+func (io *PromoteParameters) Run() error {
+	promoted, err := pipelines.Promote(io.PromoteOptions, io.fs)
+	if err != nil {
+		return err
+	}
+	log.Successf("Promoted service %s from environment %s to %s, copied %d file(s).", io.ServiceName, io.FromEnvName, io.ToEnvName, len(promoted.Copied))
+	if !io.pullRequest {
+		return nil
+	}
+	link, err := pipelines.PromotePullRequest(io.PromoteOptions, io.PullRequestOptions, promoted, factory.FromRepoURL, pipelines.NewCmdExecutor(), io.fs)
+	if err != nil {
+		return err
+	}
+	log.Successf("Opened pull request %s", link)
+	return nil
+}
+
+// NewCmdPromote creates the promote command.
+func NewCmdPromote(name, fullName string) *cobra.Command {
+	o := NewPromoteParameters()
+	promoteCmd := &cobra.Command{
+		Use:     name,
+		Short:   promoteShortDesc,
+		Long:    promoteLongDesc,
+		Example: fmt.Sprintf(promoteExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	promoteCmd.Flags().StringVar(&o.ServiceName, "service", "", "Name of the service to promote")
+	promoteCmd.Flags().StringVar(&o.AppName, "app-name", "", "Name of the application the service is in, only needed if the service is in more than one application")
+	promoteCmd.Flags().StringVar(&o.FromEnvName, "from", "", "Name of the environment to promote the service from")
+	promoteCmd.Flags().StringVar(&o.ToEnvName, "to", "", "Name of the environment to promote the service to")
+	promoteCmd.Flags().StringVar(&o.PipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	promoteCmd.Flags().BoolVar(&o.pullRequest, "pull-request", false, "Commit the promotion to a new branch, push it and open a pull request in the GitOps repository")
+	promoteCmd.Flags().StringVar(&o.Branch, "branch", "", "Branch to push the promotion to, defaults to promote-<service>-<from>-to-<to>")
+	promoteCmd.Flags().StringVar(&o.BaseBranch, "base-branch", "main", "Branch to open the pull request against")
+	promoteCmd.Flags().StringVar(&o.GitHostAccessToken, "git-host-access-token", "", "Access token to be used to open the pull request, if not provided the token stored by keyring is used")
+
+	// required flags
+	_ = promoteCmd.MarkFlagRequired("service")
+	_ = promoteCmd.MarkFlagRequired("from")
+	_ = promoteCmd.MarkFlagRequired("to")
+	return promoteCmd
+}
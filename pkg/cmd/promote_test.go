@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/redhat-developer/kam/pkg/pipelines"
+)
+
+func TestPromoteValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr string
+	}{
+		{"different environments", "dev", "stage", ""},
+		{"same environment", "dev", "dev", "must be different"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(rt *testing.T) {
+			o := &PromoteParameters{PromoteOptions: &pipelines.PromoteOptions{FromEnvName: tt.from, ToEnvName: tt.to}}
+			err := o.Validate()
+			if !matchError(rt, tt.wantErr, err) {
+				rt.Errorf("Validate() got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
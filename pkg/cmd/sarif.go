@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/redhat-developer/kam/pkg/cmd/version"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	kamURI       = "https://github.com/redhat-developer/kam"
+)
+
+// sarifLog is the subset of the SARIF format that's needed to report the
+// problems found by kam validate.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func newSARIFLog(problems []pipelines.Problem) *sarifLog {
+	rules := []sarifRule{}
+	for _, r := range pipelines.ValidationRules {
+		rules = append(rules, sarifRule{ID: r.ID, ShortDescription: sarifMessage{Text: r.Description}})
+	}
+	results := []sarifResult{}
+	for _, p := range problems {
+		locations := []sarifLocation{}
+		for _, l := range p.Locations {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(l.File)},
+				},
+			}
+			if l.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: l.Line}
+			}
+			locations = append(locations, location)
+		}
+		results = append(results, sarifResult{
+			RuleID:    p.Rule,
+			Level:     "error",
+			Message:   sarifMessage{Text: p.Message},
+			Locations: locations,
+		})
+	}
+	return &sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "kam",
+						Version:        version.Version,
+						InformationURI: kamURI,
+						Rules:          rules,
+					},
+				},
+				Results: results,
+			},
+		},
+	}
+}
+
+// sarifURI returns the URI for a file, relative paths are relative to the
+// directory that kam was run in, which is usually the root of the repository.
+func sarifURI(path string) string {
+	uri := filepath.ToSlash(filepath.Clean(path))
+	if filepath.IsAbs(path) {
+		return "file://" + uri
+	}
+	return uri
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/openshift/odo/pkg/log"
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// SchemaRecommendedCommandName the recommended command name
+	SchemaRecommendedCommandName = "schema"
+)
+
+var (
+	schemaExample = ktemplates.Examples(`
+	# Print the JSON Schema for pipelines.yaml
+	%[1]s
+
+	# Write the JSON Schema to a file, for an editor to use
+	%[1]s --output pipelines.schema.json
+	`)
+
+	schemaLongDesc  = ktemplates.LongDesc(`Print a JSON Schema for pipelines.yaml, editors that support JSON Schema can use it to validate and complete the manifest`)
+	schemaShortDesc = `Print the JSON Schema for the manifest`
+)
+
+// SchemaParameters encapsulates the parameters for the kam schema command.
+type SchemaParameters struct {
+	output string // path to write the schema to, the schema is printed if empty
+
+	fs  afero.Fs
+	out io.Writer
+}
+
+// NewSchemaParameters bootstraps a SchemaParameters instance.
+func NewSchemaParameters() *SchemaParameters {
+	return &SchemaParameters{fs: ioutils.NewFilesystem(), out: os.Stdout}
+}
+
+// Complete completes SchemaParameters after they've been created.
+func (io *SchemaParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the SchemaParameters.
+func (io *SchemaParameters) Validate() error {
+	return nil
+}
+
+// Run runs the schema command.
+func (io *SchemaParameters) Run() error {
+	body, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the schema: %w", err)
+	}
+	body = append(body, '\n')
+	if io.output == "" {
+		_, err := io.out.Write(body)
+		return err
+	}
+	path, err := homedir.Expand(io.output)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	if err := afero.WriteFile(io.fs, path, body, 0644); err != nil {
+		return fmt.Errorf("failed to write the schema: %w", err)
+	}
+	log.Successf("Wrote the schema to %s", path)
+	return nil
+}
+
+// NewCmdSchema creates the schema command.
+func NewCmdSchema(name, fullName string) *cobra.Command {
+	o := NewSchemaParameters()
+	schemaCmd := &cobra.Command{
+		Use:     name,
+		Short:   schemaShortDesc,
+		Long:    schemaLongDesc,
+		Example: fmt.Sprintf(schemaExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	schemaCmd.Flags().StringVar(&o.output, "output", "", "File path to write the schema to, the schema is printed if omitted")
+	return schemaCmd
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+)
+
+func TestSchemaRun(t *testing.T) {
+	want, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = append(want, '\n')
+
+	var out bytes.Buffer
+	o := &SchemaParameters{fs: ioutils.NewMemoryFilesystem(), out: &out}
+	if err := o.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), out.String()); diff != "" {
+		t.Fatalf("Run() failed to print the schema:\n%s", diff)
+	}
+
+	o.output = "/pipelines.schema.json"
+	if err := o.Run(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := afero.ReadFile(o.fs, "/pipelines.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Fatalf("Run() failed to write the schema:\n%s", diff)
+	}
+}
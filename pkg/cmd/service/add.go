@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/openshift/odo/pkg/log"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/redhat-developer/kam/pkg/cmd/utility"
 	"github.com/redhat-developer/kam/pkg/pipelines"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	tektonpipelines "github.com/redhat-developer/kam/pkg/pipelines/pipelines"
 	"github.com/spf13/cobra"
 
 	ktemplates "k8s.io/kubectl/pkg/util/templates"
@@ -38,6 +40,9 @@ type AddServiceOptions struct {
 // Complete is called when the command is completed
 func (o *AddServiceOptions) Complete(name string, cmd *cobra.Command, args []string) error {
 	o.GitRepoURL = utility.AddGitSuffixIfNecessary(o.GitRepoURL)
+	if len(o.SOPSAgeRecipients) > 0 {
+		o.SOPS = true
+	}
 	return nil
 }
 
@@ -55,6 +60,9 @@ func (o *AddServiceOptions) Run() error {
 	}
 
 	log.Successf("Created Service %s successfully at environment %s.\n", o.ServiceName, o.EnvName)
+	if o.SealedSecretsCert != "" || o.SOPS {
+		return nil
+	}
 	log.Info(" WARNING: Generated secrets are not encrypted. Deploying the GitOps configuration without encrypting secrets is insecure and is not recommended.\n For more information on secret management see: https://github.com/redhat-developer/kam/tree/master/docs/journey/day1#secrets\n")
 	return nil
 }
@@ -78,6 +86,10 @@ func newCmdAdd(name, fullName string) *cobra.Command {
 	cmd.Flags().StringVar(&o.ServiceName, "service-name", "", "Name of the service to be added")
 	cmd.Flags().StringVar(&o.EnvName, "env-name", "", "Name of the environment where the service will be added")
 	cmd.Flags().StringVar(&o.ImageRepo, "image-repo", "", "Image registry of the form <registry>/<username>/<image name> or <project>/<app> which is used to push newly built images")
+	cmd.Flags().StringVar(&o.SealedSecretsCert, "sealed-secrets-cert", "", "Path to a Sealed Secrets certificate, if provided the webhook secret is sealed and written to the GitOps repository")
+	cmd.Flags().BoolVar(&o.SOPS, "sops", false, "If true, the webhook secret is encrypted with SOPS, for the age recipients in the .sops.yaml in the pipelines folder, and written to the GitOps repository")
+	cmd.Flags().StringSliceVar(&o.SOPSAgeRecipients, "sops-age-recipients", nil, "The age recipients to encrypt the webhook secret for with SOPS, implies --sops")
+	cmd.Flags().StringVar(&o.PipelineTemplate, "pipeline-template", "", fmt.Sprintf("Template from the catalog of pipelines to build the service's image with, one of %s (default buildah)", strings.Join(tektonpipelines.TemplateNames(), ", ")))
 	cmd.Flags().StringVar(&o.PipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
 
 	// required flags
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/pkg/pipelines/webhook"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	removeRecommendedCommandName = "remove"
+)
+
+var (
+	removeExample = ktemplates.Examples(`
+	# Remove a Service from an environment in GitOps
+	# Example: kam service remove --env-name new-env --app-name app-bus --service-name bus --pipelines-folder <path to GitOps file>
+
+	%[1]s`)
+
+	removeLongDesc  = ktemplates.LongDesc(`Remove a Service from an environment in GitOps, deleting the files that were generated for it`)
+	removeShortDesc = `Remove a service`
+)
+
+// RemoveServiceOptions encapsulates the parameters for service remove command
+type RemoveServiceOptions struct {
+	*pipelines.RemoveServiceOptions
+	deleteWebhook bool
+	accessToken   string
+
+	deleteWebhookFunc func(accessToken, pipelinesFile, gitRepoURL string) ([]string, error)
+}
+
+// Complete is called when the command is completed
+func (o *RemoveServiceOptions) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the RemoveServiceOptions.
+func (o *RemoveServiceOptions) Validate() error {
+	return nil
+}
+
+// Run runs the service remove command.
+func (o *RemoveServiceOptions) Run() error {
+	// the service is removed, and the resources rebuilt, before the webhook is
+	// deleted, so that the webhook is kept if the manifest can't be updated.
+	svc, err := pipelines.RemoveService(o.RemoveServiceOptions, ioutils.NewFilesystem())
+	if err != nil {
+		return err
+	}
+	log.Successf("Removed Service %s successfully from environment %s.\n", o.ServiceName, o.EnvName)
+	if o.deleteWebhook && svc.SourceURL != "" {
+		ids, err := o.deleteWebhookFunc(o.accessToken, o.PipelinesFolderPath, svc.SourceURL)
+		if err != nil {
+			return fmt.Errorf("failed to delete the webhook for service %s: %w", o.ServiceName, err)
+		}
+		log.Successf("Deleted %d webhook(s) for service %s.", len(ids), o.ServiceName)
+	}
+	return nil
+}
+
+func newCmdRemove(name, fullName string) *cobra.Command {
+	o := &RemoveServiceOptions{RemoveServiceOptions: &pipelines.RemoveServiceOptions{}, deleteWebhookFunc: webhook.DeleteForRepository}
+
+	cmd := &cobra.Command{
+		Use:     name,
+		Short:   removeShortDesc,
+		Long:    removeLongDesc,
+		Example: fmt.Sprintf(removeExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&o.AppName, "app-name", "", "Name of the application where the service will be removed from")
+	cmd.Flags().StringVar(&o.ServiceName, "service-name", "", "Name of the service to be removed")
+	cmd.Flags().StringVar(&o.EnvName, "env-name", "", "Name of the environment where the service will be removed from")
+	cmd.Flags().StringVar(&o.PipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	cmd.Flags().BoolVar(&o.deleteWebhook, "delete-webhook", false, "Delete the webhook for the service's source repository")
+	cmd.Flags().StringVar(&o.accessToken, "git-host-access-token", "", "Access token to be used to delete the Git repository webhook, if not provided the token stored by keyring is used")
+
+	// required flags
+	_ = cmd.MarkFlagRequired("service-name")
+	_ = cmd.MarkFlagRequired("app-name")
+	_ = cmd.MarkFlagRequired("env-name")
+	return cmd
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/redhat-developer/kam/pkg/pipelines"
+)
+
+func TestRemoveCommandWithMissingParams(t *testing.T) {
+	cmdTests := []struct {
+		desc    string
+		flags   []keyValuePair
+		wantErr string
+	}{
+		{"Missing app-name flag",
+			[]keyValuePair{flag("service-name", "sample"), flag("env-name", "test")},
+			`required flag(s) "app-name" not set`},
+		{"Missing service-name flag",
+			[]keyValuePair{flag("app-name", "app"), flag("env-name", "test")},
+			`required flag(s) "service-name" not set`},
+		{"Missing env-name flag",
+			[]keyValuePair{flag("app-name", "app"), flag("service-name", "sample")},
+			`required flag(s) "env-name" not set`},
+	}
+	for _, tt := range cmdTests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, _, err := executeCommand(newCmdRemove("remove", "kam pipelines service"), tt.flags...)
+			if err.Error() != tt.wantErr {
+				t.Errorf("got %s, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemoveWithInvalidManifestKeepsWebhook(t *testing.T) {
+	called := false
+	o := &RemoveServiceOptions{
+		RemoveServiceOptions: &pipelines.RemoveServiceOptions{
+			EnvName:             "dev",
+			AppName:             "app",
+			ServiceName:         "svc",
+			PipelinesFolderPath: "/does/not/exist",
+		},
+		deleteWebhook: true,
+		accessToken:   "token",
+		deleteWebhookFunc: func(accessToken, pipelinesFile, gitRepoURL string) ([]string, error) {
+			called = true
+			return nil, nil
+		},
+	}
+	if err := o.Run(); err == nil {
+		t.Fatal("Run() didn't fail for a missing manifest")
+	}
+	if called {
+		t.Fatal("webhook was deleted before the service was removed")
+	}
+}
+
+func TestRemoveDeletesWebhookAfterRemoval(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `config:
+  pipelines:
+    name: cicd
+environments:
+- name: dev
+  apps:
+  - name: app
+    services:
+    - name: svc
+      source_url: https://github.com/org/svc.git
+    - name: other
+`
+	if err := os.WriteFile(filepath.Join(dir, "pipelines.yaml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var gotURL string
+	o := &RemoveServiceOptions{
+		RemoveServiceOptions: &pipelines.RemoveServiceOptions{
+			EnvName:             "dev",
+			AppName:             "app",
+			ServiceName:         "svc",
+			PipelinesFolderPath: dir,
+		},
+		deleteWebhook: true,
+		accessToken:   "token",
+		deleteWebhookFunc: func(accessToken, pipelinesFile, gitRepoURL string) ([]string, error) {
+			body, err := os.ReadFile(filepath.Join(pipelinesFile, "pipelines.yaml"))
+			if err != nil {
+				return nil, err
+			}
+			if strings.Contains(string(body), "name: svc") {
+				return nil, errors.New("the service is still in the manifest")
+			}
+			gotURL = gitRepoURL
+			return nil, errors.New("no cluster")
+		},
+	}
+	err := o.Run()
+	want := "failed to delete the webhook for service svc: no cluster"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Run() got error %v, want %q", err, want)
+	}
+	if gotURL != "https://github.com/org/svc.git" {
+		t.Fatalf("webhook deleted for the wrong repository: %q", gotURL)
+	}
+}
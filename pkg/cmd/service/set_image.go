@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/spf13/cobra"
+
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	setImageRecommendedCommandName = "set-image"
+)
+
+var (
+	setImageExample = ktemplates.Examples(`
+	# Set the image of a Service in an environment in GitOps
+	# Example: kam service set-image --env-name new-env --app-name app-bus --service-name bus --image quay.io/org/bus:v1.0.0 --pipelines-folder <path to GitOps file>
+
+	%[1]s`)
+
+	setImageLongDesc = ktemplates.LongDesc(`Set the image of a Service in an environment in GitOps.
+
+	The image is recorded in the manifest, and set in the service's overlay with a kustomize images entry, the image in the service's resources is replaced.
+
+	Only the manifest and the service's overlay are changed, so this can be run from a pipeline to update the image that's deployed.`)
+	setImageShortDesc = `Set the image of a service`
+)
+
+// SetImageOptions encapsulates the parameters for service set-image command
+type SetImageOptions struct {
+	*pipelines.SetServiceImageOptions
+}
+
+// Complete is called when the command is completed
+func (o *SetImageOptions) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the SetImageOptions.
+func (o *SetImageOptions) Validate() error {
+	return nil
+}
+
+// Run runs the service set-image command.
+func (o *SetImageOptions) Run() error {
+	err := pipelines.SetServiceImage(o.SetServiceImageOptions, ioutils.NewFilesystem())
+	if err != nil {
+		return err
+	}
+	log.Successf("Set the image of Service %s in environment %s to %s.\n", o.ServiceName, o.EnvName, o.Image)
+	return nil
+}
+
+func newCmdSetImage(name, fullName string) *cobra.Command {
+	o := &SetImageOptions{SetServiceImageOptions: &pipelines.SetServiceImageOptions{}}
+
+	cmd := &cobra.Command{
+		Use:     name,
+		Short:   setImageShortDesc,
+		Long:    setImageLongDesc,
+		Example: fmt.Sprintf(setImageExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&o.AppName, "app-name", "", "Name of the application of the service")
+	cmd.Flags().StringVar(&o.ServiceName, "service-name", "", "Name of the service to set the image of")
+	cmd.Flags().StringVar(&o.EnvName, "env-name", "", "Name of the environment of the service")
+	cmd.Flags().StringVar(&o.Image, "image", "", "Image to deploy, with a tag or digest, e.g. quay.io/org/service:v1.0.0")
+	cmd.Flags().StringVar(&o.ImageName, "image-name", "", "Image in the service's resources to replace, by default it's found from the resources the first time the image is set")
+	cmd.Flags().StringVar(&o.PipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+
+	// required flags
+	_ = cmd.MarkFlagRequired("service-name")
+	_ = cmd.MarkFlagRequired("app-name")
+	_ = cmd.MarkFlagRequired("env-name")
+	_ = cmd.MarkFlagRequired("image")
+	return cmd
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestSetImageCommandWithMissingParams(t *testing.T) {
+	cmdTests := []struct {
+		desc    string
+		flags   []keyValuePair
+		wantErr string
+	}{
+		{"Missing app-name flag",
+			[]keyValuePair{flag("service-name", "sample"), flag("env-name", "test"), flag("image", "quay.io/org/sample:v1")},
+			`required flag(s) "app-name" not set`},
+		{"Missing service-name flag",
+			[]keyValuePair{flag("app-name", "app"), flag("env-name", "test"), flag("image", "quay.io/org/sample:v1")},
+			`required flag(s) "service-name" not set`},
+		{"Missing env-name flag",
+			[]keyValuePair{flag("app-name", "app"), flag("service-name", "sample"), flag("image", "quay.io/org/sample:v1")},
+			`required flag(s) "env-name" not set`},
+		{"Missing image flag",
+			[]keyValuePair{flag("app-name", "app"), flag("service-name", "sample"), flag("env-name", "test")},
+			`required flag(s) "image" not set`},
+	}
+	for _, tt := range cmdTests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, _, err := executeCommand(newCmdSetImage("set-image", "kam service set-image"), tt.flags...)
+			if err.Error() != tt.wantErr {
+				t.Errorf("got %s, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -142,6 +142,18 @@ func EnterGitHostAccessToken(serviceRepo string) string {
 	return accessToken
 }
 
+// EnterCosignPassword prompts for the password of the cosign private key.
+func EnterCosignPassword() string {
+	var password string
+	prompt := &survey.Password{
+		Message: "Please provide the password of the cosign private key",
+		Help:    "The password is stored with the key in the secret that the pipelines sign images with, it can also be provided with the COSIGN_PASSWORD environment variable.",
+	}
+	err := survey.AskOne(prompt, &password, nil)
+	handleError(err)
+	return password
+}
+
 // EnterPrefix , if we desire to add the prefix to differentiate between namespaces, then this is the way forward.
 func EnterPrefix() string {
 	var prefix string
@@ -206,13 +218,26 @@ func SelectOptionOverwrite(currentPath string) bool {
 	return overwrite == "yes"
 }
 
+// SelectOptionWriteMigration asks users to confirm writing the changes that
+// the migration of the manifest makes.
+func SelectOptionWriteMigration() bool {
+	var write string
+	prompt := &survey.Select{
+		Message: "Do you want to write these changes?",
+		Options: []string{"yes", "no"},
+		Default: "no",
+	}
+	handleError(survey.AskOne(prompt, &write, nil))
+	return write == "yes"
+}
+
 // SelectPrivateRepoDriver lets users choose the driver for their git hosting
 // service.
 func SelectPrivateRepoDriver() string {
 	var driver string
 	prompt := &survey.Select{
 		Message: "Please select which driver to use for your Git host",
-		Options: []string{"github", "gitlab"},
+		Options: []string{"github", "gitlab", "stash", "gitea"},
 	}
 
 	err := survey.AskOne(prompt, &driver, survey.Required)
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/openshift/odo/pkg/log"
+	"github.com/redhat-developer/kam/pkg/cmd/genericclioptions"
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	ktemplates "k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// ValidateRecommendedCommandName the recommended command name
+	ValidateRecommendedCommandName = "validate"
+
+	formatText  = "text"
+	formatJSON  = "json"
+	formatSARIF = "sarif"
+)
+
+var (
+	validateExample = ktemplates.Examples(`
+	# Validate the pipelines.yaml and the generated files
+	%[1]s
+
+	# Write the problems as SARIF, for annotating pull requests in CI
+	%[1]s --format sarif > kam.sarif
+	`)
+
+	validateLongDesc  = ktemplates.LongDesc(`Validate the pipelines.yaml in the pipelines folder, and check the generated files for kustomizations that reference missing files, Argo CD Applications with paths that don't exist, and EventListener triggers that reference unknown templates or bindings`)
+	validateShortDesc = `Validate the manifest and the generated files`
+)
+
+// ValidateParameters encapsulates the parameters for the kam validate command.
+type ValidateParameters struct {
+	pipelinesFolderPath string
+	output              string // path to the Gitops resources
+	format              string
+
+	fs  afero.Fs
+	out io.Writer
+}
+
+// NewValidateParameters bootstraps a ValidateParameters instance.
+func NewValidateParameters() *ValidateParameters {
+	return &ValidateParameters{fs: ioutils.NewFilesystem(), out: os.Stdout}
+}
+
+// Complete completes ValidateParameters after they've been created.
+func (io *ValidateParameters) Complete(name string, cmd *cobra.Command, args []string) error {
+	return nil
+}
+
+// Validate validates the parameters of the ValidateParameters.
+func (io *ValidateParameters) Validate() error {
+	switch io.format {
+	case formatText, formatJSON, formatSARIF:
+		return nil
+	}
+	return fmt.Errorf("invalid format %q, must be one of %s", io.format, strings.Join([]string{formatText, formatJSON, formatSARIF}, ", "))
+}
+
+// Run runs the validate command, it returns an error if any problems are
+// found, so that the command fails.
+func (io *ValidateParameters) Run() error {
+	options := pipelines.ValidateParameters{
+		PipelinesFolderPath: io.pipelinesFolderPath,
+		OutputPath:          io.output,
+	}
+	problems, err := pipelines.Validate(&options, io.fs)
+	if err != nil {
+		return err
+	}
+	switch io.format {
+	case formatJSON:
+		err = writeJSON(io.out, problems)
+	case formatSARIF:
+		err = writeJSON(io.out, newSARIFLog(problems))
+	default:
+		writeProblems(io.out, problems)
+	}
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s)", len(problems))
+	}
+	if io.format == formatText {
+		log.Success("The manifest and the generated files are valid.")
+	}
+	return nil
+}
+
+func writeProblems(out io.Writer, problems []pipelines.Problem) {
+	for _, p := range problems {
+		for i, l := range p.Locations {
+			location := l.File
+			if l.Line > 0 {
+				location = fmt.Sprintf("%s:%d", location, l.Line)
+			}
+			if i == 0 {
+				fmt.Fprintf(out, "%s: %s [%s]\n", location, p.Message, p.Rule)
+				continue
+			}
+			fmt.Fprintf(out, "  also at %s\n", location)
+		}
+	}
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", body)
+	return err
+}
+
+// NewCmdValidate creates the validate command.
+func NewCmdValidate(name, fullName string) *cobra.Command {
+	o := NewValidateParameters()
+	validateCmd := &cobra.Command{
+		Use:     name,
+		Short:   validateShortDesc,
+		Long:    validateLongDesc,
+		Example: fmt.Sprintf(validateExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			genericclioptions.GenericRun(o, cmd, args)
+		},
+	}
+
+	validateCmd.Flags().StringVar(&o.output, "output", ".", "Folder path to the GitOps resources")
+	validateCmd.Flags().StringVar(&o.pipelinesFolderPath, "pipelines-folder", ".", "Folder path to retrieve manifest, eg. /test where manifest exists at /test/pipelines.yaml")
+	validateCmd.Flags().StringVar(&o.format, "format", formatText, "Output format, one of text, json or sarif")
+	return validateCmd
+}
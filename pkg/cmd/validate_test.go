@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/test"
+)
+
+const invalidManifest = `environments:
+- name: dev
+  pipelines:
+    integration:
+      binding: dev-binding
+`
+
+func TestValidateValidate(t *testing.T) {
+	o := &ValidateParameters{format: "yaml"}
+	test.AssertErrorMatch(t, `invalid format "yaml", must be one of text, json, sarif`, o.Validate())
+}
+
+func TestValidateRun(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	if err := afero.WriteFile(fakeFs, "pipelines.yaml", []byte(invalidManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	o := &ValidateParameters{pipelinesFolderPath: ".", output: ".", format: formatText, fs: fakeFs, out: &out}
+	test.AssertErrorMatch(t, `found 1 problem\(s\)`, o.Run())
+	want := `pipelines.yaml:5: unknown field "environments.dev.pipelines.integration.binding" [manifest-unknown-field]` + "\n"
+	if diff := cmp.Diff(want, out.String()); diff != "" {
+		t.Fatalf("Run() text output failed:\n%s", diff)
+	}
+
+	out.Reset()
+	o.format = formatJSON
+	test.AssertErrorMatch(t, `found 1 problem\(s\)`, o.Run())
+	var problems []pipelines.Problem
+	if err := json.Unmarshal(out.Bytes(), &problems); err != nil {
+		t.Fatal(err)
+	}
+	wantProblems := []pipelines.Problem{
+		{
+			Rule:      pipelines.RuleManifestUnknownField,
+			Message:   `unknown field "environments.dev.pipelines.integration.binding"`,
+			Locations: []pipelines.Location{{File: "pipelines.yaml", Line: 5, Field: "environments.dev.pipelines.integration.binding"}},
+		},
+	}
+	if diff := cmp.Diff(wantProblems, problems); diff != "" {
+		t.Fatalf("Run() JSON output failed:\n%s", diff)
+	}
+
+	out.Reset()
+	o.format = formatSARIF
+	test.AssertErrorMatch(t, `found 1 problem\(s\)`, o.Run())
+	var log sarifLog
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	wantResults := []sarifResult{
+		{
+			RuleID:  pipelines.RuleManifestUnknownField,
+			Level:   "error",
+			Message: sarifMessage{Text: `unknown field "environments.dev.pipelines.integration.binding"`},
+			Locations: []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: "pipelines.yaml"},
+						Region:           &sarifRegion{StartLine: 5},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantResults, log.Runs[0].Results); diff != "" {
+		t.Fatalf("Run() SARIF output failed:\n%s", diff)
+	}
+	if l := len(log.Runs[0].Tool.Driver.Rules); l != len(pipelines.ValidationRules) {
+		t.Fatalf("Run() SARIF output got %d rules, want %d", l, len(pipelines.ValidationRules))
+	}
+}
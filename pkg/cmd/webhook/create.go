@@ -30,7 +30,7 @@ type createOptions struct {
 
 // Run contains the logic for the kam command
 func (o *createOptions) Run() error {
-	id, err := backend.Create(o.accessToken, o.pipelinesFolderPath, o.getAppServiceNames(), o.isCICD)
+	id, err := backend.Create(o.accessToken, o.pipelinesFolderPath, o.privateRepoDriver, o.getAppServiceNames(), o.isCICD)
 
 	if err != nil {
 		return fmt.Errorf("unable to create webhook: %v", err)
@@ -61,6 +61,24 @@ func TestValidateForCreate(t *testing.T) {
 			},
 			"",
 		},
+		{
+			&createOptions{
+				options{isCICD: true, privateRepoDriver: "stash"},
+			},
+			"",
+		},
+		{
+			&createOptions{
+				options{isCICD: true, privateRepoDriver: "unknown"},
+			},
+			"invalid driver type: \"unknown\"",
+		},
+		{
+			&createOptions{
+				options{isCICD: true, privateRepoDriver: "gogs"},
+			},
+			"unsupported Git repository type: gogs",
+		},
 	}
 
 	for i, tt := range testcases {
@@ -29,7 +29,7 @@ type deleteOptions struct {
 
 // Run contains the logic for the kam command
 func (o *deleteOptions) Run() error {
-	ids, err := backend.Delete(o.accessToken, o.pipelinesFolderPath, o.getAppServiceNames(), o.isCICD)
+	ids, err := backend.Delete(o.accessToken, o.pipelinesFolderPath, o.privateRepoDriver, o.getAppServiceNames(), o.isCICD)
 
 	if len(ids) > 0 {
 		if log.IsJSON() {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/redhat-developer/kam/pkg/pipelines/scm"
 	backend "github.com/redhat-developer/kam/pkg/pipelines/webhook"
 )
 
@@ -13,6 +14,7 @@ type options struct {
 	envName             string
 	isCICD              bool
 	pipelinesFolderPath string
+	privateRepoDriver   string
 	serviceName         string
 }
 
@@ -36,6 +38,13 @@ func (o *options) Validate() (err error) {
 		}
 	}
 
+	if o.privateRepoDriver == "gogs" {
+		return scm.ErrGogsUnsupported
+	}
+	if o.privateRepoDriver != "" && !scm.IsSupportedDriver(o.privateRepoDriver) {
+		return fmt.Errorf("invalid driver type: %q", o.privateRepoDriver)
+	}
+
 	return nil
 }
 
@@ -54,6 +63,9 @@ func (o *options) setFlags(command *cobra.Command) {
 	command.Flags().StringVar(&o.serviceName, "service-name", "", "Provide service name if the target Git repository is a service's source repository.")
 	command.Flags().StringVar(&o.envName, "env-name", "", "Provide environment name if the target Git repository is a service's source repository.")
 
+	// driver option
+	command.Flags().StringVar(&o.privateRepoDriver, "private-repo-driver", "", "If your Git repositories are on a custom domain, please indicate which driver to use github, gitlab, stash (Bitbucket Server) or gitea")
+
 }
 
 func (o *options) getAppServiceNames() *backend.QualifiedServiceName {
@@ -0,0 +1,134 @@
+package argocd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	argoappv1 "github.com/redhat-developer/kam/pkg/pipelines/argocd/v1alpha1"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+)
+
+const (
+	envApplicationSetName = "environments"
+	appApplicationSetName = "apps"
+)
+
+var applicationSetTypeMeta = meta.TypeMeta(
+	"ApplicationSet",
+	"argoproj.io/v1alpha1",
+)
+
+// applicationSetBuilder collects an element for each environment, and each
+// application in each environment, for list generators, so that all the
+// Applications are generated by two ApplicationSets.
+type applicationSetBuilder struct {
+	repoURL      string
+	argoNS       string
+	argoCDConfig *config.ArgoCDConfig
+	manifest     *config.Manifest
+	envElements  []apiextensionsv1.JSON
+	appElements  []apiextensionsv1.JSON
+}
+
+func (b *applicationSetBuilder) Application(env *config.Environment, app *config.Application) error {
+	source := makeAppSource(env, app, b.repoURL)
+	element, err := makeElement(map[string]string{
+		"env":            env.Name,
+		"app":            app.Name,
+		"repoURL":        source.RepoURL,
+		"path":           source.Path,
+		"targetRevision": source.TargetRevision,
+		"project":        projectForEnv(b.argoCDConfig, env),
+	}, destinationForEnv(b.manifest, env))
+	if err != nil {
+		return err
+	}
+	b.appElements = append(b.appElements, element)
+	return nil
+}
+
+func (b *applicationSetBuilder) Environment(env *config.Environment) error {
+	source := makeEnvSource(env, b.repoURL)
+	element, err := makeElement(map[string]string{
+		"env":     env.Name,
+		"repoURL": source.RepoURL,
+		"path":    source.Path,
+		"project": projectForEnv(b.argoCDConfig, env),
+	}, destinationForEnv(b.manifest, env))
+	if err != nil {
+		return err
+	}
+	b.envElements = append(b.envElements, element)
+	return nil
+}
+
+// resources returns the ApplicationSets for the collected elements, keyed by
+// path.
+func (b *applicationSetBuilder) resources() res.Resources {
+	basePath := config.PathForArgoCD()
+	files := res.Resources{}
+	if len(b.envElements) > 0 {
+		files[filepath.ToSlash(filepath.Join(basePath, envApplicationSetName+"-appset.yaml"))] = makeApplicationSet(
+			envApplicationSetName, b.argoNS, b.envElements,
+			argoappv1.ApplicationSetTemplateMeta{
+				Name:        "{{env}}-env",
+				Namespace:   b.argoNS,
+				Annotations: map[string]string{SyncWaveAnnotation: strconv.Itoa(envSyncWave)},
+			},
+			argoappv1.ApplicationSource{RepoURL: "{{repoURL}}", Path: "{{path}}"})
+	}
+	if len(b.appElements) > 0 {
+		files[filepath.ToSlash(filepath.Join(basePath, appApplicationSetName+"-appset.yaml"))] = makeApplicationSet(
+			appApplicationSetName, b.argoNS, b.appElements,
+			argoappv1.ApplicationSetTemplateMeta{
+				Name:      "{{env}}-{{app}}",
+				Namespace: b.argoNS,
+				Labels:    map[string]string{appLabel: "{{app}}"},
+			},
+			argoappv1.ApplicationSource{RepoURL: "{{repoURL}}", Path: "{{path}}", TargetRevision: "{{targetRevision}}"})
+	}
+	return files
+}
+
+// makeElement creates a list generator element with the parameters and the
+// destination, both the server and cluster name parameters are always set, as
+// only one of them is set in a destination, the other is empty.
+func makeElement(params map[string]string, destination argoappv1.ApplicationDestination) (apiextensionsv1.JSON, error) {
+	params["server"] = destination.Server
+	params["cluster"] = destination.Name
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return apiextensionsv1.JSON{}, err
+	}
+	return apiextensionsv1.JSON{Raw: raw}, nil
+}
+
+func makeApplicationSet(name, argoNS string, elements []apiextensionsv1.JSON, templateMeta argoappv1.ApplicationSetTemplateMeta, source argoappv1.ApplicationSource) *argoappv1.ApplicationSet {
+	return &argoappv1.ApplicationSet{
+		TypeMeta:   applicationSetTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(argoNS, name)),
+		Spec: argoappv1.ApplicationSetSpec{
+			Generators: []argoappv1.ApplicationSetGenerator{
+				{List: &argoappv1.ListGenerator{Elements: elements}},
+			},
+			Template: argoappv1.ApplicationSetTemplate{
+				ApplicationSetTemplateMeta: templateMeta,
+				Spec: argoappv1.ApplicationSpec{
+					Project: "{{project}}",
+					Destination: argoappv1.ApplicationDestination{
+						Namespace: "{{env}}",
+						Server:    "{{server}}",
+						Name:      "{{cluster}}",
+					},
+					Source:     source,
+					SyncPolicy: syncPolicy,
+				},
+			},
+		},
+	}
+}
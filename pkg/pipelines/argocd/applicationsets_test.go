@@ -0,0 +1,136 @@
+package argocd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	argoappv1 "github.com/redhat-developer/kam/pkg/pipelines/argocd/v1alpha1"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+)
+
+func TestBuildCreatesApplicationSets(t *testing.T) {
+	prodEnv := &config.Environment{
+		Name:    "test-prod",
+		Cluster: "prod",
+		Apps: []*config.Application{
+			configRepoApp,
+		},
+	}
+	m := &config.Manifest{
+		Environments: []*config.Environment{
+			testEnv,
+			prodEnv,
+		},
+		Config: &config.Config{
+			ArgoCD: &config.ArgoCDConfig{Namespace: ArgoCDNamespace, ApplicationSets: true},
+			Clusters: []*config.Cluster{
+				{Name: "prod", Server: "https://api.prod.example.com:6443"},
+			},
+		},
+	}
+
+	files, err := Build(ArgoCDNamespace, testRepoURL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := res.Resources{
+		"config/argocd/environments-appset.yaml": &argoappv1.ApplicationSet{
+			TypeMeta:   applicationSetTypeMeta,
+			ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "environments")),
+			Spec: argoappv1.ApplicationSetSpec{
+				Generators: []argoappv1.ApplicationSetGenerator{
+					{
+						List: &argoappv1.ListGenerator{
+							Elements: []apiextensionsv1.JSON{
+								{Raw: []byte(`{"cluster":"","env":"test-dev","path":"environments/test-dev/env/overlays","project":"default","repoURL":"https://github.com/rhd-example-gitops/example","server":"https://kubernetes.default.svc"}`)},
+								{Raw: []byte(`{"cluster":"prod","env":"test-prod","path":"environments/test-prod/env/overlays","project":"default","repoURL":"https://github.com/rhd-example-gitops/example","server":""}`)},
+							},
+						},
+					},
+				},
+				Template: argoappv1.ApplicationSetTemplate{
+					ApplicationSetTemplateMeta: argoappv1.ApplicationSetTemplateMeta{
+						Name:        "{{env}}-env",
+						Namespace:   ArgoCDNamespace,
+						Annotations: map[string]string{"argocd.argoproj.io/sync-wave": "-1"},
+					},
+					Spec: argoappv1.ApplicationSpec{
+						Source:      argoappv1.ApplicationSource{RepoURL: "{{repoURL}}", Path: "{{path}}"},
+						Destination: argoappv1.ApplicationDestination{Namespace: "{{env}}", Server: "{{server}}", Name: "{{cluster}}"},
+						Project:     "{{project}}",
+						SyncPolicy:  syncPolicy,
+					},
+				},
+			},
+		},
+		"config/argocd/apps-appset.yaml": &argoappv1.ApplicationSet{
+			TypeMeta:   applicationSetTypeMeta,
+			ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "apps")),
+			Spec: argoappv1.ApplicationSetSpec{
+				Generators: []argoappv1.ApplicationSetGenerator{
+					{
+						List: &argoappv1.ListGenerator{
+							Elements: []apiextensionsv1.JSON{
+								{Raw: []byte(`{"app":"http-api","cluster":"","env":"test-dev","path":"environments/test-dev/apps/http-api/overlays","project":"default","repoURL":"https://github.com/rhd-example-gitops/example","server":"https://kubernetes.default.svc","targetRevision":""}`)},
+								{Raw: []byte(`{"app":"prod-api","cluster":"prod","env":"test-prod","path":"deploys","project":"default","repoURL":"https://github.com/rhd-example-gitops/other-repo","server":"","targetRevision":"master"}`)},
+							},
+						},
+					},
+				},
+				Template: argoappv1.ApplicationSetTemplate{
+					ApplicationSetTemplateMeta: argoappv1.ApplicationSetTemplateMeta{
+						Name:      "{{env}}-{{app}}",
+						Namespace: ArgoCDNamespace,
+						Labels:    map[string]string{appLabel: "{{app}}"},
+					},
+					Spec: argoappv1.ApplicationSpec{
+						Source:      argoappv1.ApplicationSource{RepoURL: "{{repoURL}}", Path: "{{path}}", TargetRevision: "{{targetRevision}}"},
+						Destination: argoappv1.ApplicationDestination{Namespace: "{{env}}", Server: "{{server}}", Name: "{{cluster}}"},
+						Project:     "{{project}}",
+						SyncPolicy:  syncPolicy,
+					},
+				},
+			},
+		},
+		"config/argocd/argo-app.yaml": fakeArgoApplication(),
+		"config/argocd/kustomization.yaml": &res.Kustomization{
+			Resources: []string{
+				"apps-appset.yaml",
+				"argo-app.yaml",
+				"environments-appset.yaml",
+			},
+		},
+	}
+
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Fatalf("files didn't match: %s\n", diff)
+	}
+}
+
+func TestBuildApplicationSetsWithNoEnvironments(t *testing.T) {
+	m := &config.Manifest{
+		Config: &config.Config{
+			ArgoCD: &config.ArgoCDConfig{Namespace: ArgoCDNamespace, ApplicationSets: true},
+		},
+	}
+
+	files, err := Build(ArgoCDNamespace, testRepoURL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := res.Resources{
+		"config/argocd/argo-app.yaml": fakeArgoApplication(),
+		"config/argocd/kustomization.yaml": &res.Kustomization{
+			Resources: []string{"argo-app.yaml"},
+		},
+	}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Fatalf("files didn't match: %s\n", diff)
+	}
+}
@@ -3,6 +3,7 @@ package argocd
 import (
 	"path/filepath"
 	"sort"
+	"strconv"
 
 	// This is a hack because ArgoCD doesn't support a compatible (code-wise)
 	// version of k8s in common with kam.
@@ -14,7 +15,14 @@ import (
 	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
 )
 
-const appLabel = "app.kubernetes.io/name"
+const (
+	appLabel = "app.kubernetes.io/name"
+	// SyncWaveAnnotation orders the sync of the Applications.
+	SyncWaveAnnotation = "argocd.argoproj.io/sync-wave"
+	// the environment's Application creates the namespace, so it's synced
+	// before the applications in the environment.
+	envSyncWave = -1
+)
 
 var (
 	applicationTypeMeta = meta.TypeMeta(
@@ -80,17 +88,28 @@ func Build(argoNS, repoURL string, m *config.Manifest) (res.Resources, error) {
 		return res.Resources{}, nil
 	}
 
-	files := make(res.Resources)
-	eb := &argocdBuilder{repoURL: repoURL, files: files, argoCDConfig: argoCDConfig, argoNS: argoNS}
-	err := m.Walk(eb)
-	if err != nil {
-		return nil, err
+	var files res.Resources
+	if argoCDConfig.ApplicationSets {
+		asb := &applicationSetBuilder{repoURL: repoURL, argoNS: argoNS, argoCDConfig: argoCDConfig, manifest: m}
+		if err := m.Walk(asb); err != nil {
+			return nil, err
+		}
+		files = asb.resources()
+	} else {
+		eb := &argocdBuilder{repoURL: repoURL, files: res.Resources{}, argoCDConfig: argoCDConfig, argoNS: argoNS, manifest: m}
+		if err := m.Walk(eb); err != nil {
+			return nil, err
+		}
+		files = eb.files
+	}
+	if argoCDConfig.Projects != nil {
+		files = res.Merge(projectResources(argoNS, repoURL, argoCDConfig.Projects, m), files)
 	}
-	err = argoCDConfigResources(m.Config, m.GitOpsURL, eb.files)
+	err := argoCDConfigResources(m.Config, m.GitOpsURL, files)
 	if err != nil {
 		return nil, err
 	}
-	return eb.files, err
+	return files, err
 }
 
 type argocdBuilder struct {
@@ -98,6 +117,7 @@ type argocdBuilder struct {
 	argoCDConfig *config.ArgoCDConfig
 	files        res.Resources
 	argoNS       string
+	manifest     *config.Manifest
 }
 
 func (b *argocdBuilder) Application(env *config.Environment, app *config.Application) error {
@@ -105,11 +125,14 @@ func (b *argocdBuilder) Application(env *config.Environment, app *config.Applica
 	argoFiles := res.Resources{}
 	filename := filepath.ToSlash(filepath.Join(basePath, env.Name+"-"+app.Name+"-app.yaml"))
 
-	argoFiles[filename] = makeApplication(app, env.Name+"-"+app.Name, b.argoNS,
-		defaultProject,
-		env.Name,
-		clusterForEnv(env),
-		makeAppSource(env, app, b.repoURL))
+	var syncWave *int
+	if app.ArgoCD != nil {
+		syncWave = app.ArgoCD.SyncWave
+	}
+	argoFiles[filename] = configureApplication(makeApplication(app, env.Name+"-"+app.Name, b.argoNS,
+		projectForEnv(b.argoCDConfig, env),
+		destinationForEnv(b.manifest, env),
+		makeAppSource(env, app, b.repoURL)), syncWave, env.ArgoCD, app.ArgoCD)
 	b.files = res.Merge(argoFiles, b.files)
 	return nil
 }
@@ -119,13 +142,16 @@ func (b *argocdBuilder) Environment(env *config.Environment) error {
 	argoFiles := res.Resources{}
 	filename := filepath.ToSlash(filepath.Join(basePath, env.Name+"-env-app.yaml"))
 
-	argoFiles[filename] = makeApplication(
+	syncWave := intPtr(envSyncWave)
+	if env.ArgoCD != nil && env.ArgoCD.SyncWave != nil {
+		syncWave = env.ArgoCD.SyncWave
+	}
+	argoFiles[filename] = configureApplication(makeApplication(
 		nil,
 		env.Name+"-env", b.argoNS,
-		defaultProject,
-		env.Name,
-		clusterForEnv(env),
-		makeEnvSource(env, b.repoURL))
+		projectForEnv(b.argoCDConfig, env),
+		destinationForEnv(b.manifest, env),
+		makeEnvSource(env, b.repoURL)), syncWave, env.ArgoCD)
 	b.files = res.Merge(argoFiles, b.files)
 	return nil
 }
@@ -138,11 +164,11 @@ func argoCDConfigResources(cfg *config.Config, repoURL string, files res.Resourc
 	filename := filepath.ToSlash(filepath.Join(basePath, "kustomization.yaml"))
 	files[filepath.ToSlash(filepath.Join(basePath, "argo-app.yaml"))] =
 		ignoreDifferences(makeApplication(nil, "argo-app", cfg.ArgoCD.Namespace,
-			defaultProject, cfg.ArgoCD.Namespace, defaultServer,
+			defaultProject, defaultDestination(cfg.ArgoCD.Namespace),
 			&argoappv1.ApplicationSource{RepoURL: repoURL, Path: basePath}))
 	if cfg.Pipelines != nil {
 		files[filepath.ToSlash(filepath.Join(basePath, "cicd-app.yaml"))] = ignoreDifferences(
-			makeApplication(nil, "cicd-app", cfg.ArgoCD.Namespace, defaultProject, cfg.Pipelines.Name, defaultServer,
+			makeApplication(nil, "cicd-app", cfg.ArgoCD.Namespace, defaultProject, defaultDestination(cfg.Pipelines.Name),
 				&argoappv1.ApplicationSource{RepoURL: repoURL, Path: filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg.Pipelines), "overlays"))}))
 	}
 	resourceNames := []string{}
@@ -182,7 +208,7 @@ func ignoreDifferences(app *argoappv1.Application) *argoappv1.Application {
 	return app
 }
 
-func makeApplication(app *config.Application, appName, argoNS, project, ns, server string, source *argoappv1.ApplicationSource) *argoappv1.Application {
+func makeApplication(app *config.Application, appName, argoNS, project string, destination argoappv1.ApplicationDestination, source *argoappv1.ApplicationSource) *argoappv1.Application {
 	options := []meta.ObjectMetaOpt{}
 	if app != nil {
 		options = append(options, meta.AddLabels(map[string]string{
@@ -195,20 +221,90 @@ func makeApplication(app *config.Application, appName, argoNS, project, ns, serv
 			options...,
 		),
 		Spec: argoappv1.ApplicationSpec{
-			Project: project,
-			Destination: argoappv1.ApplicationDestination{
-				Namespace: ns,
-				Server:    server,
-			},
-			Source:     *source,
-			SyncPolicy: syncPolicy,
+			Project:     project,
+			Destination: destination,
+			Source:      *source,
+			SyncPolicy:  syncPolicy,
 		},
 	}
 }
 
-func clusterForEnv(env *config.Environment) string {
-	if env.Cluster != "" {
-		return env.Cluster
+// configureApplication applies the Argo CD configuration to the Application,
+// the configurations are applied in order, so that an application's
+// configuration overrides its environment's, the ignored differences are
+// combined.
+func configureApplication(application *argoappv1.Application, syncWave *int, configs ...*config.ArgoCDApplicationConfig) *argoappv1.Application {
+	if syncWave != nil {
+		meta.AddAnnotations(map[string]string{SyncWaveAnnotation: strconv.Itoa(*syncWave)})(&application.ObjectMeta)
+	}
+	manualSync := false
+	var syncOptions []string
+	var retry *config.ArgoCDRetry
+	for _, cfg := range configs {
+		if cfg == nil {
+			continue
+		}
+		if cfg.ManualSync != nil {
+			manualSync = *cfg.ManualSync
+		}
+		if len(cfg.SyncOptions) > 0 {
+			syncOptions = cfg.SyncOptions
+		}
+		if cfg.Retry != nil {
+			retry = cfg.Retry
+		}
+		for _, d := range cfg.IgnoreDifferences {
+			application.Spec.IgnoreDifferences = append(application.Spec.IgnoreDifferences, argoappv1.ResourceIgnoreDifferences{
+				Group:        d.Group,
+				Kind:         d.Kind,
+				Name:         d.Name,
+				Namespace:    d.Namespace,
+				JSONPointers: d.JSONPointers,
+			})
+		}
+	}
+	switch {
+	case manualSync && syncOptions == nil && retry == nil:
+		application.Spec.SyncPolicy = nil
+		return application
+	case syncOptions == nil && retry == nil:
+		return application
+	}
+	policy := &argoappv1.SyncPolicy{SyncOptions: syncOptions}
+	if !manualSync {
+		policy.Automated = syncPolicy.Automated
+	}
+	if retry != nil {
+		policy.Retry = &argoappv1.RetryStrategy{Limit: retry.Limit}
+		if retry.Backoff != nil {
+			policy.Retry.Backoff = &argoappv1.Backoff{
+				Duration:    retry.Backoff.Duration,
+				Factor:      retry.Backoff.Factor,
+				MaxDuration: retry.Backoff.MaxDuration,
+			}
+		}
+	}
+	application.Spec.SyncPolicy = policy
+	return application
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// destinationForEnv returns the destination for the environment's namespace,
+// declared clusters are referred to by name, so that ArgoCD uses the cluster
+// registered by the cluster secret.
+func destinationForEnv(m *config.Manifest, env *config.Environment) argoappv1.ApplicationDestination {
+	if env.Cluster == "" {
+		return defaultDestination(env.Name)
 	}
-	return defaultServer
+	if cluster := m.GetCluster(env.Cluster); cluster != nil {
+		return argoappv1.ApplicationDestination{Namespace: env.Name, Name: cluster.Name}
+	}
+	return argoappv1.ApplicationDestination{Namespace: env.Name, Server: env.Cluster}
+}
+
+func defaultDestination(ns string) argoappv1.ApplicationDestination {
+	return argoappv1.ApplicationDestination{Namespace: ns, Server: defaultServer}
 }
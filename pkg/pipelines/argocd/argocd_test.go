@@ -37,6 +37,8 @@ var (
 		},
 	}
 
+	envSyncWaveAnnotation = meta.AddAnnotations(map[string]string{"argocd.argoproj.io/sync-wave": "-1"})
+
 	testEnvPath     = filepath.ToSlash(filepath.Join(config.PathForEnvironment(testEnv), "env"))
 	testEnvBasePath = filepath.ToSlash(filepath.Join(testEnvPath, "overlays"))
 )
@@ -61,6 +63,7 @@ func TestBuildCreatesArgoCD(t *testing.T) {
 			TypeMeta: applicationTypeMeta,
 			ObjectMeta: meta.ObjectMeta(meta.NamespacedName(
 				ArgoCDNamespace, "test-dev-env"),
+				envSyncWaveAnnotation,
 			),
 			Spec: argoappv1.ApplicationSpec{
 				Source: argoappv1.ApplicationSource{
@@ -211,7 +214,7 @@ func TestBuildWithRepoConfig(t *testing.T) {
 	want := res.Resources{
 		"config/argocd/test-production-env-app.yaml": &argoappv1.Application{
 			TypeMeta:   applicationTypeMeta,
-			ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "test-production-env")),
+			ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "test-production-env"), envSyncWaveAnnotation),
 			Spec: argoappv1.ApplicationSpec{
 				Source: *makeEnvSource(prodEnv, testRepoURL),
 				Destination: argoappv1.ApplicationDestination{
@@ -283,6 +286,7 @@ func TestBuildAddsClusterToApp(t *testing.T) {
 			TypeMeta: applicationTypeMeta,
 			ObjectMeta: meta.ObjectMeta(
 				meta.NamespacedName(ArgoCDNamespace, "test-dev-env"),
+				envSyncWaveAnnotation,
 			),
 			Spec: argoappv1.ApplicationSpec{
 				Source: *makeEnvSource(testEnv, testRepoURL),
@@ -327,6 +331,40 @@ func TestBuildAddsClusterToApp(t *testing.T) {
 	}
 }
 
+func TestBuildUsesDeclaredClusterName(t *testing.T) {
+	env := &config.Environment{
+		Name:    "test-prod",
+		Cluster: "prod",
+		Apps: []*config.Application{
+			testApp,
+		},
+	}
+	m := &config.Manifest{
+		Config: &config.Config{
+			ArgoCD: &config.ArgoCDConfig{Namespace: ArgoCDNamespace},
+			Clusters: []*config.Cluster{
+				{Name: "prod", Server: "https://api.prod.example.com:6443"},
+			},
+		},
+		Environments: []*config.Environment{
+			env,
+		},
+	}
+
+	files, err := Build(ArgoCDNamespace, testRepoURL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := argoappv1.ApplicationDestination{Name: "prod", Namespace: "test-prod"}
+	for _, filename := range []string{"config/argocd/test-prod-env-app.yaml", "config/argocd/test-prod-http-api-app.yaml"} {
+		got := files[filename].(*argoappv1.Application).Spec.Destination
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("destination for %s didn't match: %s\n", filename, diff)
+		}
+	}
+}
+
 func TestIgnoreDifferences(t *testing.T) {
 	want := &argoappv1.Application{
 		TypeMeta:   applicationTypeMeta,
@@ -357,3 +395,112 @@ func fakeArgoApplication() *argoappv1.Application {
 		},
 	}
 }
+
+func TestBuildWithArgoCDConfig(t *testing.T) {
+	manualSync := true
+	factor := int64(2)
+	prodApp := &config.Application{
+		Name: "http-api",
+		ArgoCD: &config.ArgoCDApplicationConfig{
+			ManualSync:  boolPtr(false),
+			SyncOptions: []string{"ServerSideApply=true"},
+			IgnoreDifferences: []*config.ArgoCDIgnoreDifference{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/template/spec/containers/0/image"}},
+			},
+			SyncWave: intPtr(2),
+		},
+	}
+	prodEnv := &config.Environment{
+		Name: "test-prod",
+		ArgoCD: &config.ArgoCDApplicationConfig{
+			ManualSync:  &manualSync,
+			SyncOptions: []string{"CreateNamespace=true"},
+			Retry: &config.ArgoCDRetry{
+				Limit:   5,
+				Backoff: &config.ArgoCDBackoff{Duration: "5s", Factor: &factor, MaxDuration: "3m"},
+			},
+			IgnoreDifferences: []*config.ArgoCDIgnoreDifference{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}},
+			},
+			SyncWave: intPtr(-2),
+		},
+		Apps: []*config.Application{prodApp},
+	}
+	m := &config.Manifest{
+		Config: &config.Config{
+			ArgoCD: &config.ArgoCDConfig{Namespace: ArgoCDNamespace},
+		},
+		Environments: []*config.Environment{prodEnv},
+	}
+
+	files, err := Build(ArgoCDNamespace, testRepoURL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retry := &argoappv1.RetryStrategy{
+		Limit:   5,
+		Backoff: &argoappv1.Backoff{Duration: "5s", Factor: &factor, MaxDuration: "3m"},
+	}
+	want := &argoappv1.Application{
+		TypeMeta: applicationTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "test-prod-env"),
+			meta.AddAnnotations(map[string]string{"argocd.argoproj.io/sync-wave": "-2"})),
+		Spec: argoappv1.ApplicationSpec{
+			Source:      *makeEnvSource(prodEnv, testRepoURL),
+			Destination: argoappv1.ApplicationDestination{Server: defaultServer, Namespace: "test-prod"},
+			Project:     defaultProject,
+			SyncPolicy: &argoappv1.SyncPolicy{
+				SyncOptions: argoappv1.SyncOptions{"CreateNamespace=true"},
+				Retry:       retry,
+			},
+			IgnoreDifferences: []argoappv1.ResourceIgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, files["config/argocd/test-prod-env-app.yaml"]); diff != "" {
+		t.Fatalf("environment application didn't match: %s\n", diff)
+	}
+
+	want = &argoappv1.Application{
+		TypeMeta: applicationTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "test-prod-http-api"),
+			meta.AddLabels(map[string]string{appLabel: "http-api"}),
+			meta.AddAnnotations(map[string]string{"argocd.argoproj.io/sync-wave": "2"})),
+		Spec: argoappv1.ApplicationSpec{
+			Source:      *makeAppSource(prodEnv, prodApp, testRepoURL),
+			Destination: argoappv1.ApplicationDestination{Server: defaultServer, Namespace: "test-prod"},
+			Project:     defaultProject,
+			SyncPolicy: &argoappv1.SyncPolicy{
+				Automated:   &argoappv1.SyncPolicyAutomated{Prune: true, SelfHeal: true},
+				SyncOptions: argoappv1.SyncOptions{"ServerSideApply=true"},
+				Retry:       retry,
+			},
+			IgnoreDifferences: []argoappv1.ResourceIgnoreDifferences{
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/replicas"}},
+				{Group: "apps", Kind: "Deployment", JSONPointers: []string{"/spec/template/spec/containers/0/image"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, files["config/argocd/test-prod-http-api-app.yaml"]); diff != "" {
+		t.Fatalf("application didn't match: %s\n", diff)
+	}
+}
+
+func TestConfigureApplicationWithManualSync(t *testing.T) {
+	app := configureApplication(makeApplication(nil, "test", ArgoCDNamespace, defaultProject,
+		defaultDestination("test"), &argoappv1.ApplicationSource{RepoURL: testRepoURL}), nil,
+		&config.ArgoCDApplicationConfig{ManualSync: boolPtr(true)})
+
+	if app.Spec.SyncPolicy != nil {
+		t.Fatalf("got sync policy %#v, want nil", app.Spec.SyncPolicy)
+	}
+	if syncPolicy.Automated == nil {
+		t.Fatal("the default sync policy was modified")
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
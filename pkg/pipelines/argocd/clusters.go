@@ -0,0 +1,204 @@
+package argocd
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+)
+
+const (
+	// ClusterSecretTypeLabel is the label that ArgoCD uses to find the secrets
+	// that declare clusters.
+	ClusterSecretTypeLabel = "argocd.argoproj.io/secret-type"
+	clusterSecretType      = "cluster"
+)
+
+// clusterConfig is the connection configuration in an ArgoCD cluster secret.
+type clusterConfig struct {
+	BearerToken     string          `json:"bearerToken,omitempty"`
+	TLSClientConfig tlsClientConfig `json:"tlsClientConfig"`
+}
+
+type tlsClientConfig struct {
+	Insecure   bool   `json:"insecure"`
+	ServerName string `json:"serverName,omitempty"`
+	CAData     []byte `json:"caData,omitempty"`
+	CertData   []byte `json:"certData,omitempty"`
+	KeyData    []byte `json:"keyData,omitempty"`
+}
+
+// ClusterSecretName returns the name of the ArgoCD secret for a cluster.
+func ClusterSecretName(clusterName string) string {
+	return "cluster-" + clusterName
+}
+
+// ClusterSecrets creates the ArgoCD cluster secrets for the clusters that are
+// declared in the manifest, the credentials are read from the referenced
+// files, relative paths are relative to baseDir.
+//
+// The secrets are keyed by paths in a "secrets" folder, as they contain the
+// credentials, they should not be written to the GitOps repository.
+func ClusterSecrets(fs afero.Fs, baseDir, argoNS string, m *config.Manifest) (res.Resources, error) {
+	files := res.Resources{}
+	if m.Config == nil {
+		return files, nil
+	}
+	for _, cluster := range m.Config.Clusters {
+		secret, err := makeClusterSecret(fs, baseDir, argoNS, cluster)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the secret for cluster %s: %w", cluster.Name, err)
+		}
+		files[filepath.ToSlash(filepath.Join("secrets", ClusterSecretName(cluster.Name)+".yaml"))] = secret
+	}
+	return files, nil
+}
+
+func makeClusterSecret(fs afero.Fs, baseDir, argoNS string, cluster *config.Cluster) (*corev1.Secret, error) {
+	if cluster.Credentials == nil {
+		return nil, fmt.Errorf("no credentials for the cluster")
+	}
+	var server string
+	var cfg *clusterConfig
+	var err error
+	if cluster.Credentials.KubeconfigFile != "" {
+		server, cfg, err = kubeconfigCredentials(fs, baseDir, cluster)
+	} else {
+		server, cfg, err = bearerTokenCredentials(fs, baseDir, cluster)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the cluster configuration: %w", err)
+	}
+	return &corev1.Secret{
+		TypeMeta: meta.TypeMeta("Secret", "v1"),
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(argoNS, ClusterSecretName(cluster.Name)),
+			meta.AddLabels(map[string]string{ClusterSecretTypeLabel: clusterSecretType})),
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"name":   cluster.Name,
+			"server": server,
+			"config": string(body),
+		},
+	}, nil
+}
+
+func bearerTokenCredentials(fs afero.Fs, baseDir string, cluster *config.Cluster) (string, *clusterConfig, error) {
+	token, err := readCredentialsFile(fs, baseDir, cluster.Credentials.BearerTokenFile)
+	if err != nil {
+		return "", nil, err
+	}
+	cfg := &clusterConfig{BearerToken: strings.TrimSpace(string(token))}
+	if cluster.Credentials.CAFile != "" {
+		cfg.TLSClientConfig.CAData, err = readCredentialsFile(fs, baseDir, cluster.Credentials.CAFile)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return cluster.Server, cfg, nil
+}
+
+// kubeconfigCredentials reads the server and credentials from the context in
+// the kubeconfig file, the server in the manifest takes precedence over the
+// server in the kubeconfig file.
+func kubeconfigCredentials(fs afero.Fs, baseDir string, cluster *config.Cluster) (string, *clusterConfig, error) {
+	creds := cluster.Credentials
+	body, err := readCredentialsFile(fs, baseDir, creds.KubeconfigFile)
+	if err != nil {
+		return "", nil, err
+	}
+	kubeconfig, err := clientcmd.Load(body)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse kubeconfig %s: %w", creds.KubeconfigFile, err)
+	}
+	contextName := creds.Context
+	if contextName == "" {
+		contextName = kubeconfig.CurrentContext
+	}
+	kubeContext, ok := kubeconfig.Contexts[contextName]
+	if !ok {
+		return "", nil, fmt.Errorf("context %q does not exist in kubeconfig %s", contextName, creds.KubeconfigFile)
+	}
+	kubeCluster, ok := kubeconfig.Clusters[kubeContext.Cluster]
+	if !ok {
+		return "", nil, fmt.Errorf("cluster %q does not exist in kubeconfig %s", kubeContext.Cluster, creds.KubeconfigFile)
+	}
+	authInfo, ok := kubeconfig.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return "", nil, fmt.Errorf("user %q does not exist in kubeconfig %s", kubeContext.AuthInfo, creds.KubeconfigFile)
+	}
+
+	// files referenced in the kubeconfig are relative to the kubeconfig.
+	kubeconfigDir := filepath.Dir(credentialsPath(baseDir, creds.KubeconfigFile))
+	cfg := &clusterConfig{
+		BearerToken: authInfo.Token,
+		TLSClientConfig: tlsClientConfig{
+			Insecure:   kubeCluster.InsecureSkipTLSVerify,
+			ServerName: kubeCluster.TLSServerName,
+			CAData:     kubeCluster.CertificateAuthorityData,
+			CertData:   authInfo.ClientCertificateData,
+			KeyData:    authInfo.ClientKeyData,
+		},
+	}
+	for _, ref := range []struct {
+		path string
+		data *[]byte
+	}{
+		{kubeCluster.CertificateAuthority, &cfg.TLSClientConfig.CAData},
+		{authInfo.ClientCertificate, &cfg.TLSClientConfig.CertData},
+		{authInfo.ClientKey, &cfg.TLSClientConfig.KeyData},
+	} {
+		if ref.path == "" || len(*ref.data) > 0 {
+			continue
+		}
+		if *ref.data, err = readCredentialsFile(fs, kubeconfigDir, ref.path); err != nil {
+			return "", nil, err
+		}
+	}
+	if cfg.BearerToken == "" && authInfo.TokenFile != "" {
+		token, err := readCredentialsFile(fs, kubeconfigDir, authInfo.TokenFile)
+		if err != nil {
+			return "", nil, err
+		}
+		cfg.BearerToken = strings.TrimSpace(string(token))
+	}
+	if cfg.BearerToken == "" && len(cfg.TLSClientConfig.CertData) == 0 {
+		return "", nil, fmt.Errorf("user %q in kubeconfig %s has no bearer token or client certificate", kubeContext.AuthInfo, creds.KubeconfigFile)
+	}
+
+	server := cluster.Server
+	if server == "" {
+		server = kubeCluster.Server
+	}
+	return server, cfg, nil
+}
+
+func readCredentialsFile(fs afero.Fs, baseDir, path string) ([]byte, error) {
+	body, err := afero.ReadFile(fs, credentialsPath(baseDir, path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	return body, nil
+}
+
+func credentialsPath(baseDir, path string) string {
+	if expanded, err := homedir.Expand(path); err == nil {
+		path = expanded
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
@@ -0,0 +1,132 @@
+package argocd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/test"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: stage
+clusters:
+- name: stage-cluster
+  cluster:
+    server: https://api.stage.example.com:6443
+    certificate-authority: stage-ca.crt
+contexts:
+- name: stage
+  context:
+    cluster: stage-cluster
+    user: stage-user
+- name: anonymous
+  context:
+    cluster: stage-cluster
+    user: anonymous-user
+users:
+- name: stage-user
+  user:
+    token: stage-token
+- name: anonymous-user
+  user: {}
+`
+
+func TestClusterSecrets(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	writeFile(t, fakeFs, "/secrets/prod-token", "prod-token\n")
+	writeFile(t, fakeFs, "/secrets/prod-ca.crt", "prod-ca")
+	writeFile(t, fakeFs, "/secrets/kubeconfig", testKubeconfig)
+	writeFile(t, fakeFs, "/secrets/stage-ca.crt", "stage-ca")
+	m := &config.Manifest{
+		Config: &config.Config{
+			Clusters: []*config.Cluster{
+				{
+					Name:   "prod",
+					Server: "https://api.prod.example.com:6443",
+					Credentials: &config.ClusterCredentials{
+						BearerTokenFile: "../secrets/prod-token",
+						CAFile:          "/secrets/prod-ca.crt",
+					},
+				},
+				{
+					Name:        "stage",
+					Credentials: &config.ClusterCredentials{KubeconfigFile: "../secrets/kubeconfig"},
+				},
+			},
+		},
+	}
+
+	files, err := ClusterSecrets(fakeFs, "/gitops", ArgoCDNamespace, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := res.Resources{
+		"secrets/cluster-prod.yaml": makeTestClusterSecret("prod", "https://api.prod.example.com:6443",
+			`{"bearerToken":"prod-token","tlsClientConfig":{"insecure":false,"caData":"cHJvZC1jYQ=="}}`),
+		"secrets/cluster-stage.yaml": makeTestClusterSecret("stage", "https://api.stage.example.com:6443",
+			`{"bearerToken":"stage-token","tlsClientConfig":{"insecure":false,"caData":"c3RhZ2UtY2E="}}`),
+	}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Fatalf("cluster secrets didn't match: %s\n", diff)
+	}
+}
+
+func TestClusterSecretsErrors(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	writeFile(t, fakeFs, "/secrets/kubeconfig", testKubeconfig)
+	writeFile(t, fakeFs, "/secrets/stage-ca.crt", "stage-ca")
+
+	errorTests := []struct {
+		name        string
+		credentials *config.ClusterCredentials
+		errMsg      string
+	}{
+		{"missing token file", &config.ClusterCredentials{BearerTokenFile: "/secrets/unknown"}, "failed to read credentials"},
+		{"missing context", &config.ClusterCredentials{KubeconfigFile: "/secrets/kubeconfig", Context: "unknown"}, `context "unknown" does not exist`},
+		{"no user credentials", &config.ClusterCredentials{KubeconfigFile: "/secrets/kubeconfig", Context: "anonymous"}, `user "anonymous-user" .* has no bearer token or client certificate`},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			m := &config.Manifest{
+				Config: &config.Config{
+					Clusters: []*config.Cluster{
+						{Name: "test", Server: "https://api.test.example.com:6443", Credentials: tt.credentials},
+					},
+				},
+			}
+			_, err := ClusterSecrets(fakeFs, "/gitops", ArgoCDNamespace, m)
+			test.AssertErrorMatch(rt, tt.errMsg, err)
+		})
+	}
+}
+
+func makeTestClusterSecret(name, server, cfg string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: meta.TypeMeta("Secret", "v1"),
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "cluster-"+name),
+			meta.AddLabels(map[string]string{"argocd.argoproj.io/secret-type": "cluster"})),
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			"name":   name,
+			"server": server,
+			"config": cfg,
+		},
+	}
+}
+
+func writeFile(t *testing.T, fs afero.Fs, path, body string) {
+	t.Helper()
+	if err := afero.WriteFile(fs, path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
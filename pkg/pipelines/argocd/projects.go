@@ -0,0 +1,79 @@
+package argocd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	argoappv1 "github.com/redhat-developer/kam/pkg/pipelines/argocd/v1alpha1"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+)
+
+var (
+	appProjectTypeMeta = meta.TypeMeta(
+		"AppProject",
+		"argoproj.io/v1alpha1",
+	)
+
+	// the environments' base creates the environment's namespace.
+	defaultClusterResources = []metav1.GroupKind{
+		{Group: "", Kind: "Namespace"},
+	}
+)
+
+// projectForEnv returns the name of the AppProject for the environment's
+// Applications.
+func projectForEnv(cfg *config.ArgoCDConfig, env *config.Environment) string {
+	if cfg.Projects != nil {
+		return env.Name
+	}
+	return defaultProject
+}
+
+// projectResources creates an AppProject for each environment, the
+// environment's Applications can only be deployed from the GitOps repository
+// and the config repositories of its applications, to the environment's
+// namespace.
+func projectResources(argoNS, repoURL string, cfg *config.ArgoCDProjectsConfig, m *config.Manifest) res.Resources {
+	files := res.Resources{}
+	clusterResources := defaultClusterResources
+	if len(cfg.ClusterResources) > 0 {
+		clusterResources = []metav1.GroupKind{}
+		for _, gk := range cfg.ClusterResources {
+			clusterResources = append(clusterResources, metav1.GroupKind{Group: gk.Group, Kind: gk.Kind})
+		}
+	}
+	for _, env := range m.Environments {
+		filename := filepath.ToSlash(filepath.Join(config.PathForArgoCD(), env.Name+"-project.yaml"))
+		files[filename] = makeProject(env.Name, argoNS, sourceReposForEnv(env, repoURL), destinationForEnv(m, env), clusterResources)
+	}
+	return files
+}
+
+func sourceReposForEnv(env *config.Environment, repoURL string) []string {
+	repos := []string{repoURL}
+	seen := map[string]bool{repoURL: true}
+	for _, app := range env.Apps {
+		if app.ConfigRepo != nil && !seen[app.ConfigRepo.URL] {
+			repos = append(repos, app.ConfigRepo.URL)
+			seen[app.ConfigRepo.URL] = true
+		}
+	}
+	return repos
+}
+
+func makeProject(name, argoNS string, sourceRepos []string, destination argoappv1.ApplicationDestination, clusterResources []metav1.GroupKind) *argoappv1.AppProject {
+	return &argoappv1.AppProject{
+		TypeMeta:   appProjectTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(argoNS, name)),
+		Spec: argoappv1.AppProjectSpec{
+			Description:              fmt.Sprintf("Applications deployed to the %s environment", name),
+			SourceRepos:              sourceRepos,
+			Destinations:             []argoappv1.ApplicationDestination{destination},
+			ClusterResourceWhitelist: clusterResources,
+		},
+	}
+}
@@ -0,0 +1,109 @@
+package argocd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	argoappv1 "github.com/redhat-developer/kam/pkg/pipelines/argocd/v1alpha1"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+)
+
+func TestBuildCreatesProjects(t *testing.T) {
+	devEnv := &config.Environment{
+		Name: "test-dev",
+		Apps: []*config.Application{
+			testApp,
+		},
+	}
+	prodEnv := &config.Environment{
+		Name:    "test-prod",
+		Cluster: "prod",
+		Apps: []*config.Application{
+			testApp,
+			configRepoApp,
+		},
+	}
+	m := &config.Manifest{
+		Environments: []*config.Environment{
+			devEnv,
+			prodEnv,
+		},
+		Config: &config.Config{
+			ArgoCD: &config.ArgoCDConfig{Namespace: ArgoCDNamespace, Projects: &config.ArgoCDProjectsConfig{}},
+			Clusters: []*config.Cluster{
+				{Name: "prod", Server: "https://api.prod.example.com:6443"},
+			},
+		},
+	}
+
+	files, err := Build(ArgoCDNamespace, testRepoURL, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &argoappv1.AppProject{
+		TypeMeta:   appProjectTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "test-prod")),
+		Spec: argoappv1.AppProjectSpec{
+			Description:              "Applications deployed to the test-prod environment",
+			SourceRepos:              []string{testRepoURL, "https://github.com/rhd-example-gitops/other-repo"},
+			Destinations:             []argoappv1.ApplicationDestination{{Namespace: "test-prod", Name: "prod"}},
+			ClusterResourceWhitelist: []metav1.GroupKind{{Group: "", Kind: "Namespace"}},
+		},
+	}
+	if diff := cmp.Diff(want, files["config/argocd/test-prod-project.yaml"]); diff != "" {
+		t.Fatalf("project didn't match: %s\n", diff)
+	}
+	want = &argoappv1.AppProject{
+		TypeMeta:   appProjectTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ArgoCDNamespace, "test-dev")),
+		Spec: argoappv1.AppProjectSpec{
+			Description:              "Applications deployed to the test-dev environment",
+			SourceRepos:              []string{testRepoURL},
+			Destinations:             []argoappv1.ApplicationDestination{{Namespace: "test-dev", Server: defaultServer}},
+			ClusterResourceWhitelist: []metav1.GroupKind{{Group: "", Kind: "Namespace"}},
+		},
+	}
+	if diff := cmp.Diff(want, files["config/argocd/test-dev-project.yaml"]); diff != "" {
+		t.Fatalf("project didn't match: %s\n", diff)
+	}
+
+	for filename, project := range map[string]string{
+		"config/argocd/test-dev-env-app.yaml":       "test-dev",
+		"config/argocd/test-dev-http-api-app.yaml":  "test-dev",
+		"config/argocd/test-prod-prod-api-app.yaml": "test-prod",
+		"config/argocd/argo-app.yaml":               defaultProject,
+	} {
+		if got := files[filename].(*argoappv1.Application).Spec.Project; got != project {
+			t.Errorf("%s got project %q, want %q", filename, got, project)
+		}
+	}
+}
+
+func TestProjectResourcesWithClusterResources(t *testing.T) {
+	m := &config.Manifest{
+		Environments: []*config.Environment{
+			testEnv,
+		},
+	}
+	cfg := &config.ArgoCDProjectsConfig{
+		ClusterResources: []*config.GroupKind{
+			{Kind: "Namespace"},
+			{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+		},
+	}
+
+	files := projectResources(ArgoCDNamespace, testRepoURL, cfg, m)
+
+	want := []metav1.GroupKind{
+		{Group: "", Kind: "Namespace"},
+		{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+	}
+	got := files["config/argocd/test-dev-project.yaml"].(*argoappv1.AppProject).Spec.ClusterResourceWhitelist
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("cluster resources didn't match: %s\n", diff)
+	}
+}
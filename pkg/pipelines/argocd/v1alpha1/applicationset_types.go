@@ -0,0 +1,81 @@
+package argocd
+
+import (
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplicationSet is a set of Application resources
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=applicationsets,shortName=appset;appsets
+type ApplicationSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata" protobuf:"bytes,1,opt,name=metadata"`
+	Spec              ApplicationSetSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+}
+
+// ApplicationSetSpec represents a class of application set state.
+type ApplicationSetSpec struct {
+	Generators []ApplicationSetGenerator `json:"generators" protobuf:"bytes,1,name=generators"`
+	Template   ApplicationSetTemplate    `json:"template" protobuf:"bytes,2,name=template"`
+	SyncPolicy *ApplicationSetSyncPolicy `json:"syncPolicy,omitempty" protobuf:"bytes,3,name=syncPolicy"`
+}
+
+// ApplicationSetSyncPolicy configures how generated Applications will relate to their
+// ApplicationSet.
+type ApplicationSetSyncPolicy struct {
+	// PreserveResourcesOnDeletion will preserve resources on deletion. If PreserveResourcesOnDeletion is set to true, these Applications will not be deleted.
+	PreserveResourcesOnDeletion bool `json:"preserveResourcesOnDeletion,omitempty" protobuf:"bytes,1,name=syncPolicy"`
+}
+
+// ApplicationSetTemplate represents argocd ApplicationSpec
+type ApplicationSetTemplate struct {
+	ApplicationSetTemplateMeta `json:"metadata" protobuf:"bytes,1,name=metadata"`
+	Spec                       ApplicationSpec `json:"spec" protobuf:"bytes,2,name=spec"`
+}
+
+// ApplicationSetTemplateMeta represents the Argo CD application fields that may
+// be used for Applications generated from the ApplicationSet (based on metav1.ObjectMeta)
+type ApplicationSetTemplateMeta struct {
+	Name        string            `json:"name,omitempty" protobuf:"bytes,1,name=name"`
+	Namespace   string            `json:"namespace,omitempty" protobuf:"bytes,2,name=namespace"`
+	Labels      map[string]string `json:"labels,omitempty" protobuf:"bytes,3,name=labels"`
+	Annotations map[string]string `json:"annotations,omitempty" protobuf:"bytes,4,name=annotations"`
+	Finalizers  []string          `json:"finalizers,omitempty" protobuf:"bytes,5,name=finalizers"`
+}
+
+// ApplicationSetGenerator represents a generator at the top level of an ApplicationSet.
+type ApplicationSetGenerator struct {
+	List *ListGenerator `json:"list,omitempty" protobuf:"bytes,1,name=list"`
+	Git  *GitGenerator  `json:"git,omitempty" protobuf:"bytes,3,name=git"`
+}
+
+// ListGenerator include items info
+type ListGenerator struct {
+	Elements []apiextensionsv1.JSON `json:"elements" protobuf:"bytes,1,name=elements"`
+}
+
+// GitGenerator generates parameters from the directories or files in a Git
+// repository.
+type GitGenerator struct {
+	RepoURL             string                      `json:"repoURL" protobuf:"bytes,1,name=repoURL"`
+	Directories         []GitDirectoryGeneratorItem `json:"directories,omitempty" protobuf:"bytes,2,name=directories"`
+	Files               []GitFileGeneratorItem      `json:"files,omitempty" protobuf:"bytes,3,name=files"`
+	Revision            string                      `json:"revision" protobuf:"bytes,4,name=revision"`
+	RequeueAfterSeconds *int64                      `json:"requeueAfterSeconds,omitempty" protobuf:"bytes,5,name=requeueAfterSeconds"`
+}
+
+// GitDirectoryGeneratorItem is a path to a directory in the Git repository,
+// an Application is generated for each matching directory.
+type GitDirectoryGeneratorItem struct {
+	Path    string `json:"path" protobuf:"bytes,1,name=path"`
+	Exclude bool   `json:"exclude,omitempty" protobuf:"bytes,2,name=exclude"`
+}
+
+// GitFileGeneratorItem is a path to a JSON or YAML file in the Git repository,
+// an Application is generated for each matching file.
+type GitFileGeneratorItem struct {
+	Path string `json:"path" protobuf:"bytes,1,name=path"`
+}
@@ -14,9 +14,11 @@ import (
 	"github.com/spf13/afero"
 	corev1 "k8s.io/api/core/v1"
 	v1rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/argocd"
+	"github.com/redhat-developer/kam/pkg/pipelines/chains"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 	"github.com/redhat-developer/kam/pkg/pipelines/deployment"
 	"github.com/redhat-developer/kam/pkg/pipelines/dryrun"
@@ -32,6 +34,7 @@ import (
 	"github.com/redhat-developer/kam/pkg/pipelines/scm"
 	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
 	"github.com/redhat-developer/kam/pkg/pipelines/tasks"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
 	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
@@ -51,10 +54,18 @@ const (
 	appCiPipelinesPath    = "04-pipelines/app-ci-pipeline.yaml"
 	pushTemplatePath      = "06-templates/ci-dryrun-from-push-template.yaml"
 	appCIPushTemplatePath = "06-templates/app-ci-build-from-push-template.yaml"
+	prTemplatePath        = "06-templates/ci-dryrun-from-pr-template.yaml"
+	appCIPRTemplatePath   = "06-templates/app-ci-build-from-pr-template.yaml"
+	appCIPRPipelinesPath  = "04-pipelines/app-ci-pr-pipeline.yaml"
+	updateImageTaskPath   = "03-tasks/update-image-pull-request-task.yaml"
+	appCDPipelinesPath    = "04-pipelines/app-cd-pipeline.yaml"
+	appCDTemplatePath     = "06-templates/app-cd-build-from-push-template.yaml"
 	eventListenerPath     = "07-eventlisteners/cicd-event-listener.yaml"
 	routePath             = "08-routes/gitops-webhook-event-listener.yaml"
+	chainsConfigPath      = "09-chains/tekton-chain.yaml"
 
 	dockerSecretName = "regcred"
+	cosignSecretName = "cosign-key"
 
 	authTokenSecretName = "git-host-access-token"
 	basicAuthTokenName  = "git-host-basic-auth-token"
@@ -63,10 +74,11 @@ const (
 	roleBindingName     = "pipelines-service-role-binding"
 	webhookSecretLength = 20
 
-	pipelinesFile     = "pipelines.yaml"
-	bootstrapImage    = "nginxinc/nginx-unprivileged:latest"
-	appCITemplateName = "app-ci-template"
-	version           = 1
+	pipelinesFile       = "pipelines.yaml"
+	bootstrapImage      = "nginxinc/nginx-unprivileged:latest"
+	appCITemplateName   = "app-ci-template"
+	appCIPRTemplateName = "app-ci-pr-template"
+	appCDTemplateName   = "app-cd-template"
 )
 
 // BootstrapOptions is a struct that provides the optional flags
@@ -75,15 +87,23 @@ type BootstrapOptions struct {
 	GitOpsWebhookSecret      string // This is the secret for authenticating hooks from your GitOps repo.
 	Prefix                   string
 	DockerConfigJSONFilename string
-	ImageRepo                string // This is where built images are pushed to.
-	OutputPath               string // Where to write the bootstrapped files to?
-	GitHostAccessToken       string // The auth token to use to access repositories.
-	Overwrite                bool   // This allows to overwrite if there is an existing gitops repository
-	ServiceRepoURL           string // This is the full URL to your GitHub repository for your app source.
-	SaveTokenKeyRing         bool   // If true, the access-token will be saved in the keyring
-	ServiceWebhookSecret     string // This is the secret for authenticating hooks from your app source.
-	PrivateRepoDriver        string // Records the type of the GitOpsRepoURL driver if not a well-known host.
-	PushToGit                bool   // If true, gitops repository is pushed to remote git repository.
+	ImageRepo                string   // This is where built images are pushed to.
+	OutputPath               string   // Where to write the bootstrapped files to?
+	GitHostAccessToken       string   // The auth token to use to access repositories.
+	Overwrite                bool     // This allows to overwrite if there is an existing gitops repository
+	ServiceRepoURL           string   // This is the full URL to your GitHub repository for your app source.
+	SaveTokenKeyRing         bool     // If true, the access-token will be saved in the keyring
+	ServiceWebhookSecret     string   // This is the secret for authenticating hooks from your app source.
+	PrivateRepoDriver        string   // Records the type of the GitOpsRepoURL driver if not a well-known host.
+	PushToGit                bool     // If true, gitops repository is pushed to remote git repository.
+	SealedSecretsCert        string   // If set, secrets are sealed with this Sealed Secrets certificate and written to the GitOps repository.
+	SOPS                     bool     // If true, secrets are encrypted with SOPS and written to the GitOps repository.
+	SOPSAgeRecipients        []string // The age recipients to encrypt secrets for, if empty they're read from the .sops.yaml in the output folder.
+	CosignKey                string   // If set, built images are signed with this cosign private key, and an SBOM is attested for them.
+	CosignPassword           string   // The password of the cosign private key.
+	SupplyChain              bool     // If true, Tekton Chains is configured to generate SLSA provenance for the built images.
+	SupplyChainTransparency  bool     // If true, Tekton Chains records the signatures and provenance in the public Rekor transparency log.
+	TektonAPIVersion         string   // The version of the Tekton APIs that the Pipelines, Tasks and Triggers resources are generated for.
 }
 
 // PolicyRules to be bound to service account
@@ -120,6 +140,9 @@ var (
 // Bootstrap is the entry-point from the CLI for bootstrapping the GitOps
 // configuration.
 func Bootstrap(o *BootstrapOptions, appFs afero.Fs) error {
+	if o.SealedSecretsCert != "" && o.SOPS {
+		return errors.New("secrets can't be both sealed and encrypted with SOPS")
+	}
 	err := checkPipelinesFileExists(appFs, o.OutputPath, o.Overwrite, o.PushToGit)
 	if err != nil {
 		return err
@@ -141,6 +164,26 @@ func Bootstrap(o *BootstrapOptions, appFs afero.Fs) error {
 	}
 
 	bootstrapped = res.Merge(built, bootstrapped)
+	if err := addCICDKustomization(appFs, o.OutputPath, m.GetPipelinesConfig(), bootstrapped, nil); err != nil {
+		return err
+	}
+	bootstrapped = res.Merge(generatedFilesIndex(built), bootstrapped)
+	if o.SealedSecretsCert != "" {
+		bootstrapped, err = sealBootstrapSecrets(appFs, o.SealedSecretsCert, m, bootstrapped, otherResources)
+		if err != nil {
+			return err
+		}
+		otherResources = res.Resources{}
+	}
+	if o.SOPS {
+		encrypted, err := encryptSOPSSecrets(appFs, o.OutputPath, o.SOPSAgeRecipients, m.GetPipelinesConfig(), otherResources, bootstrapped)
+		if err != nil {
+			return err
+		}
+		bootstrapped = res.Merge(encrypted, bootstrapped)
+		otherResources = res.Resources{}
+		log.Success("Secrets encrypted with SOPS")
+	}
 	log.Successf("Created dev, stage and CICD environments")
 	_, err = yaml.WriteResources(appFs, o.OutputPath, bootstrapped)
 	if err != nil {
@@ -154,6 +197,25 @@ func Bootstrap(o *BootstrapOptions, appFs afero.Fs) error {
 	return nil
 }
 
+// sealBootstrapSecrets seals the unsealed secrets, and adds them to the
+// bootstrapped resources and the pipelines base kustomization.
+func sealBootstrapSecrets(fs afero.Fs, certPath string, m *config.Manifest, bootstrapped, unsealed res.Resources) (res.Resources, error) {
+	cfg := m.GetPipelinesConfig()
+	sealed, filenames, err := sealSecrets(fs, certPath, cfg, unsealed)
+	if err != nil {
+		return nil, err
+	}
+	kustomizePath := filepath.Join(config.PathForPipelines(cfg), "base", "kustomization.yaml")
+	k, ok := bootstrapped[kustomizePath].(res.Kustomization)
+	if !ok {
+		return nil, fmt.Errorf("no kustomization for the %s environment found", kustomizePath)
+	}
+	k.AddResources(filenames...)
+	bootstrapped[kustomizePath] = k
+	log.Success("Secrets sealed with the Sealed Secrets certificate")
+	return res.Merge(sealed, bootstrapped), nil
+}
+
 func maybeMakeHookSecrets(o *BootstrapOptions) error {
 	if o.GitOpsWebhookSecret == "" {
 		gitopsSecret, err := secrets.GenerateString(webhookSecretLength)
@@ -198,6 +260,13 @@ func bootstrapResources(o *BootstrapOptions, appFs afero.Fs) (res.Resources, res
 	if !isInternalRegistry {
 		log.Progressf("  Path to config.json: %s", o.DockerConfigJSONFilename)
 	}
+	if o.CosignKey != "" {
+		log.Progressf("  Path to cosign key: %s", o.CosignKey)
+	}
+	if o.SupplyChain {
+		log.Progressf("  Tekton Chains namespace: %s", chains.DefaultNamespace)
+		log.Progressf("  Tekton Chains transparency log: %s", strconv.FormatBool(o.SupplyChainTransparency))
+	}
 	log.Progressf("  Output folder: %s", o.OutputPath)
 	log.Progressf("  Overwrite output folder: %s", strconv.FormatBool(o.Overwrite))
 	log.Progressf("")
@@ -218,6 +287,9 @@ func bootstrapResources(o *BootstrapOptions, appFs afero.Fs) (res.Resources, res
 	if err != nil {
 		return nil, nil, err
 	}
+	configEnv.Pipelines.ImageSigning = imageSigning(o)
+	configEnv.Pipelines.SupplyChain = supplyChain(o)
+	configEnv.Pipelines.TektonAPIVersion = tektonAPIVersion(o)
 	if o.PrivateRepoDriver != "" {
 		host, err := scm.HostnameFromURL(o.GitOpsRepoURL)
 		if err != nil {
@@ -256,6 +328,11 @@ func bootstrapResources(o *BootstrapOptions, appFs afero.Fs) (res.Resources, res
 	otherResources[secretFilename] = opaqueSecret
 	bindingName, imageRepoBindingFilename, svcImageBinding := createSvcImageBinding(cfg, devEnv, appName, serviceName, imageRepo, !isInternalRegistry)
 	bootstrapped = res.Merge(svcImageBinding, bootstrapped)
+	cdBindingName, cdBindingFilename, svcCDBinding, err := createSvcCDBinding(m, cfg, devEnv, devEnv.Apps[0], serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	bootstrapped = res.Merge(svcCDBinding, bootstrapped)
 
 	kustomizePath := filepath.Join(config.PathForPipelines(cfg), "base", "kustomization.yaml")
 	k, ok := bootstrapped[kustomizePath].(res.Kustomization)
@@ -276,12 +353,13 @@ func bootstrapResources(o *BootstrapOptions, appFs afero.Fs) (res.Resources, res
 	// This is specific to bootstrap, because there's only one service.
 	devEnv.Apps[0].Services[0].Pipelines = &config.Pipelines{
 		Integration: &config.TemplateBinding{
-			Bindings: append([]string{bindingName}, devEnv.Pipelines.Integration.Bindings...),
+			Template: appCDTemplateName,
+			Bindings: append([]string{bindingName, cdBindingName}, devEnv.Pipelines.Integration.Bindings...),
 		},
 	}
 	bootstrapped[pipelinesFile] = m
 
-	k.AddResources(imageRepoBindingFilename)
+	k.AddResources(imageRepoBindingFilename, cdBindingFilename)
 	bootstrapped[kustomizePath] = k
 
 	bootstrapped = res.Merge(svcFiles, bootstrapped)
@@ -456,7 +534,7 @@ func errorIfFileExists(appFs afero.Fs, outputPath string, files ...string) error
 }
 
 func createInitialFiles(fs afero.Fs, repo scm.Repository, o *BootstrapOptions) (res.Resources, res.Resources, error) {
-	cicd := &config.PipelinesConfig{Name: o.Prefix + "cicd"}
+	cicd := &config.PipelinesConfig{Name: o.Prefix + "cicd", ImageSigning: imageSigning(o), SupplyChain: supplyChain(o), TektonAPIVersion: tektonAPIVersion(o)}
 	pipelineConfig := &config.Config{Pipelines: cicd}
 	manifest := createManifest(repo.URL(), pipelineConfig)
 	initialFiles := res.Resources{
@@ -501,6 +579,47 @@ func createDockerSecret(fs afero.Fs, dockerConfigJSONFilename, secretNS string)
 	return dockerSecret, nil
 }
 
+// createCosignSecret creates a secret with the cosign key that signs the built
+// images.
+func createCosignSecret(fs afero.Fs, keyFilename, password string, name types.NamespacedName) (*corev1.Secret, error) {
+	keyPath, err := homedir.Expand(keyFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate path to file: %v", err)
+	}
+	key, err := afero.ReadFile(fs, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cosign key %#v : %s", keyPath, err)
+	}
+	return secrets.CreateUnsealedCosignSecret(name, key, password), nil
+}
+
+// imageSigning returns the image signing configuration for the pipelines, or
+// nil if images aren't signed.
+func imageSigning(o *BootstrapOptions) *config.ImageSigning {
+	if o.CosignKey == "" {
+		return nil
+	}
+	return &config.ImageSigning{Secret: cosignSecretName}
+}
+
+// supplyChain returns the Tekton Chains configuration for the pipelines, or
+// nil if Tekton Chains isn't configured.
+func supplyChain(o *BootstrapOptions) *config.SupplyChain {
+	if !o.SupplyChain {
+		return nil
+	}
+	return &config.SupplyChain{Namespace: chains.DefaultNamespace, Transparency: o.SupplyChainTransparency}
+}
+
+// tektonAPIVersion returns the version of the Tekton APIs that the resources
+// are generated for, the default version isn't recorded in the manifest.
+func tektonAPIVersion(o *BootstrapOptions) tekton.Version {
+	if v := tekton.Version(o.TektonAPIVersion); v != tekton.V1Beta1 {
+		return v
+	}
+	return ""
+}
+
 // createCICDResources creates resources for OpenShift pipelines.
 func createCICDResources(fs afero.Fs, repo scm.Repository, pipelineConfig *config.PipelinesConfig, o *BootstrapOptions) (res.Resources, res.Resources, error) {
 	cicdNamespace := pipelineConfig.Name
@@ -526,11 +645,35 @@ func createCICDResources(fs afero.Fs, repo scm.Repository, pipelineConfig *confi
 		}
 		if dockerUnencryptedSecret != nil {
 			otherOutputs[filepath.Join("secrets", "docker-config.yaml")] = dockerUnencryptedSecret
-			log.Success("Authentication tokens for docker config not sealed in secrets")
+			if o.SealedSecretsCert == "" && !o.SOPS {
+				log.Success("Authentication tokens for docker config not sealed in secrets")
+			}
 		}
 		outputs[serviceAccountPath] = roles.AddSecretToSA(sa, dockerSecretName)
 	}
 
+	if o.CosignKey != "" {
+		cosignSecret, err := createCosignSecret(fs, o.CosignKey, o.CosignPassword, meta.NamespacedName(cicdNamespace, cosignSecretName))
+		if err != nil {
+			return nil, nil, err
+		}
+		otherOutputs[filepath.Join("secrets", cosignSecretName+".yaml")] = cosignSecret
+		outputs[serviceAccountPath] = roles.AddSecretToSA(sa, cosignSecretName)
+	}
+
+	if supplyChain := pipelineConfig.SupplyChain; supplyChain != nil {
+		outputs[chainsConfigPath] = chains.CreateConfig(supplyChain.Namespace, supplyChain.Transparency)
+		// Tekton Chains signs the provenance with the key in the signing-secrets
+		// Secret in its own namespace.
+		if o.CosignKey != "" {
+			signingSecret, err := createCosignSecret(fs, o.CosignKey, o.CosignPassword, meta.NamespacedName(supplyChain.Namespace, chains.SigningSecretName))
+			if err != nil {
+				return nil, nil, err
+			}
+			otherOutputs[filepath.Join("secrets", chains.SigningSecretName+".yaml")] = signingSecret
+		}
+	}
+
 	if o.GitHostAccessToken != "" {
 		err := generateSecrets(outputs, otherOutputs, sa, cicdNamespace, o)
 		if err != nil {
@@ -550,11 +693,15 @@ func createCICDResources(fs afero.Fs, repo scm.Repository, pipelineConfig *confi
 		outputs[commitStatusTaskPath] = tasks.CreateCommitStatusTask(cicdNamespace)
 	}
 	outputs[ciPipelinesPath] = removeCommitStatus(pipelines.CreateCIPipeline(meta.NamespacedName(cicdNamespace, "ci-dryrun-from-push-pipeline"), cicdNamespace), o.PrivateRepoDriver)
-	outputs[appCiPipelinesPath] = removeCommitStatus(pipelines.CreateAppCIPipeline(meta.NamespacedName(cicdNamespace, "app-ci-pipeline")), o.PrivateRepoDriver)
+	outputs[appCiPipelinesPath] = removeCommitStatus(securePipeline(pipelines.CreateAppCIPipeline(meta.NamespacedName(cicdNamespace, "app-ci-pipeline")), pipelineConfig), o.PrivateRepoDriver)
 	pushBinding, pushBindingName := repo.CreatePushBinding(cicdNamespace)
 	outputs[filepath.ToSlash(filepath.Join("05-bindings", pushBindingName+".yaml"))] = pushBinding
 	outputs[pushTemplatePath] = triggers.CreateCIDryRunTemplate(cicdNamespace, saName)
 	outputs[appCIPushTemplatePath] = triggers.CreateDevCIBuildPRTemplate(cicdNamespace, saName)
+	prBinding, prBindingName := repo.CreatePRBinding(cicdNamespace)
+	outputs[prBindingPath(prBindingName)] = prBinding
+	outputs = res.Merge(createPRResources(cicdNamespace, o.PrivateRepoDriver), outputs)
+	outputs = res.Merge(createCDResources(pipelineConfig, o.PrivateRepoDriver), outputs)
 	outputs[eventListenerPath], err = eventlisteners.Generate(repo, cicdNamespace, saName, eventlisteners.GitOpsWebhookSecret)
 	if err != nil {
 		return nil, nil, err
@@ -566,7 +713,7 @@ func createCICDResources(fs afero.Fs, repo scm.Repository, pipelineConfig *confi
 	}
 	outputs[routePath] = route
 	log.Success("Openshift Route for EventListener created")
-	return outputs, otherOutputs, nil
+	return convertResources(outputs, pipelineConfig.TektonAPIVersion), otherOutputs, nil
 }
 
 func createManifest(gitOpsRepoURL string, configEnv *config.Config, envs ...*config.Environment) *config.Manifest {
@@ -574,14 +721,14 @@ func createManifest(gitOpsRepoURL string, configEnv *config.Config, envs ...*con
 		GitOpsURL:    gitOpsRepoURL,
 		Environments: envs,
 		Config:       configEnv,
-		Version:      version,
+		Version:      config.ManifestVersion,
 	}
 }
 
 func getCICDKustomization(files []string) res.Resources {
 	return res.Resources{
 		"overlays/kustomization.yaml": res.Kustomization{
-			Bases: []string{"../base"},
+			Resources: []string{"../base"},
 		},
 		"base/kustomization.yaml": res.Kustomization{
 			Resources: files,
@@ -633,6 +780,19 @@ func generateSecrets(outputs res.Resources, otherOutputs res.Resources, sa *core
 	return nil
 }
 
+// securePipeline adds the tasks that sign the pipeline's image, and attest its
+// SBOM, if image signing is configured for the pipelines, and the results that
+// Tekton Chains generates provenance from, if Tekton Chains is configured.
+func securePipeline(pipeline *pipelinev1.Pipeline, cfg *config.PipelinesConfig) *pipelinev1.Pipeline {
+	if cfg.ImageSigning != nil && !pipelines.AddImageSigning(pipeline, cfg.ImageSigning.Secret) {
+		log.Warningf("The image built by the %s pipeline isn't signed, the task that builds it doesn't have an IMAGE_DIGEST result", pipeline.Name)
+	}
+	if cfg.SupplyChain != nil {
+		pipelines.AddProvenanceResults(pipeline)
+	}
+	return pipeline
+}
+
 // remove the commit status task and it's dependency
 func removeCommitStatus(pipeline *pipelinev1.Pipeline, driver string) *pipelinev1.Pipeline {
 	if driver == "" {
@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/redhat-developer/kam/pkg/pipelines/argocd"
+	"github.com/redhat-developer/kam/pkg/pipelines/chains"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 	"github.com/redhat-developer/kam/pkg/pipelines/deployment"
 	"github.com/redhat-developer/kam/pkg/pipelines/eventlisteners"
@@ -17,6 +18,11 @@ import (
 	"github.com/redhat-developer/kam/pkg/pipelines/routes"
 	"github.com/redhat-developer/kam/pkg/pipelines/scm"
 	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
+	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	"github.com/spf13/afero"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -78,7 +84,7 @@ func TestBootstrapManifest(t *testing.T) {
 		"environments/tst-dev/apps/app-http-api/services/http-api/base/config/kustomization.yaml": &res.Kustomization{
 			Resources: []string{"100-deployment.yaml", "200-service.yaml", "300-route.yaml"}},
 		pipelinesFile: &config.Manifest{
-			Version:   version,
+			Version:   config.ManifestVersion,
 			GitOpsURL: "https://github.com/my-org/gitops.git",
 			Environments: []*config.Environment{
 				{
@@ -104,7 +110,7 @@ func TestBootstrapManifest(t *testing.T) {
 										},
 									},
 									Pipelines: &config.Pipelines{
-										Integration: &config.TemplateBinding{Bindings: []string{"tst-dev-app-http-api-http-api-binding", "github-push-binding"}},
+										Integration: &config.TemplateBinding{Template: "app-cd-template", Bindings: []string{"tst-dev-app-http-api-http-api-binding", "tst-dev-app-http-api-http-api-cd-binding", "github-push-binding"}},
 									},
 								},
 							},
@@ -136,11 +142,19 @@ func TestBootstrapManifest(t *testing.T) {
 		"02-rolebindings/pipeline-service-rolebinding.yaml",
 		"03-tasks/deploy-from-source-task.yaml",
 		"03-tasks/set-commit-status-task.yaml",
+		"03-tasks/update-image-pull-request-task.yaml",
+		"04-pipelines/app-cd-pipeline.yaml",
 		"04-pipelines/app-ci-pipeline.yaml",
+		"04-pipelines/app-ci-pr-pipeline.yaml",
 		"04-pipelines/ci-dryrun-from-push-pipeline.yaml",
+		"05-bindings/github-pr-binding.yaml",
 		"05-bindings/github-push-binding.yaml",
 		"05-bindings/tst-dev-app-http-api-http-api-binding.yaml",
+		"05-bindings/tst-dev-app-http-api-http-api-cd-binding.yaml",
+		"06-templates/app-cd-build-from-push-template.yaml",
+		"06-templates/app-ci-build-from-pr-template.yaml",
 		"06-templates/app-ci-build-from-push-template.yaml",
+		"06-templates/ci-dryrun-from-pr-template.yaml",
 		"06-templates/ci-dryrun-from-push-template.yaml",
 		"07-eventlisteners/cicd-event-listener.yaml",
 		"08-routes/gitops-webhook-event-listener.yaml",
@@ -249,7 +263,7 @@ func TestCreateManifest(t *testing.T) {
 	want := &config.Manifest{
 		GitOpsURL: repoURL,
 		Config:    Config,
-		Version:   version,
+		Version:   config.ManifestVersion,
 	}
 	got := createManifest(repoURL, Config)
 	if diff := cmp.Diff(want, got); diff != "" {
@@ -286,10 +300,149 @@ func TestInitialFiles(t *testing.T) {
 	}
 }
 
+func TestCreateCICDResourcesWithCosignKey(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, afero.WriteFile(fakeFs, "/keys/cosign.key", []byte("encrypted key"), 0600))
+	repo, err := scm.NewRepository(testGitOpsRepo)
+	assertNoError(t, err)
+	o := &BootstrapOptions{GitOpsWebhookSecret: "123", CosignKey: "/keys/cosign.key", CosignPassword: "secret"}
+	cfg := &config.PipelinesConfig{Name: "tst-cicd", ImageSigning: imageSigning(o)}
+
+	outputs, otherOutputs, err := createCICDResources(fakeFs, repo, cfg, o)
+	assertNoError(t, err)
+
+	wantSecret := secrets.CreateUnsealedCosignSecret(meta.NamespacedName("tst-cicd", "cosign-key"), []byte("encrypted key"), "secret")
+	if diff := cmp.Diff(wantSecret, otherOutputs["secrets/cosign-key.yaml"]); diff != "" {
+		t.Fatalf("createCICDResources() failed to create the cosign Secret:\n%s", diff)
+	}
+	sa := outputs[serviceAccountPath].(*corev1.ServiceAccount)
+	if diff := cmp.Diff([]corev1.ObjectReference{{Name: "cosign-key"}}, sa.Secrets); diff != "" {
+		t.Fatalf("createCICDResources() failed to add the cosign Secret to the ServiceAccount:\n%s", diff)
+	}
+	for _, path := range []string{appCiPipelinesPath, appCDPipelinesPath} {
+		names := []string{}
+		for _, task := range outputs[path].(*pipelinev1.Pipeline).Spec.Tasks {
+			names = append(names, task.Name)
+		}
+		if !hasString(names, "sign-image") || !hasString(names, "generate-sbom") {
+			t.Fatalf("%s doesn't sign the image: %v", path, names)
+		}
+	}
+}
+
+func TestCreateCICDResourcesWithSupplyChain(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, afero.WriteFile(fakeFs, "/keys/cosign.key", []byte("encrypted key"), 0600))
+	repo, err := scm.NewRepository(testGitOpsRepo)
+	assertNoError(t, err)
+	o := &BootstrapOptions{GitOpsWebhookSecret: "123", CosignKey: "/keys/cosign.key", CosignPassword: "secret", SupplyChain: true}
+	cfg := &config.PipelinesConfig{Name: "tst-cicd", ImageSigning: imageSigning(o), SupplyChain: supplyChain(o)}
+
+	outputs, otherOutputs, err := createCICDResources(fakeFs, repo, cfg, o)
+	assertNoError(t, err)
+
+	if diff := cmp.Diff(chains.CreateConfig("openshift-pipelines", false), outputs[chainsConfigPath]); diff != "" {
+		t.Fatalf("createCICDResources() failed to create the chains config:\n%s", diff)
+	}
+	wantSecret := secrets.CreateUnsealedCosignSecret(meta.NamespacedName("openshift-pipelines", "signing-secrets"), []byte("encrypted key"), "secret")
+	if diff := cmp.Diff(wantSecret, otherOutputs["secrets/signing-secrets.yaml"]); diff != "" {
+		t.Fatalf("createCICDResources() failed to create the signing Secret:\n%s", diff)
+	}
+	for _, path := range []string{appCiPipelinesPath, appCDPipelinesPath} {
+		results := []string{}
+		for _, r := range outputs[path].(*pipelinev1.Pipeline).Spec.Results {
+			results = append(results, r.Name)
+		}
+		if diff := cmp.Diff([]string{"IMAGE_URL", "IMAGE_DIGEST"}, results); diff != "" {
+			t.Fatalf("%s results didn't match:\n%s", path, diff)
+		}
+	}
+}
+
+func TestCreateCICDResourcesWithSupplyChainTransparency(t *testing.T) {
+	repo, err := scm.NewRepository(testGitOpsRepo)
+	assertNoError(t, err)
+	transparencyTests := []struct {
+		transparency bool
+		want         string
+	}{
+		{false, "false"},
+		{true, "true"},
+	}
+
+	for _, tt := range transparencyTests {
+		o := &BootstrapOptions{GitOpsWebhookSecret: "123", SupplyChain: true, SupplyChainTransparency: tt.transparency}
+		cfg := &config.PipelinesConfig{Name: "tst-cicd", SupplyChain: supplyChain(o)}
+		if cfg.SupplyChain.Transparency != tt.transparency {
+			t.Fatalf("supplyChain() got transparency %v, want %v", cfg.SupplyChain.Transparency, tt.transparency)
+		}
+
+		outputs, _, err := createCICDResources(ioutils.NewMemoryFilesystem(), repo, cfg, o)
+		assertNoError(t, err)
+
+		if got := outputs[chainsConfigPath].(*chains.TektonChain).Spec.TransparencyEnabled; got != tt.want {
+			t.Fatalf("createCICDResources() got transparency.enabled %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCreateCICDResourcesWithSupplyChainWithoutCosignKey(t *testing.T) {
+	repo, err := scm.NewRepository(testGitOpsRepo)
+	assertNoError(t, err)
+	o := &BootstrapOptions{GitOpsWebhookSecret: "123", SupplyChain: true}
+	cfg := &config.PipelinesConfig{Name: "tst-cicd", SupplyChain: supplyChain(o)}
+
+	outputs, otherOutputs, err := createCICDResources(ioutils.NewMemoryFilesystem(), repo, cfg, o)
+	assertNoError(t, err)
+
+	if _, ok := outputs[chainsConfigPath]; !ok {
+		t.Fatal("createCICDResources() didn't create the chains config")
+	}
+	if _, ok := otherOutputs["secrets/signing-secrets.yaml"]; ok {
+		t.Fatal("createCICDResources() created a signing Secret without a cosign key")
+	}
+}
+
+func TestCreateCICDResourcesWithTektonV1(t *testing.T) {
+	repo, err := scm.NewRepository(testGitOpsRepo)
+	assertNoError(t, err)
+	o := &BootstrapOptions{GitOpsWebhookSecret: "123", TektonAPIVersion: "v1"}
+	cfg := &config.PipelinesConfig{Name: "tst-cicd", TektonAPIVersion: tektonAPIVersion(o)}
+
+	outputs, _, err := createCICDResources(ioutils.NewMemoryFilesystem(), repo, cfg, o)
+	assertNoError(t, err)
+
+	if diff := cmp.Diff("tekton.dev/v1", outputs[gitopsTasksPath].(map[string]interface{})["apiVersion"]); diff != "" {
+		t.Fatalf("createCICDResources() failed to convert the Task:\n%s", diff)
+	}
+	if diff := cmp.Diff("tekton.dev/v1", outputs[ciPipelinesPath].(map[string]interface{})["apiVersion"]); diff != "" {
+		t.Fatalf("createCICDResources() failed to convert the Pipeline:\n%s", diff)
+	}
+	if diff := cmp.Diff("triggers.tekton.dev/v1beta1", outputs[eventListenerPath].(triggersv1.EventListener).APIVersion); diff != "" {
+		t.Fatalf("createCICDResources() failed to convert the EventListener:\n%s", diff)
+	}
+	want := triggers.CreateCIDryRunTemplate("tst-cicd", saName)
+	triggers.ConvertTemplate(&want, tekton.V1)
+	if diff := cmp.Diff(want, outputs[pushTemplatePath]); diff != "" {
+		t.Fatalf("createCICDResources() failed to convert the TriggerTemplate:\n%s", diff)
+	}
+}
+
+func TestCreateCICDResourcesWithMissingCosignKey(t *testing.T) {
+	repo, err := scm.NewRepository(testGitOpsRepo)
+	assertNoError(t, err)
+	o := &BootstrapOptions{GitOpsWebhookSecret: "123", CosignKey: "/keys/cosign.key"}
+
+	_, _, err = createCICDResources(ioutils.NewMemoryFilesystem(), repo, testpipelineConfig, o)
+	if err == nil {
+		t.Fatal("createCICDResources() didn't fail with a missing cosign key")
+	}
+}
+
 func TestGetCICDKustomization(t *testing.T) {
 	want := res.Resources{
 		"overlays/kustomization.yaml": res.Kustomization{
-			Bases: []string{"../base"},
+			Resources: []string{"../base"},
 		},
 		"base/kustomization.yaml": res.Kustomization{
 			Resources: []string{"resource1", "resource2"},
@@ -1,6 +1,12 @@
 package pipelines
 
 import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
 	"github.com/redhat-developer/kam/pkg/pipelines/argocd"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 	"github.com/redhat-developer/kam/pkg/pipelines/environments"
@@ -14,20 +20,100 @@ import (
 type BuildParameters struct {
 	PipelinesFolderPath string
 	OutputPath          string
+	Prune               bool // Remove previously generated files that are no longer generated.
+	DryRun              bool // Only report the files that would be pruned, nothing is written.
 }
 
 // BuildResources builds all resources from a pipelines.
-func BuildResources(o *BuildParameters, appFs afero.Fs) error {
+//
+// It returns the previously generated files that are no longer generated from
+// the manifest, these are removed if Prune is set.
+func BuildResources(o *BuildParameters, appFs afero.Fs) ([]string, error) {
 	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	resources, err := buildResources(appFs, m)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := keepOverlayImages(appFs, o.OutputPath, m, resources); err != nil {
+		return nil, err
+	}
+	previous, err := readGeneratedFiles(appFs, o.OutputPath)
+	if err != nil {
+		return nil, err
+	}
+	stale := staleFiles(previous, resources)
+	if o.DryRun {
+		return stale, nil
+	}
+	removed := []string{}
+	if o.Prune {
+		removed = stale
+	}
+	if err := addCICDKustomization(appFs, o.OutputPath, m.GetPipelinesConfig(), resources, removed); err != nil {
+		return nil, err
+	}
+	if o.Prune {
+		if err := pruneFiles(appFs, o.OutputPath, stale); err != nil {
+			return nil, err
+		}
+		resources = res.Merge(generatedFilesIndex(resources), resources)
+	} else {
+		resources = res.Merge(generatedFilesIndex(resources, stale...), resources)
 	}
 	_, err = yaml.WriteResources(appFs, o.OutputPath, resources)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	clusterSecrets, err := argocd.ClusterSecrets(appFs, o.PipelinesFolderPath, argocd.ArgoCDNamespace, m)
+	if err != nil {
+		return nil, err
+	}
+	// the cluster secrets contain the credentials, so they're written outside
+	// of the GitOps repository, like the other unencrypted secrets.
+	_, err = yaml.WriteResources(appFs, filepath.Join(o.OutputPath, ".."), clusterSecrets) // Don't call filepath.ToSlash
+	if err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// addCICDKustomization adds the files that are written to the CICD base to the
+// resources of the base kustomization, which is added to the files.
+//
+// The removed paths, relative to root, are dropped from the resources, the
+// resources are otherwise kept, as the base also has the files that were
+// created when bootstrapping or adding services.
+func addCICDKustomization(fs afero.Fs, root string, cfg *config.PipelinesConfig, files res.Resources, removed []string) error {
+	if cfg == nil {
+		return nil
+	}
+	base := filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg), "base"))
+	kustomizationPath := path.Join(base, Kustomize)
+	built := []string{}
+	for k := range files {
+		if name := strings.TrimPrefix(filepath.ToSlash(k), base+"/"); name != filepath.ToSlash(k) && name != Kustomize {
+			built = append(built, name)
+		}
+	}
+	k, err := readKustomization(fs, root, kustomizationPath, files)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err != nil && len(built) == 0 {
+		return nil
+	}
+	resources := []string{}
+	for _, r := range k.Resources {
+		if len(without([]string{path.Join(base, r)}, removed)) > 0 {
+			resources = append(resources, r)
+		}
+	}
+	k.Resources = addUnique(resources, built...)
+	files[kustomizationPath] = k
+	return nil
 }
 
 func buildResources(fs afero.Fs, m *config.Manifest) (res.Resources, error) {
@@ -0,0 +1,76 @@
+package chains
+
+import (
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+)
+
+const (
+	// DefaultNamespace is the namespace that the OpenShift Pipelines Operator
+	// installs Tekton Chains in.
+	DefaultNamespace = "openshift-pipelines"
+
+	// ConfigName is the name of the TektonChain that the OpenShift Pipelines
+	// Operator configures Tekton Chains from.
+	ConfigName = "chain"
+
+	// SigningSecretName is the name of the Secret with the cosign key that
+	// Tekton Chains signs with, it must be in the namespace of Tekton Chains.
+	SigningSecretName = "signing-secrets"
+)
+
+var tektonChainTypeMeta = meta.TypeMeta("TektonChain", "operator.tekton.dev/v1alpha1")
+
+// TektonChain is the OpenShift Pipelines Operator resource that installs and
+// configures Tekton Chains, the operator writes its spec to the chains-config
+// ConfigMap.
+type TektonChain struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TektonChainSpec `json:"spec,omitempty"`
+}
+
+// TektonChainSpec defines the namespace that Tekton Chains is installed in,
+// and the keys of the chains-config ConfigMap.
+type TektonChainSpec struct {
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	ArtifactsTaskRunFormat      string `json:"artifacts.taskrun.format,omitempty"`
+	ArtifactsTaskRunStorage     string `json:"artifacts.taskrun.storage,omitempty"`
+	ArtifactsPipelineRunFormat  string `json:"artifacts.pipelinerun.format,omitempty"`
+	ArtifactsPipelineRunStorage string `json:"artifacts.pipelinerun.storage,omitempty"`
+	ArtifactsOCIStorage         string `json:"artifacts.oci.storage,omitempty"`
+	TransparencyEnabled         string `json:"transparency.enabled,omitempty"`
+}
+
+// CreateConfig returns the TektonChain that installs Tekton Chains in the
+// namespace, and configures it to generate in-toto SLSA provenance for the
+// TaskRuns and PipelineRuns that build images, and store it, and the
+// signatures, in the image's OCI registry.
+//
+// If transparency is true, they're also uploaded to the public Rekor
+// transparency log, which discloses the image names, and the details of the
+// builds, so it's disabled otherwise.
+//
+// The operator owns the chains-config ConfigMap, so it's configured through
+// the TektonChain, rather than written directly.
+func CreateConfig(ns string, transparency bool) *TektonChain {
+	return &TektonChain{
+		TypeMeta:   tektonChainTypeMeta,
+		ObjectMeta: meta.ObjectMeta(types.NamespacedName{Name: ConfigName}),
+		Spec: TektonChainSpec{
+			TargetNamespace:             ns,
+			ArtifactsTaskRunFormat:      "in-toto",
+			ArtifactsTaskRunStorage:     "oci",
+			ArtifactsPipelineRunFormat:  "in-toto",
+			ArtifactsPipelineRunStorage: "oci",
+			ArtifactsOCIStorage:         "oci",
+			TransparencyEnabled:         strconv.FormatBool(transparency),
+		},
+	}
+}
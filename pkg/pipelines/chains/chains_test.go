@@ -0,0 +1,62 @@
+package chains
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCreateConfig(t *testing.T) {
+	configTests := []struct {
+		transparency bool
+		want         string
+	}{
+		{false, "false"},
+		{true, "true"},
+	}
+
+	for _, tt := range configTests {
+		want := &TektonChain{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "TektonChain",
+				APIVersion: "operator.tekton.dev/v1alpha1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "chain",
+			},
+			Spec: TektonChainSpec{
+				TargetNamespace:             "tekton-chains",
+				ArtifactsTaskRunFormat:      "in-toto",
+				ArtifactsTaskRunStorage:     "oci",
+				ArtifactsPipelineRunFormat:  "in-toto",
+				ArtifactsPipelineRunStorage: "oci",
+				ArtifactsOCIStorage:         "oci",
+				TransparencyEnabled:         tt.want,
+			},
+		}
+
+		if diff := cmp.Diff(want, CreateConfig("tekton-chains", tt.transparency)); diff != "" {
+			t.Fatalf("CreateConfig() with transparency %v failed:\n%s", tt.transparency, diff)
+		}
+	}
+}
+
+func TestCreateConfigKeys(t *testing.T) {
+	b, err := yaml.Marshal(CreateConfig("tekton-chains", false).Spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `artifacts.oci.storage: oci
+artifacts.pipelinerun.format: in-toto
+artifacts.pipelinerun.storage: oci
+artifacts.taskrun.format: in-toto
+artifacts.taskrun.storage: oci
+targetNamespace: tekton-chains
+transparency.enabled: "false"
+`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Fatalf("CreateConfig() keys failed:\n%s", diff)
+	}
+}
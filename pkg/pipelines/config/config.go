@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 const (
@@ -85,6 +87,71 @@ func (m *Manifest) AddService(envName, appName string, svc *Service) error {
 	return nil
 }
 
+// GetService returns the named service from an Application within a specific
+// environment.
+func (m *Manifest) GetService(envName, appName, svcName string) (*Service, error) {
+	if m.GetEnvironment(envName) == nil {
+		return nil, fmt.Errorf("environment %s does not exist", envName)
+	}
+	app := m.GetApplication(envName, appName)
+	if app == nil {
+		return nil, fmt.Errorf("application %s does not exist in environment %s", appName, envName)
+	}
+	for _, svc := range app.Services {
+		if svc.Name == svcName {
+			return svc, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s does not exist in application %s of environment %s", svcName, appName, envName)
+}
+
+// RemoveService removes a service from an Application within a specific
+// environment, the Application is removed if it has no services left.
+//
+// It returns the removed service.
+func (m *Manifest) RemoveService(envName, appName, svcName string) (*Service, error) {
+	env := m.GetEnvironment(envName)
+	if env == nil {
+		return nil, fmt.Errorf("environment %s does not exist", envName)
+	}
+	app := m.GetApplication(envName, appName)
+	if app == nil {
+		return nil, fmt.Errorf("application %s does not exist in environment %s", appName, envName)
+	}
+	for i, svc := range app.Services {
+		if svc.Name == svcName {
+			app.Services = append(app.Services[:i], app.Services[i+1:]...)
+			if len(app.Services) == 0 {
+				env.Apps = removeApplication(env.Apps, appName)
+			}
+			return svc, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s does not exist in application %s of environment %s", svcName, appName, envName)
+}
+
+// RemoveEnvironment removes a named environment from the configuration.
+//
+// It returns the removed environment.
+func (m *Manifest) RemoveEnvironment(envName string) (*Environment, error) {
+	for i, env := range m.Environments {
+		if env.Name == envName {
+			m.Environments = append(m.Environments[:i], m.Environments[i+1:]...)
+			return env, nil
+		}
+	}
+	return nil, fmt.Errorf("environment %s does not exist", envName)
+}
+
+func removeApplication(apps []*Application, appName string) []*Application {
+	for i, app := range apps {
+		if app.Name == appName {
+			return append(apps[:i], apps[i+1:]...)
+		}
+	}
+	return apps
+}
+
 // GetPipelinesConfig returns the global Pipelines configuration, if one exists.
 func (m *Manifest) GetPipelinesConfig() *PipelinesConfig {
 	if m.Config != nil {
@@ -101,13 +168,32 @@ func (m *Manifest) GetArgoCDConfig() *ArgoCDConfig {
 	return nil
 }
 
+// GetCluster returns a named cluster if it's declared in the configuration.
+func (m *Manifest) GetCluster(name string) *Cluster {
+	if m.Config == nil {
+		return nil
+	}
+	for _, cluster := range m.Config.Clusters {
+		if cluster.Name == name {
+			return cluster
+		}
+	}
+	return nil
+}
+
 // Environment is a slice of Apps, these are the named apps in the namespace.
 //
+// The Cluster is the name of a cluster declared in the configuration, for
+// compatibility with older manifests, it can also be the URL of the cluster's
+// API server.
 type Environment struct {
 	Name      string         `json:"name,omitempty"`
 	Cluster   string         `json:"cluster,omitempty"`
 	Pipelines *Pipelines     `json:"pipelines,omitempty"`
 	Apps      []*Application `json:"apps,omitempty"`
+	// ArgoCD configures the environment's Argo CD Application, and is the
+	// default for the Argo CD Applications of the environment's applications.
+	ArgoCD *ArgoCDApplicationConfig `json:"argocd,omitempty"`
 }
 
 // Config represents the configuration for non-application environments.
@@ -115,16 +201,87 @@ type Config struct {
 	Pipelines *PipelinesConfig `json:"pipelines,omitempty"`
 	ArgoCD    *ArgoCDConfig    `json:"argocd,omitempty"`
 	Git       *GitConfig       `json:"git,omitempty"`
+	Clusters  []*Cluster       `json:"clusters,omitempty"`
 }
 
 // PipelinesConfig provides configuration for the CI/CD pipelines.
 type PipelinesConfig struct {
 	Name string `json:"name,omitempty"`
+	// ImageSigning signs the images that are built by the pipelines, and
+	// attests an SBOM for them.
+	ImageSigning *ImageSigning `json:"image_signing,omitempty"`
+	// SupplyChain configures Tekton Chains to generate SLSA provenance for
+	// the images that are built by the pipelines.
+	SupplyChain *SupplyChain `json:"supply_chain,omitempty"`
+	// TektonAPIVersion is the version of the Tekton APIs that the Pipelines,
+	// Tasks and Triggers resources are generated for, v1beta1 if empty.
+	TektonAPIVersion tekton.Version `json:"tekton_api_version,omitempty"`
+}
+
+// SupplyChain configures Tekton Chains.
+type SupplyChain struct {
+	// Namespace is the namespace that Tekton Chains is installed in.
+	Namespace string `json:"namespace,omitempty"`
+	// Transparency records the signatures and provenance in the public Rekor
+	// transparency log, if false they're only stored in the image registry.
+	Transparency bool `json:"transparency,omitempty"`
+}
+
+// ImageSigning configures the signing of images with cosign.
+type ImageSigning struct {
+	// Secret is the name of a Secret in the CICD namespace, with the cosign
+	// private key in the "cosign.key" key, and its password in the
+	// "cosign.password" key.
+	Secret string `json:"secret,omitempty"`
 }
 
 // ArgoCDConfig provides configuration for the ArgoCD application generation.
 type ArgoCDConfig struct {
 	Namespace string `json:"namespace,omitempty"`
+	// ApplicationSets generates ApplicationSets for the environments and
+	// applications, rather than an Application for each of them.
+	ApplicationSets bool `json:"application_sets,omitempty"`
+	// Projects generates an AppProject for each environment, restricting
+	// where the environment's applications are deployed from and to.
+	Projects *ArgoCDProjectsConfig `json:"projects,omitempty"`
+}
+
+// ArgoCDProjectsConfig configures the AppProjects generated for the
+// environments.
+type ArgoCDProjectsConfig struct {
+	// ClusterResources are the cluster-scoped kinds that can be deployed to the
+	// environments, if omitted, only Namespaces can be deployed.
+	ClusterResources []*GroupKind `json:"cluster_resources,omitempty"`
+}
+
+// GroupKind identifies a kind of resource, the core group is empty.
+type GroupKind struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+}
+
+// Cluster is a cluster that environments can be deployed to, it's registered
+// with ArgoCD using the credentials.
+type Cluster struct {
+	Name        string              `json:"name,omitempty"`
+	Server      string              `json:"server,omitempty"`
+	Credentials *ClusterCredentials `json:"credentials,omitempty"`
+}
+
+// ClusterCredentials refers to the files with the credentials for accessing a
+// cluster, either a bearer token, or a kubeconfig file.
+//
+// Relative paths are relative to the pipelines folder, the files should not be
+// committed to the GitOps repository.
+type ClusterCredentials struct {
+	BearerTokenFile string `json:"bearer_token_file,omitempty"`
+	// CAFile is the certificate authority for the server, used with the bearer
+	// token, if omitted, the system's roots are used.
+	CAFile         string `json:"ca_file,omitempty"`
+	KubeconfigFile string `json:"kubeconfig_file,omitempty"`
+	// Context is the context in the kubeconfig file to use, if omitted, the
+	// current context is used.
+	Context string `json:"context,omitempty"`
 }
 
 // GitConfig configures the git drivers.
@@ -141,9 +298,50 @@ func (e Environment) GoString() string {
 // The ConfigRepo indicates that the configuration for this application lives in
 // another repository.
 type Application struct {
-	Name       string      `json:"name,omitempty"`
-	Services   []*Service  `json:"services,omitempty"`
-	ConfigRepo *Repository `json:"config_repo,omitempty"`
+	Name       string                   `json:"name,omitempty"`
+	Services   []*Service               `json:"services,omitempty"`
+	ConfigRepo *Repository              `json:"config_repo,omitempty"`
+	ArgoCD     *ArgoCDApplicationConfig `json:"argocd,omitempty"`
+}
+
+// ArgoCDApplicationConfig configures the generated Argo CD Application for an
+// environment or an application.
+type ArgoCDApplicationConfig struct {
+	// ManualSync disables the automated sync of the Application.
+	ManualSync *bool `json:"manual_sync,omitempty"`
+	// SyncOptions are the Argo CD sync options e.g. CreateNamespace=true.
+	SyncOptions []string `json:"sync_options,omitempty"`
+	// Retry configures retrying failed syncs.
+	Retry *ArgoCDRetry `json:"retry,omitempty"`
+	// IgnoreDifferences are added to the environment's differences for an
+	// application.
+	IgnoreDifferences []*ArgoCDIgnoreDifference `json:"ignore_differences,omitempty"`
+	// SyncWave is the Application's sync wave, it's not inherited by the
+	// environment's applications.
+	SyncWave *int `json:"sync_wave,omitempty"`
+}
+
+// ArgoCDRetry configures retrying failed syncs.
+type ArgoCDRetry struct {
+	Limit   int64          `json:"limit,omitempty"`
+	Backoff *ArgoCDBackoff `json:"backoff,omitempty"`
+}
+
+// ArgoCDBackoff configures the backoff between retries of failed syncs.
+type ArgoCDBackoff struct {
+	Duration    string `json:"duration,omitempty"`
+	Factor      *int64 `json:"factor,omitempty"`
+	MaxDuration string `json:"max_duration,omitempty"`
+}
+
+// ArgoCDIgnoreDifference identifies fields of resources that are ignored when
+// comparing the live state with the desired state.
+type ArgoCDIgnoreDifference struct {
+	Group        string   `json:"group,omitempty"`
+	Kind         string   `json:"kind,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Namespace    string   `json:"namespace,omitempty"`
+	JSONPointers []string `json:"json_pointers,omitempty"`
 }
 
 // Service has an upstream source.
@@ -152,6 +350,19 @@ type Service struct {
 	Webhook   *Webhook   `json:"webhook,omitempty"`
 	SourceURL string     `json:"source_url,omitempty"`
 	Pipelines *Pipelines `json:"pipelines,omitempty"`
+	// Image is the image that's deployed for the service, it's set in the
+	// service's overlay, usually with kam service set-image.
+	Image *ServiceImage `json:"image,omitempty"`
+}
+
+// ServiceImage replaces an image in a service's resources.
+type ServiceImage struct {
+	// Name is the image in the service's resources that is replaced.
+	Name string `json:"name,omitempty"`
+	// Repo is the repository of the image that is deployed.
+	Repo   string `json:"repo,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Digest string `json:"digest,omitempty"`
 }
 
 // Webhook provides Github webhook secret for eventlisteners
@@ -180,6 +391,31 @@ type Repository struct {
 // These pipelines will be executed with a Git clone URL and commit SHA.
 type Pipelines struct {
 	Integration *TemplateBinding `json:"integration,omitempty"`
+	// Stages are run in order against the service's source before the image
+	// is built, a failing stage stops the image from being built.
+	Stages []*Stage `json:"stages,omitempty"`
+}
+
+// Stage is a pre-build step, either a command that's run in an image, or a
+// SonarQube scan of the source.
+//
+// If the Script is empty, the image's entrypoint is run, which is useful for
+// linter images.
+type Stage struct {
+	Name      string         `json:"name,omitempty"`
+	Image     string         `json:"image,omitempty"`
+	Script    string         `json:"script,omitempty"`
+	SonarQube *SonarQubeScan `json:"sonarqube,omitempty"`
+}
+
+// SonarQubeScan scans the source with the SonarQube scanner, and fails if the
+// project doesn't pass its quality gate.
+type SonarQubeScan struct {
+	HostURL    string `json:"host_url,omitempty"`
+	ProjectKey string `json:"project_key,omitempty"`
+	// TokenSecret is the name of a Secret in the CICD namespace with the
+	// SonarQube token in the "token" key.
+	TokenSecret string `json:"token_secret,omitempty"`
 }
 
 // TemplateBinding is a combination of the template and binding to be used for a
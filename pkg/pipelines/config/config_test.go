@@ -202,6 +202,125 @@ func TestGetEnvironment(t *testing.T) {
 		t.Fatalf("found an unknown env: %#v", unknown)
 	}
 }
+
+func TestRemoveService(t *testing.T) {
+	newManifest := func() *Manifest {
+		return &Manifest{
+			Environments: []*Environment{
+				{
+					Name: "dev",
+					Apps: []*Application{
+						{Name: "app-1", Services: []*Service{{Name: "svc-1"}, {Name: "svc-2"}}},
+						{Name: "app-2", Services: []*Service{{Name: "svc-3"}}},
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		desc     string
+		env      string
+		app      string
+		svc      string
+		wantApps []*Application
+		wantErr  string
+	}{
+		{"removing a service", "dev", "app-1", "svc-1",
+			[]*Application{
+				{Name: "app-1", Services: []*Service{{Name: "svc-2"}}},
+				{Name: "app-2", Services: []*Service{{Name: "svc-3"}}},
+			}, ""},
+		{"removing the last service removes the app", "dev", "app-2", "svc-3",
+			[]*Application{
+				{Name: "app-1", Services: []*Service{{Name: "svc-1"}, {Name: "svc-2"}}},
+			}, ""},
+		{"unknown environment", "prod", "app-1", "svc-1", nil, "environment prod does not exist"},
+		{"unknown application", "dev", "app-3", "svc-1", nil, "application app-3 does not exist in environment dev"},
+		{"unknown service", "dev", "app-1", "svc-3", nil, "service svc-3 does not exist in application app-1 of environment dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(rt *testing.T) {
+			m := newManifest()
+			svc, err := m.RemoveService(tt.env, tt.app, tt.svc)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					rt.Fatalf("RemoveService() got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if svc.Name != tt.svc {
+				rt.Fatalf("RemoveService() returned the wrong service: %#v", svc)
+			}
+			if diff := cmp.Diff(tt.wantApps, m.Environments[0].Apps); diff != "" {
+				rt.Fatalf("RemoveService() apps:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetService(t *testing.T) {
+	m := &Manifest{
+		Environments: []*Environment{
+			{
+				Name: "dev",
+				Apps: []*Application{
+					{Name: "app-1", Services: []*Service{{Name: "svc-1"}, {Name: "svc-2"}}},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		desc    string
+		env     string
+		app     string
+		svc     string
+		wantErr string
+	}{
+		{"getting a service", "dev", "app-1", "svc-2", ""},
+		{"unknown environment", "prod", "app-1", "svc-1", "environment prod does not exist"},
+		{"unknown application", "dev", "app-2", "svc-1", "application app-2 does not exist in environment dev"},
+		{"unknown service", "dev", "app-1", "svc-3", "service svc-3 does not exist in application app-1 of environment dev"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(rt *testing.T) {
+			svc, err := m.GetService(tt.env, tt.app, tt.svc)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					rt.Fatalf("GetService() got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if svc != m.Environments[0].Apps[0].Services[1] {
+				rt.Fatalf("GetService() returned the wrong service: %#v", svc)
+			}
+		})
+	}
+}
+
+func TestRemoveEnvironment(t *testing.T) {
+	m := &Manifest{Environments: makeEnvs([]testEnv{{name: "prod"}, {name: "testing"}})}
+	env, err := m.RemoveEnvironment("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Name != "prod" {
+		t.Fatalf("removed the wrong environment: %#v", env)
+	}
+	if diff := cmp.Diff(makeEnvs([]testEnv{{name: "testing"}}), m.Environments); diff != "" {
+		t.Fatalf("RemoveEnvironment() environments:\n%s", diff)
+	}
+	_, err = m.RemoveEnvironment("unknown")
+	if err == nil || err.Error() != "environment unknown does not exist" {
+		t.Fatalf("RemoveEnvironment() got error %v", err)
+	}
+}
+
 func makeEnvs(ns []testEnv) []*Environment {
 	n := make([]*Environment, len(ns))
 	for i, v := range ns {
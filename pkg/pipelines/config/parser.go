@@ -6,12 +6,26 @@ import (
 	"io/ioutil"
 	"path/filepath"
 
+	"github.com/mkmik/multierror"
+	"github.com/openshift/odo/pkg/log"
 	"github.com/spf13/afero"
 	"sigs.k8s.io/yaml"
 )
 
-// Parse decodes YAML describing an environment manifest.
+// Parse decodes YAML describing an environment manifest, manifests with a
+// newer version than ManifestVersion are rejected.
+//
+// From StrictFieldsVersion, fields that are not part of the manifest are
+// reported as errors, with the line that they're on, in older manifests they
+// are logged as warnings.
 func Parse(in io.Reader) (*Manifest, error) {
+	return ParseWithWarnings(in, logUnknownFields)
+}
+
+// ParseWithWarnings parses the manifest in the same way as Parse, but the
+// unknown fields in manifests before StrictFieldsVersion are passed to warn,
+// rather than being logged.
+func ParseWithWarnings(in io.Reader, warn func(error)) (*Manifest, error) {
 	m := &Manifest{}
 	buf, err := ioutil.ReadAll(in)
 	if err != nil {
@@ -21,9 +35,24 @@ func Parse(in io.Reader) (*Manifest, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := checkVersion(m); err != nil {
+		return nil, err
+	}
+	if err := CheckFields(buf); err != nil {
+		if m.Version >= StrictFieldsVersion {
+			return nil, err
+		}
+		warn(err)
+	}
 	return m, nil
 }
 
+func logUnknownFields(err error) {
+	for _, err := range multierror.Split(err) {
+		log.Warningf("%s in %s, it's ignored, and is rejected from manifest version %d", err, PipelinesFile, StrictFieldsVersion)
+	}
+}
+
 // ParseFile is a wrapper around Parse that accepts a filename, it opens and
 // parses the file, and closes it.
 func ParseFile(fs afero.Fs, filename string) (*Manifest, error) {
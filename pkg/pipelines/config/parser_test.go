@@ -2,11 +2,14 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/mkmik/multierror"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
 	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+	"github.com/spf13/afero"
 )
 
 var parseTests = []struct {
@@ -175,3 +178,112 @@ func TestParsePipelinesFolder(t *testing.T) {
 		t.Fatalf("ParsePipelinesFolder() failed: %s", diff)
 	}
 }
+
+func TestParseVersion(t *testing.T) {
+	versionTests := []struct {
+		body    string
+		want    int
+		wantErr string
+	}{
+		{"environments: []\n", 0, ""},
+		{"version: 1\n", 1, ""},
+		{"version: 2\n", 2, ""},
+		{"version: 3\n", 3, ""},
+		{"version: 4\n", 0, "the manifest version 4 is newer than the latest version 3 supported by this kam, please upgrade kam"},
+		{"version: -1\n", 0, "invalid manifest version -1"},
+	}
+
+	for _, tt := range versionTests {
+		t.Run(tt.body, func(rt *testing.T) {
+			m, err := Parse(strings.NewReader(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					rt.Fatalf("Parse() got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if m.Version != tt.want {
+				rt.Fatalf("Parse() got version %d, want %d", m.Version, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUnknownFields(t *testing.T) {
+	fs := ioutils.NewFilesystem()
+	f, err := fs.Open("testdata/unknown_fields.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, err = Parse(f)
+	want := multierror.Join([]error{
+		&UnknownFieldError{Path: "environments.development.pipelines.integration.binding", Line: 6, Column: 9},
+		&UnknownFieldError{Path: "environments.development.apps.my-app-1.services.service-http.webhooks", Line: 12, Column: 13},
+		&UnknownFieldError{Path: "config.argocd.ignore_differences", Line: 18, Column: 5},
+	})
+	if diff := cmp.Diff(want.Error(), fmt.Sprint(err)); diff != "" {
+		t.Fatalf("Parse() failed:\n%s", diff)
+	}
+}
+
+func TestParseUnknownFieldsBeforeStrictFieldsVersion(t *testing.T) {
+	body, err := afero.ReadFile(ioutils.NewFilesystem(), "testdata/unknown_fields.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []string{"version: 2", ""} {
+		t.Run(version, func(rt *testing.T) {
+			warnings := []string{}
+			m, err := ParseWithWarnings(strings.NewReader(strings.Replace(string(body), "version: 3", version, 1)), func(err error) {
+				for _, err := range multierror.Split(err) {
+					warnings = append(warnings, err.Error())
+				}
+			})
+			if err != nil {
+				rt.Fatalf("ParseWithWarnings() failed: %v", err)
+			}
+			if m.Version >= StrictFieldsVersion {
+				rt.Fatalf("ParseWithWarnings() got version %d", m.Version)
+			}
+			want := []string{
+				`line 6: unknown field "environments.development.pipelines.integration.binding"`,
+				`line 12: unknown field "environments.development.apps.my-app-1.services.service-http.webhooks"`,
+				`line 18: unknown field "config.argocd.ignore_differences"`,
+			}
+			if diff := cmp.Diff(want, warnings); diff != "" {
+				rt.Fatalf("ParseWithWarnings() warnings failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFieldLine(t *testing.T) {
+	body, err := afero.ReadFile(ioutils.NewFilesystem(), "testdata/unknown_fields.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lineTests := []struct {
+		path string
+		want int
+	}{
+		{"environments.development", 2},
+		{"environments.development.pipelines.integration.template", 5},
+		{"environments.development.apps.my-app-1.services.service-http.source_url", 11},
+		{"config.argocd.ignore_differences.0.kind", 19},
+		{"environments.staging", 0},
+		{"config.pipelines", 0},
+	}
+
+	for _, tt := range lineTests {
+		if got := FieldLine(body, tt.path); got != tt.want {
+			t.Errorf("FieldLine(%q) got %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
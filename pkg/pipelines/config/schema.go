@@ -0,0 +1,82 @@
+package config
+
+import (
+	"reflect"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is the subset of JSON Schema (draft-07) that is needed to
+// describe the manifest.
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Properties  map[string]*JSONSchema `json:"properties,omitempty"`
+	Items       *JSONSchema            `json:"items,omitempty"`
+	Minimum     *int                   `json:"minimum,omitempty"`
+	Maximum     *int                   `json:"maximum,omitempty"`
+	Definitions map[string]*JSONSchema `json:"definitions,omitempty"`
+	// AdditionalProperties is either false, or the schema of the values of a
+	// map.
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// Schema returns a JSON Schema for the manifest, generated from the Manifest
+// struct, so that editors can validate and complete pipelines.yaml files.
+//
+// Each struct is a definition, named after the struct, and fields that are not
+// part of the manifest are not allowed, as with Parse.
+func Schema() *JSONSchema {
+	definitions := map[string]*JSONSchema{}
+	root := schemaForType(reflect.TypeOf(Manifest{}), definitions)
+	minVersion, maxVersion := 0, ManifestVersion
+	definitions["Manifest"].Properties["version"].Minimum = &minVersion
+	definitions["Manifest"].Properties["version"].Maximum = &maxVersion
+	return &JSONSchema{
+		Schema:      jsonSchemaDraft,
+		Ref:         root.Ref,
+		Title:       "kam " + PipelinesFile,
+		Definitions: definitions,
+	}
+}
+
+func schemaForType(t reflect.Type, definitions map[string]*JSONSchema) *JSONSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		ref := &JSONSchema{Ref: "#/definitions/" + t.Name()}
+		if _, ok := definitions[t.Name()]; ok {
+			return ref
+		}
+		s := &JSONSchema{
+			Type:                 "object",
+			Properties:           map[string]*JSONSchema{},
+			AdditionalProperties: false,
+		}
+		// the definition is added before the fields, so that recursive types
+		// refer to it, rather than recursing forever.
+		definitions[t.Name()] = s
+		for name, field := range jsonFields(t) {
+			s.Properties[name] = schemaForType(field.Type, definitions)
+		}
+		return ref
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaForType(t.Elem(), definitions)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaForType(t.Elem(), definitions)}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	}
+	return &JSONSchema{}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchema(t *testing.T) {
+	s := Schema()
+
+	if s.Ref != "#/definitions/Manifest" {
+		t.Fatalf("Schema() got root %q, want %q", s.Ref, "#/definitions/Manifest")
+	}
+	want := &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"template": {Type: "string"},
+			"bindings": {Type: "array", Items: &JSONSchema{Type: "string"}},
+		},
+		AdditionalProperties: false,
+	}
+	if diff := cmp.Diff(want, s.Definitions["TemplateBinding"]); diff != "" {
+		t.Fatalf("Schema() failed to describe TemplateBinding:\n%s", diff)
+	}
+	want = &JSONSchema{Type: "object", AdditionalProperties: &JSONSchema{Type: "string"}}
+	if diff := cmp.Diff(want, s.Definitions["GitConfig"].Properties["drivers"]); diff != "" {
+		t.Fatalf("Schema() failed to describe the git drivers:\n%s", diff)
+	}
+	if v := s.Definitions["Manifest"].Properties["version"]; *v.Maximum != ManifestVersion {
+		t.Fatalf("Schema() got maximum version %d, want %d", *v.Maximum, ManifestVersion)
+	}
+}
+
+func TestSchemaManifestProperties(t *testing.T) {
+	got := []string{}
+	for name := range Schema().Definitions["Manifest"].Properties {
+		got = append(got, name)
+	}
+	sort.Strings(got)
+
+	want := []string{"config", "environments", "gitops_url", "version"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Schema() failed to describe the Manifest:\n%s", diff)
+	}
+}
@@ -0,0 +1,183 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mkmik/multierror"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// UnknownFieldError is a field in the manifest that doesn't exist in the
+// manifest format, this is usually a typo.
+type UnknownFieldError struct {
+	// Path is the dot-separated path to the field, items in lists are
+	// identified by their name, or their index if they don't have a name.
+	Path   string
+	Line   int
+	Column int
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("line %d: unknown field %q", e.Line, e.Path)
+}
+
+// CheckFields returns an error for each field in the YAML that isn't a field of
+// the Manifest, these are only rejected by Parse from StrictFieldsVersion, and
+// are warnings in older manifests.
+//
+// The YAML is parsed again, rather than unmarshalling strictly, as the JSON
+// decoder that is used to unmarshal the manifest doesn't know the lines of the
+// fields, and stops at the first unknown field.
+func CheckFields(buf []byte) error {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+	errs := checkNode(&doc, reflect.TypeOf(Manifest{}), "")
+	if len(errs) == 0 {
+		return nil
+	}
+	return multierror.Join(errs)
+}
+
+func checkNode(node *yamlv3.Node, t reflect.Type, path string) []error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return checkNode(node.Content[0], t, path)
+	case yamlv3.AliasNode:
+		return checkNode(node.Alias, t, path)
+	case yamlv3.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		errs := []error{}
+		for i, item := range node.Content {
+			errs = append(errs, checkNode(item, t.Elem(), joinFieldPath(path, itemName(item, i)))...)
+		}
+		return errs
+	case yamlv3.MappingNode:
+		return checkMapping(node, t, path)
+	}
+	return nil
+}
+
+func checkMapping(node *yamlv3.Node, t reflect.Type, path string) []error {
+	errs := []error{}
+	switch t.Kind() {
+	case reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkNode(node.Content[i+1], t.Elem(), joinFieldPath(path, node.Content[i].Value))...)
+		}
+	case reflect.Struct:
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			fieldPath := joinFieldPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, &UnknownFieldError{Path: fieldPath, Line: key.Line, Column: key.Column})
+				continue
+			}
+			errs = append(errs, checkNode(value, field.Type, fieldPath)...)
+		}
+	}
+	return errs
+}
+
+// jsonFields returns the fields of a struct keyed by their JSON names.
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if ok {
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// jsonFieldName returns the name of the field when it's encoded as JSON, and
+// false if the field isn't encoded.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// FieldLine returns the line of a field in the manifest YAML, the path is
+// dot-separated, with items in lists identified by their name, or their index
+// if they don't have a name, as in the paths of validation errors.
+//
+// It returns 0 if the field doesn't exist.
+func FieldLine(buf []byte, path string) int {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(buf, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+	node := doc.Content[0]
+	line := 0
+	for _, field := range strings.Split(path, ".") {
+		if node.Kind == yamlv3.AliasNode {
+			node = node.Alias
+		}
+		var next *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == field {
+					line, next = node.Content[i].Line, node.Content[i+1]
+					break
+				}
+			}
+		case yamlv3.SequenceNode:
+			for i, item := range node.Content {
+				if itemName(item, i) == field {
+					line, next = item.Line, item
+					break
+				}
+			}
+		}
+		if next == nil {
+			return 0
+		}
+		node = next
+	}
+	return line
+}
+
+func itemName(node *yamlv3.Node, i int) string {
+	if node.Kind == yamlv3.MappingNode {
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == "name" && node.Content[j+1].Kind == yamlv3.ScalarNode && node.Content[j+1].Value != "" {
+				return node.Content[j+1].Value
+			}
+		}
+	}
+	return strconv.Itoa(i)
+}
+
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return yamlJoin(path, field)
+}
@@ -14,18 +14,25 @@ func LoadManifest(fs afero.Fs, path string) (*Manifest, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load manifest: %w", err)
 	}
-	if !(m.Config == nil || m.Config.Git == nil || m.Config.Git.Drivers == nil) {
-		drivers := []factory.MappingFunc{}
-		for k, v := range m.Config.Git.Drivers {
-			drivers = append(drivers, factory.Mapping(k, v))
-		}
-		if len(drivers) > 0 {
-			id := factory.NewDriverIdentifier(drivers...)
-			factory.DefaultIdentifier = id
-		}
-	}
+	ConfigureDrivers(m)
 	if err := m.Validate(); err != nil {
 		return nil, err
 	}
 	return m, nil
 }
+
+// ConfigureDrivers configures the identification of the git drivers with the
+// drivers in the manifest's configuration.
+func ConfigureDrivers(m *Manifest) {
+	if m.Config == nil || m.Config.Git == nil || m.Config.Git.Drivers == nil {
+		return
+	}
+	drivers := []factory.MappingFunc{}
+	for k, v := range m.Config.Git.Drivers {
+		drivers = append(drivers, factory.Mapping(k, v))
+	}
+	if len(drivers) > 0 {
+		id := factory.NewDriverIdentifier(drivers...)
+		factory.DefaultIdentifier = id
+	}
+}
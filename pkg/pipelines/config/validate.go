@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/mkmik/multierror"
@@ -12,17 +13,21 @@ import (
 )
 
 const (
-	longServiceName  = "a service name cannot exceed 47 characters"
-	serviceNameLimit = 47
+	longServiceName      = "a service name cannot exceed 47 characters"
+	serviceNameLimit     = 47
+	defaultArgoCDProject = "default"
 )
 
 type validateVisitor struct {
-	errs         []error
-	envNames     map[string]bool
-	appNames     map[string]bool
-	serviceNames map[string]bool
-	serviceURLs  map[string][]string
-	configNames  map[string]bool
+	errs            []error
+	envNames        map[string]bool
+	appNames        map[string]bool
+	serviceNames    map[string]bool
+	serviceURLs     map[string][]string
+	configNames     map[string]bool
+	clusterNames    map[string]bool
+	projects        bool
+	applicationSets bool
 }
 
 // Validate validates the Manifest, returning a multi-error representing all the
@@ -35,6 +40,7 @@ func (m *Manifest) Validate() error {
 		serviceNames: map[string]bool{},
 		serviceURLs:  map[string][]string{},
 		configNames:  map[string]bool{},
+		clusterNames: map[string]bool{},
 	}
 
 	vv.errs = append(vv.errs, vv.validateConfig(m)...)
@@ -85,6 +91,9 @@ func (vv *validateVisitor) Environment(env *Environment) error {
 	if _, ok := vv.configNames[env.Name]; ok {
 		vv.errs = append(vv.errs, invalidEnvironment(env.Name, "Environment name cannot be the same as a config name.", []string{envPath}))
 	}
+	if vv.projects && env.Name == defaultArgoCDProject {
+		vv.errs = append(vv.errs, invalidEnvironment(env.Name, "Environment name cannot be the same as the Argo CD default project when projects are generated.", []string{envPath}))
+	}
 	if err := checkDuplicate(env.Name, envPath, vv.envNames); err != nil {
 		vv.errs = append(vv.errs, err)
 	}
@@ -94,6 +103,10 @@ func (vv *validateVisitor) Environment(env *Environment) error {
 	if err := validatePipelines(env.Pipelines, envPath); err != nil {
 		vv.errs = append(vv.errs, err...)
 	}
+	vv.errs = append(vv.errs, vv.validateArgoCD(env.ArgoCD, envPath)...)
+	if env.Cluster != "" && !isServerURL(env.Cluster) && !vv.clusterNames[env.Cluster] {
+		vv.errs = append(vv.errs, undeclaredClusterError(env.Cluster, []string{yamlJoin(envPath, "cluster")}))
+	}
 	return nil
 }
 
@@ -113,6 +126,7 @@ func (vv *validateVisitor) Application(env *Environment, app *Application) error
 		vv.errs = append(vv.errs, apis.ErrMultipleOneOf(yamlJoin(appPath, "services"), yamlJoin(appPath, "config_repo")))
 	}
 
+	vv.errs = append(vv.errs, vv.validateArgoCD(app.ArgoCD, appPath)...)
 	if app.ConfigRepo != nil {
 		vv.errs = append(vv.errs, validateConfigRepo(app.ConfigRepo, yamlJoin(appPath, "config_repo"))...)
 	}
@@ -151,6 +165,9 @@ func (vv *validateVisitor) Service(app *Application, env *Environment, svc *Serv
 	if err := validateWebhook(svc.Webhook, svcPath); err != nil {
 		vv.errs = append(vv.errs, err...)
 	}
+	if err := validateImage(svc.Image, svcPath); err != nil {
+		vv.errs = append(vv.errs, err...)
+	}
 	if err := validatePipelines(svc.Pipelines, svcPath); err != nil {
 		vv.errs = append(vv.errs, err...)
 	}
@@ -190,17 +207,73 @@ func validateWebhook(hook *Webhook, path string) []error {
 	return errs
 }
 
+func validateImage(image *ServiceImage, path string) []error {
+	if image == nil {
+		return nil
+	}
+	missing := []string{}
+	if image.Name == "" {
+		missing = append(missing, "name")
+	}
+	if image.Repo == "" {
+		missing = append(missing, "repo")
+	}
+	if len(missing) > 0 {
+		return list(missingFieldsError(missing, []string{yamlJoin(path, "image")}))
+	}
+	return nil
+}
+
 func validatePipelines(pipelines *Pipelines, path string) []error {
 	errs := []error{}
 	if pipelines == nil {
 		return nil
 	}
-	if pipelines.Integration == nil {
-		return list(missingFieldsError([]string{"integration"}, []string{yamlJoin(path, "pipelines")}))
+	if pipelines.Integration == nil && len(pipelines.Stages) == 0 {
+		return list(missingFieldsError([]string{"integration", "stages"}, []string{yamlJoin(path, "pipelines")}))
 	}
-	for _, name := range pipelines.Integration.Bindings {
-		if err := validateName(name, yamlJoin(path, "pipelines", "integration", "binding")); err != nil {
-			errs = append(errs, err)
+	if pipelines.Integration != nil {
+		for _, name := range pipelines.Integration.Bindings {
+			if err := validateName(name, yamlJoin(path, "pipelines", "integration", "binding")); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	names := map[string]bool{}
+	for i, stage := range pipelines.Stages {
+		errs = append(errs, validateStage(stage, yamlJoin(path, "pipelines", "stages", strconv.Itoa(i)), names)...)
+	}
+	return errs
+}
+
+func validateStage(stage *Stage, path string, names map[string]bool) []error {
+	errs := []error{}
+	if stage.Name == "" {
+		errs = append(errs, missingFieldsError([]string{"name"}, []string{path}))
+	} else if err := validateName(stage.Name, yamlJoin(path, "name")); err != nil {
+		errs = append(errs, err)
+	} else if names[stage.Name] {
+		errs = append(errs, duplicateFieldsError([]string{stage.Name}, []string{yamlJoin(path, "name")}))
+	}
+	names[stage.Name] = true
+	switch {
+	case stage.Image == "" && stage.SonarQube == nil:
+		errs = append(errs, missingFieldsError([]string{"image", "sonarqube"}, []string{path}))
+	case stage.SonarQube != nil && (stage.Image != "" || stage.Script != ""):
+		errs = append(errs, apis.ErrMultipleOneOf(yamlJoin(path, "image"), yamlJoin(path, "sonarqube")))
+	case stage.SonarQube != nil:
+		missing := []string{}
+		if stage.SonarQube.HostURL == "" {
+			missing = append(missing, "host_url")
+		}
+		if stage.SonarQube.ProjectKey == "" {
+			missing = append(missing, "project_key")
+		}
+		if stage.SonarQube.TokenSecret == "" {
+			missing = append(missing, "token_secret")
+		}
+		if len(missing) > 0 {
+			errs = append(errs, missingFieldsError(missing, []string{yamlJoin(path, "sonarqube")}))
 		}
 	}
 	return errs
@@ -213,17 +286,110 @@ func (vv *validateVisitor) validateConfig(manifest *Manifest) []error {
 				errs = append(errs, err)
 			}
 			vv.configNames[manifest.Config.ArgoCD.Namespace] = true
+			vv.applicationSets = manifest.Config.ArgoCD.ApplicationSets
+			if projects := manifest.Config.ArgoCD.Projects; projects != nil {
+				vv.projects = true
+				for i, gk := range projects.ClusterResources {
+					if gk.Kind == "" {
+						errs = append(errs, missingFieldsError([]string{"kind"}, []string{yamlJoin("config", "argocd", "projects", "cluster_resources", strconv.Itoa(i))}))
+					}
+				}
+			}
 		}
 		if manifest.Config.Pipelines != nil {
 			if err := validateName(manifest.Config.Pipelines.Name, yamlPath(PathForPipelines(manifest.Config.Pipelines))); err != nil {
 				errs = append(errs, err)
 			}
 			vv.configNames[manifest.Config.Pipelines.Name] = true
+			if signing := manifest.Config.Pipelines.ImageSigning; signing != nil {
+				signingPath := yamlJoin("config", "pipelines", "image_signing")
+				if signing.Secret == "" {
+					errs = append(errs, missingFieldsError([]string{"secret"}, []string{signingPath}))
+				} else if err := validateName(signing.Secret, yamlJoin(signingPath, "secret")); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if supplyChain := manifest.Config.Pipelines.SupplyChain; supplyChain != nil {
+				supplyChainPath := yamlJoin("config", "pipelines", "supply_chain")
+				if supplyChain.Namespace == "" {
+					errs = append(errs, missingFieldsError([]string{"namespace"}, []string{supplyChainPath}))
+				} else if err := validateName(supplyChain.Namespace, yamlJoin(supplyChainPath, "namespace")); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			if version := manifest.Config.Pipelines.TektonAPIVersion; !version.IsValid() {
+				errs = append(errs, apis.ErrInvalidValue(version, yamlJoin("config", "pipelines", "tekton_api_version")))
+			}
+		}
+		for _, cluster := range manifest.Config.Clusters {
+			errs = append(errs, vv.validateCluster(cluster)...)
 		}
 	}
 	return errs
 }
 
+func (vv *validateVisitor) validateCluster(cluster *Cluster) []error {
+	clusterPath := yamlJoin("config", "clusters", cluster.Name)
+	errs := []error{}
+	if vv.clusterNames[cluster.Name] {
+		errs = append(errs, duplicateFieldsError([]string{cluster.Name}, []string{clusterPath}))
+	}
+	vv.clusterNames[cluster.Name] = true
+	if err := validateName(cluster.Name, clusterPath); err != nil {
+		errs = append(errs, err)
+	}
+	creds := cluster.Credentials
+	if creds == nil {
+		return append(errs, missingFieldsError([]string{"credentials"}, []string{clusterPath}))
+	}
+	credsPath := yamlJoin(clusterPath, "credentials")
+	switch {
+	case creds.BearerTokenFile == "" && creds.KubeconfigFile == "":
+		errs = append(errs, missingFieldsError([]string{"bearer_token_file", "kubeconfig_file"}, []string{credsPath}))
+	case creds.BearerTokenFile != "" && creds.KubeconfigFile != "":
+		errs = append(errs, apis.ErrMultipleOneOf(yamlJoin(credsPath, "bearer_token_file"), yamlJoin(credsPath, "kubeconfig_file")))
+	}
+	// the server can only be omitted if it's read from the kubeconfig file.
+	if cluster.Server == "" && creds.KubeconfigFile == "" {
+		errs = append(errs, missingFieldsError([]string{"server"}, []string{clusterPath}))
+	}
+	return errs
+}
+
+func (vv *validateVisitor) validateArgoCD(cfg *ArgoCDApplicationConfig, path string) []error {
+	if cfg == nil {
+		return nil
+	}
+	argoCDPath := yamlJoin(path, "argocd")
+	if vv.applicationSets {
+		return list(&apis.FieldError{
+			Message: "the Argo CD configuration can't be used with application_sets",
+			Details: "The Applications generated by the ApplicationSets share their configuration.",
+			Paths:   []string{argoCDPath},
+		})
+	}
+	errs := []error{}
+	for i, d := range cfg.IgnoreDifferences {
+		missingFields := []string{}
+		if d.Kind == "" {
+			missingFields = append(missingFields, "kind")
+		}
+		if len(d.JSONPointers) == 0 {
+			missingFields = append(missingFields, "json_pointers")
+		}
+		if len(missingFields) > 0 {
+			errs = append(errs, missingFieldsError(missingFields, []string{yamlJoin(argoCDPath, "ignore_differences", strconv.Itoa(i))}))
+		}
+	}
+	return errs
+}
+
+// isServerURL returns true if the environment's cluster is the URL of an API
+// server, rather than the name of a declared cluster.
+func isServerURL(cluster string) bool {
+	return strings.Contains(cluster, "://")
+}
+
 func validateName(name, path string) *apis.FieldError {
 	err := validation.NameIsDNS1035Label(name, true)
 	if len(err) > 0 {
@@ -277,6 +443,14 @@ func duplicateFieldsError(fields, paths []string) *apis.FieldError {
 	}
 }
 
+func undeclaredClusterError(cluster string, paths []string) *apis.FieldError {
+	return &apis.FieldError{
+		Message: fmt.Sprintf("cluster %q is not declared", cluster),
+		Details: "The cluster must be declared in config.clusters, or be the URL of the cluster's API server.",
+		Paths:   paths,
+	}
+}
+
 func missingServiceError(app string, paths []string) *apis.FieldError {
 	return &apis.FieldError{
 		Message: fmt.Sprintf("missing service app %q", app),
@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/mkmik/multierror"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 	"knative.dev/pkg/apis"
 )
 
@@ -67,7 +68,8 @@ var validateTests = []struct {
 		"testdata/missing_fields_error.yaml",
 		multierror.Join([]error{
 			missingFieldsError([]string{"secret"}, []string{"environments.development.apps.app-1.services.service-1.webhook"}),
-			missingFieldsError([]string{"integration"}, []string{"environments.development.apps.app-1.services.service-1.pipelines"}),
+			missingFieldsError([]string{"name", "repo"}, []string{"environments.development.apps.app-1.services.service-1.image"}),
+			missingFieldsError([]string{"integration", "stages"}, []string{"environments.development.apps.app-1.services.service-1.pipelines"}),
 		}),
 	},
 	{
@@ -120,6 +122,104 @@ var validateTests = []struct {
 			},
 		),
 	},
+	{
+		"cluster errors",
+		"testdata/cluster_errors.yaml",
+		multierror.Join(
+			[]error{
+				duplicateFieldsError([]string{"prod"}, []string{"config.clusters.prod"}),
+				missingFieldsError([]string{"credentials"}, []string{"config.clusters.stage"}),
+				apis.ErrMultipleOneOf("config.clusters.qa.credentials.bearer_token_file", "config.clusters.qa.credentials.kubeconfig_file"),
+				missingFieldsError([]string{"bearer_token_file", "kubeconfig_file"}, []string{"config.clusters.test.credentials"}),
+				missingFieldsError([]string{"server"}, []string{"config.clusters.test"}),
+				undeclaredClusterError("staging", []string{"environments.staging.cluster"}),
+			},
+		),
+	},
+	{
+		"projects errors",
+		"testdata/projects_errors.yaml",
+		multierror.Join(
+			[]error{
+				missingFieldsError([]string{"kind"}, []string{"config.argocd.projects.cluster_resources.1"}),
+				invalidEnvironment("default", "Environment name cannot be the same as the Argo CD default project when projects are generated.", []string{"environments.default"}),
+			},
+		),
+	},
+	{
+		"argocd errors",
+		"testdata/argocd_errors.yaml",
+		multierror.Join(
+			[]error{
+				missingFieldsError([]string{"json_pointers"}, []string{"environments.production.apps.app-1.argocd.ignore_differences.0"}),
+				missingFieldsError([]string{"kind", "json_pointers"}, []string{"environments.production.argocd.ignore_differences.1"}),
+			},
+		),
+	},
+	{
+		"argocd configuration with application sets",
+		"testdata/argocd_application_sets.yaml",
+		multierror.Join(
+			[]error{
+				&apis.FieldError{
+					Message: "the Argo CD configuration can't be used with application_sets",
+					Details: "The Applications generated by the ApplicationSets share their configuration.",
+					Paths:   []string{"environments.production.argocd"},
+				},
+			},
+		),
+	},
+	{
+		"image signing errors",
+		"testdata/image_signing_errors.yaml",
+		multierror.Join(
+			[]error{
+				missingFieldsError([]string{"secret"}, []string{"config.pipelines.image_signing"}),
+			},
+		),
+	},
+	{
+		"supply chain errors",
+		"testdata/supply_chain_errors.yaml",
+		multierror.Join(
+			[]error{
+				missingFieldsError([]string{"namespace"}, []string{"config.pipelines.supply_chain"}),
+			},
+		),
+	},
+	{
+		"tekton api version errors",
+		"testdata/tekton_api_version_errors.yaml",
+		multierror.Join(
+			[]error{
+				apis.ErrInvalidValue(tekton.Version("v1alpha1"), "config.pipelines.tekton_api_version"),
+			},
+		),
+	},
+	{
+		"pipeline stages errors",
+		"testdata/stages_errors.yaml",
+		multierror.Join(
+			[]error{
+				missingFieldsError([]string{"name"}, []string{"environments.development.apps.app-1.services.service-1.pipelines.stages.0"}),
+				missingFieldsError([]string{"image", "sonarqube"}, []string{"environments.development.apps.app-1.services.service-1.pipelines.stages.1"}),
+				apis.ErrMultipleOneOf("environments.development.apps.app-1.services.service-1.pipelines.stages.2.image", "environments.development.apps.app-1.services.service-1.pipelines.stages.2.sonarqube"),
+				missingFieldsError([]string{"project_key", "token_secret"}, []string{"environments.development.apps.app-1.services.service-1.pipelines.stages.3.sonarqube"}),
+				duplicateFieldsError([]string{"unit-tests"}, []string{"environments.development.pipelines.stages.1.name"}),
+				invalidNameError("Lint", DNS1035Error, []string{"environments.development.pipelines.stages.2.name"}),
+			},
+		),
+	},
+	{
+		"valid pipeline stages",
+		"testdata/valid_stages.yaml",
+		nil,
+	},
+	{
+		"valid clusters",
+		"testdata/valid_clusters.yaml",
+		nil,
+	},
 	{
 		"service with pipeline with no template",
 		"testdata/service_with_bindings_no_template.yaml",
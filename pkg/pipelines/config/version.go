@@ -0,0 +1,24 @@
+package config
+
+import "fmt"
+
+// ManifestVersion is the version of the manifest that's written by this
+// version of kam, older manifests can be upgraded with kam migrate.
+const ManifestVersion = 3
+
+// StrictFieldsVersion is the first version of the manifest that fields that
+// are not part of the manifest are rejected in, they're ignored in older
+// manifests, so that they can still be loaded and migrated.
+const StrictFieldsVersion = 3
+
+// checkVersion returns an error if the manifest is newer than the manifests
+// that this version of kam understands.
+func checkVersion(m *Manifest) error {
+	if m.Version > ManifestVersion {
+		return fmt.Errorf("the manifest version %d is newer than the latest version %d supported by this kam, please upgrade kam", m.Version, ManifestVersion)
+	}
+	if m.Version < 0 {
+		return fmt.Errorf("invalid manifest version %d", m.Version)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package pipelines
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+)
+
+// DiffParameters is a struct that provides flags for the DiffResources
+// command.
+type DiffParameters struct {
+	PipelinesFolderPath string
+	OutputPath          string
+	Prune               bool // Show the removal of files that are no longer generated.
+}
+
+// FileDiff is the difference between a file on disk and the file that would
+// be written by a build.
+type FileDiff struct {
+	Path   string
+	Status string
+	Diff   string
+}
+
+const (
+	// DiffAdded indicates that the build would create a new file.
+	DiffAdded = "added"
+	// DiffChanged indicates that the build would change an existing file.
+	DiffChanged = "changed"
+	// DiffRemoved indicates that the build would remove an existing file.
+	DiffRemoved = "removed"
+)
+
+// DiffResources builds all resources from a pipelines into an in-memory
+// overlay of the filesystem, and returns the differences between the overlay
+// and the files on disk, sorted by path.
+func DiffResources(o *DiffParameters, appFs afero.Fs) ([]FileDiff, error) {
+	overlay := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(appFs), afero.NewMemMapFs())
+	stale, err := BuildResources(&BuildParameters{PipelinesFolderPath: o.PipelinesFolderPath, OutputPath: o.OutputPath}, overlay)
+	if err != nil {
+		return nil, err
+	}
+	root, err := homedir.Expand(o.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	before, err := readFiles(appFs, root)
+	if err != nil {
+		return nil, err
+	}
+	after, err := readFiles(overlay, root)
+	if err != nil {
+		return nil, err
+	}
+	if o.Prune {
+		// files can't be removed from the overlay, so the pruning is applied
+		// to the files read back from it.
+		if err := pruneFromIndex(after, stale); err != nil {
+			return nil, err
+		}
+	}
+	return diffFiles(before, after)
+}
+
+func pruneFromIndex(files map[string][]byte, stale []string) error {
+	if len(stale) == 0 {
+		return nil
+	}
+	index := generatedFiles{}
+	if err := yaml.Unmarshal(files[generatedFilesPath], &index); err != nil {
+		return fmt.Errorf("failed to parse the generated files index %s: %w", generatedFilesPath, err)
+	}
+	pruned := map[string]bool{}
+	for _, f := range stale {
+		pruned[f] = true
+		delete(files, f)
+	}
+	kept := []string{}
+	for _, f := range index.Files {
+		if !pruned[f] {
+			kept = append(kept, f)
+		}
+	}
+	body, err := yaml.Marshal(generatedFiles{Files: kept})
+	if err != nil {
+		return fmt.Errorf("failed to marshal the generated files index: %w", err)
+	}
+	files[generatedFilesPath] = body
+	return nil
+}
+
+func diffFiles(before, after map[string][]byte) ([]FileDiff, error) {
+	paths := []string{}
+	for k := range before {
+		paths = append(paths, k)
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			paths = append(paths, k)
+		}
+	}
+	sort.Strings(paths)
+
+	diffs := []FileDiff{}
+	for _, path := range paths {
+		oldBody, inBefore := before[path]
+		newBody, inAfter := after[path]
+		if inBefore && inAfter && bytes.Equal(oldBody, newBody) {
+			continue
+		}
+		fromFile, toFile := "a/"+path, "b/"+path
+		status := DiffChanged
+		switch {
+		case !inBefore:
+			status, fromFile = DiffAdded, "/dev/null"
+		case !inAfter:
+			status, toFile = DiffRemoved, "/dev/null"
+		}
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(oldBody),
+			B:        splitLines(newBody),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w", path, err)
+		}
+		diffs = append(diffs, FileDiff{Path: path, Status: status, Diff: text})
+	}
+	return diffs, nil
+}
+
+// splitLines splits the body into lines, keeping the line endings, unlike
+// difflib.SplitLines it doesn't add an empty line to newline terminated files.
+func splitLines(body []byte) []string {
+	if len(body) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(body), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// readFiles returns the contents of all the files below root, keyed by their
+// slash separated path relative to root, .git directories are skipped.
+func readFiles(fs afero.Fs, root string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		body, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = body
+		return nil
+	})
+	return files, err
+}
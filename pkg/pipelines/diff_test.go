@@ -0,0 +1,130 @@
+package pipelines
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+func TestDiffResources(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	m := &config.Manifest{
+		GitOpsURL: "https://github.com/org/gitops.git",
+		Environments: []*config.Environment{
+			{Name: "test-dev"},
+		},
+	}
+	_, err := yaml.WriteResources(fakeFs, "/", res.Resources{pipelinesFile: m})
+	assertNoError(t, err)
+	o := &DiffParameters{PipelinesFolderPath: "/", OutputPath: "/"}
+
+	diffs, err := DiffResources(o, fakeFs)
+	assertNoError(t, err)
+	if len(diffs) == 0 {
+		t.Fatal("DiffResources() returned no differences before building")
+	}
+	for _, d := range diffs {
+		if d.Status != DiffAdded {
+			t.Errorf("%s: got status %q, want %q", d.Path, d.Status, DiffAdded)
+		}
+	}
+	exists, err := fakeFs.Exists("/environments/test-dev/env/base/kustomization.yaml")
+	assertNoError(t, err)
+	if exists {
+		t.Fatal("DiffResources() wrote to the filesystem")
+	}
+
+	_, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/"}, fakeFs)
+	assertNoError(t, err)
+	diffs, err = DiffResources(o, fakeFs)
+	assertNoError(t, err)
+	if diff := cmp.Diff([]FileDiff{}, diffs); diff != "" {
+		t.Fatalf("DiffResources() after build:\n%s", diff)
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	before := map[string][]byte{
+		"same.yaml":    []byte("a: b\n"),
+		"changed.yaml": []byte("a: b\nc: d\n"),
+		"removed.yaml": []byte("a: b\n"),
+	}
+	after := map[string][]byte{
+		"same.yaml":    []byte("a: b\n"),
+		"changed.yaml": []byte("a: b\nc: e\n"),
+		"added.yaml":   []byte("a: b\n"),
+	}
+	got, err := diffFiles(before, after)
+	assertNoError(t, err)
+
+	want := []FileDiff{
+		{
+			Path:   "added.yaml",
+			Status: DiffAdded,
+			Diff:   "--- /dev/null\n+++ b/added.yaml\n@@ -0,0 +1 @@\n+a: b\n",
+		},
+		{
+			Path:   "changed.yaml",
+			Status: DiffChanged,
+			Diff:   "--- a/changed.yaml\n+++ b/changed.yaml\n@@ -1,2 +1,2 @@\n a: b\n-c: d\n+c: e\n",
+		},
+		{
+			Path:   "removed.yaml",
+			Status: DiffRemoved,
+			Diff:   "--- a/removed.yaml\n+++ /dev/null\n@@ -1 +0,0 @@\n-a: b\n",
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("diffFiles() failed:\n%s", diff)
+	}
+	for _, d := range got {
+		if !strings.HasSuffix(d.Diff, "\n") {
+			t.Errorf("%s: diff is not newline terminated", d.Path)
+		}
+	}
+}
+
+func TestDiffResourcesWithPrune(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	m := &config.Manifest{
+		GitOpsURL: "https://github.com/org/gitops.git",
+		Environments: []*config.Environment{
+			{Name: "test-dev"},
+			{Name: "test-stage"},
+		},
+	}
+	_, err := yaml.WriteResources(fakeFs, "/", res.Resources{pipelinesFile: m})
+	assertNoError(t, err)
+	_, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/"}, fakeFs)
+	assertNoError(t, err)
+	m.Environments = m.Environments[:1]
+	_, err = yaml.WriteResources(fakeFs, "/", res.Resources{pipelinesFile: m})
+	assertNoError(t, err)
+
+	diffs, err := DiffResources(&DiffParameters{PipelinesFolderPath: "/", OutputPath: "/", Prune: true}, fakeFs)
+	assertNoError(t, err)
+	removed := 0
+	for _, d := range diffs {
+		switch {
+		case d.Path == generatedFilesPath:
+			if d.Status != DiffChanged {
+				t.Errorf("%s: got status %q, want %q", d.Path, d.Status, DiffChanged)
+			}
+		case strings.HasPrefix(d.Path, "environments/test-stage/"):
+			if d.Status != DiffRemoved {
+				t.Errorf("%s: got status %q, want %q", d.Path, d.Status, DiffRemoved)
+			}
+			removed++
+		default:
+			t.Errorf("unexpected difference in %s", d.Path)
+		}
+	}
+	if removed == 0 {
+		t.Fatal("DiffResources() didn't report the removed environment")
+	}
+}
@@ -14,7 +14,7 @@ cmd={{ .Cmd }}
 overall_exit=0
 
 execute() {
-  if [[ ! -z "${cmd}" ]]; then $cmd apply --dry-run=$(inputs.params.DRYRUN) -k $1; fi
+  if [[ ! -z "${cmd}" ]]; then $cmd apply --dry-run=$(params.DRYRUN) -k $1; fi
   e=$?
   if [ $e -gt $overall_exit ]; then
     overall_exit=$e
@@ -41,11 +41,49 @@ func AddEnv(o *EnvParameters, appFs afero.Fs) error {
 	if err != nil {
 		return fmt.Errorf("failed to build resources: %v", err)
 	}
+	if err := keepOverlayImages(appFs, o.PipelinesFolderPath, m, built); err != nil {
+		return err
+	}
 	files = res.Merge(built, files)
+	index, err := updateGeneratedFilesIndex(appFs, o.PipelinesFolderPath, built)
+	if err != nil {
+		return err
+	}
+	files = res.Merge(index, files)
 	_, err = yaml.WriteResources(appFs, o.PipelinesFolderPath, files)
 	return err
 }
 
+// RemoveEnv removes an environment from the pipelines file, regenerates the
+// resources and removes the files that were generated for the environment and
+// its services.
+func RemoveEnv(o *EnvParameters, appFs afero.Fs) error {
+	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
+	if err != nil {
+		return err
+	}
+	before, err := buildResources(appFs, m)
+	if err != nil {
+		return fmt.Errorf("failed to build resources: %v", err)
+	}
+	env, err := m.RemoveEnvironment(o.EnvName)
+	if err != nil {
+		return err
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	owned := []string{config.PathForEnvironment(env)}
+	secretFiles := []string{}
+	for _, app := range env.Apps {
+		for _, svc := range app.Services {
+			owned = append(owned, serviceCICDFiles(m.GetPipelinesConfig(), env, app, svc)...)
+			secretFiles = append(secretFiles, serviceSecretFiles(svc)...)
+		}
+	}
+	return rebuildAfterRemoval(appFs, o.PipelinesFolderPath, m, before, owned, secretFiles)
+}
+
 func newEnvironment(m *config.Manifest, name string) (*config.Environment, error) {
 	pipelinesConfig := m.GetPipelinesConfig()
 	if pipelinesConfig != nil && m.GitOpsURL != "" {
@@ -245,3 +245,48 @@ func mustReadFileAsMap(t *testing.T, fs afero.Fs, filename string) map[string]in
 	}
 	return m
 }
+
+func TestRemoveEnv(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	gitopsPath := afero.GetTempDir(fakeFs, "test")
+	b, err := yaml.Marshal(buildManifest(true, true))
+	assertNoError(t, err)
+	assertNoError(t, afero.WriteFile(fakeFs, filepath.Join(gitopsPath, pipelinesFile), b, 0644))
+	assertNoError(t, AddEnv(&EnvParameters{PipelinesFolderPath: gitopsPath, EnvName: "test-stage"}, fakeFs))
+	err = AddService(&AddServiceOptions{
+		AppName:             "stage-app",
+		EnvName:             "test-stage",
+		GitRepoURL:          "http://github.com/org/stage-svc",
+		PipelinesFolderPath: gitopsPath,
+		WebhookSecret:       "123",
+		ServiceName:         "stage-svc",
+		ImageRepo:           "quay.io/org/stage-svc",
+	}, fakeFs)
+	assertNoError(t, err)
+	removedPaths := []string{
+		"environments/test-stage",
+		"config/argocd/test-stage-env-app.yaml",
+		"config/argocd/test-stage-stage-app-app.yaml",
+		"config/cicd/base/05-bindings/test-stage-stage-app-stage-svc-binding.yaml",
+		"../secrets/webhook-secret-test-stage-stage-svc.yaml",
+	}
+	for _, path := range removedPaths {
+		assertFileExists(t, fakeFs, filepath.Join(gitopsPath, path), true)
+	}
+
+	assertNoError(t, RemoveEnv(&EnvParameters{PipelinesFolderPath: gitopsPath, EnvName: "test-stage"}, fakeFs))
+
+	for _, path := range removedPaths {
+		assertFileExists(t, fakeFs, filepath.Join(gitopsPath, path), false)
+	}
+	assertFileExists(t, fakeFs, filepath.Join(gitopsPath, "environments/test-dev/env/base/kustomization.yaml"), true)
+	m, err := config.LoadManifest(fakeFs, gitopsPath)
+	assertNoError(t, err)
+	if env := m.GetEnvironment("test-stage"); env != nil {
+		t.Fatalf("environment was not removed from the manifest: %#v", env)
+	}
+	err = RemoveEnv(&EnvParameters{PipelinesFolderPath: gitopsPath, EnvName: "test-stage"}, fakeFs)
+	if err == nil || err.Error() != "environment test-stage does not exist" {
+		t.Fatalf("RemoveEnv() got error %v", err)
+	}
+}
@@ -78,7 +78,7 @@ func (b *envBuilder) Application(env *config.Environment, app *config.Applicatio
 
 func (b *envBuilder) Service(app *config.Application, env *config.Environment, svc *config.Service) error {
 	svcPath := config.PathForService(app, env, svc.Name)
-	svcFiles, err := filesForService(svcPath)
+	svcFiles, err := filesForService(svcPath, svc)
 	if err != nil {
 		return err
 	}
@@ -119,15 +119,14 @@ func (b *envBuilder) Environment(env *config.Environment) error {
 		return err
 	}
 	envFiles[kustomizationPath] = &res.Kustomization{
-		Bases:     relApps,
-		Resources: kustomizedFilenames.Items(),
+		Resources: append(kustomizedFilenames.Items(), relApps...),
 	}
 	overlaysPath := filepath.ToSlash(filepath.Join(envPath, "overlays"))
 	relPath, err := filepath.Rel(overlaysPath, basePath)
 	if err != nil {
 		return err
 	}
-	envFiles[filepath.ToSlash(filepath.Join(overlaysPath, kustomization))] = &res.Kustomization{Bases: []string{filepath.ToSlash(relPath)}}
+	envFiles[filepath.ToSlash(filepath.Join(overlaysPath, kustomization))] = &res.Kustomization{Resources: []string{filepath.ToSlash(relPath)}}
 	b.files = res.Merge(envFiles, b.files)
 	return nil
 }
@@ -159,17 +158,19 @@ func filesForApplication(env *config.Environment, fullname, appPath string, app
 		relServices = append(relServices, filepath.ToSlash(relService))
 	}
 
+	// the label includes the selectors, as the selectors of the deployments
+	// that were generated with commonLabels can't be changed.
 	envFiles[filepath.ToSlash(filepath.Join(appPath, kustomization))] = &res.Kustomization{
-		Bases: []string{"overlays"},
-		CommonLabels: map[string]string{
-			vcsSourceLabel: fullname,
+		Resources: []string{"overlays"},
+		Labels: []res.Label{
+			{Pairs: map[string]string{vcsSourceLabel: fullname}, IncludeSelectors: true},
 		},
 	}
 	envFiles[filepath.ToSlash(filepath.Join(appPath, "base", kustomization))] = &res.Kustomization{
-		Bases: relServices,
+		Resources: relServices,
 	}
 	envFiles[overlaysFile] = &res.Kustomization{
-		Bases: []string{filepath.ToSlash(overlayRel)},
+		Resources: []string{filepath.ToSlash(overlayRel)},
 	}
 	return envFiles, nil
 }
@@ -179,7 +180,7 @@ func createRoleBinding(env *config.Environment, cicdNS, saName string) *v1.RoleB
 	return roles.CreateRoleBinding(meta.NamespacedName(env.Name, fmt.Sprintf("%s-rolebinding", env.Name)), sa, "ClusterRole", "edit")
 }
 
-func filesForService(svcPath string) (res.Resources, error) {
+func filesForService(svcPath string, svc *config.Service) (res.Resources, error) {
 	envFiles := res.Resources{}
 	basePath := filepath.ToSlash(filepath.Join(svcPath, "base"))
 	overlaysPath := filepath.ToSlash(filepath.Join(svcPath, "overlays"))
@@ -188,13 +189,25 @@ func filesForService(svcPath string) (res.Resources, error) {
 	if err != nil {
 		return nil, err
 	}
-	envFiles[filepath.ToSlash(filepath.Join(svcPath, kustomization))] = &res.Kustomization{Bases: []string{"overlays"}}
-	envFiles[filepath.ToSlash(filepath.Join(svcPath, "base", kustomization))] = &res.Kustomization{Bases: []string{"./config"}}
-	envFiles[overlaysFile] = &res.Kustomization{Bases: []string{filepath.ToSlash(overlayRel)}}
+	envFiles[filepath.ToSlash(filepath.Join(svcPath, kustomization))] = &res.Kustomization{Resources: []string{"overlays"}}
+	envFiles[filepath.ToSlash(filepath.Join(svcPath, "base", kustomization))] = &res.Kustomization{Resources: []string{"./config"}}
+	overlay := &res.Kustomization{Resources: []string{filepath.ToSlash(overlayRel)}}
+	if svc.Image != nil {
+		overlay.Images = []res.Image{serviceImage(svc.Image)}
+	}
+	envFiles[overlaysFile] = overlay
 
 	return envFiles, nil
 }
 
+func serviceImage(image *config.ServiceImage) res.Image {
+	i := res.Image{Name: image.Name, NewTag: image.Tag, Digest: image.Digest}
+	if image.Repo != image.Name {
+		i.NewName = image.Repo
+	}
+	return i
+}
+
 // StringSet is a set of strings.
 type StringSet map[string]bool
 
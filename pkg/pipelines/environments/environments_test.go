@@ -26,27 +26,25 @@ func TestBuildEnvironmentFilesWithAppsToEnvironment(t *testing.T) {
 	}
 	want := res.Resources{
 		"environments/test-dev/apps/my-app-1/base/kustomization.yaml": &res.Kustomization{
-			Bases: []string{
+			Resources: []string{
 				"../services/service-http",
 				"../services/service-metrics",
 			},
 		},
 		"environments/test-dev/apps/my-app-1/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"overlays"},
-			CommonLabels: map[string]string{
-				vcsSourceLabel: "example/example",
-			}},
-		"environments/test-dev/apps/my-app-1/overlays/kustomization.yaml":                          &res.Kustomization{Bases: []string{"../base"}},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{vcsSourceLabel: "example/example"}, IncludeSelectors: true}}},
+		"environments/test-dev/apps/my-app-1/overlays/kustomization.yaml":                          &res.Kustomization{Resources: []string{"../base"}},
 		"environments/test-dev/env/base/test-dev-environment.yaml":                                 namespaces.Create("test-dev", testGitOpsRepoURL),
 		"environments/test-dev/env/base/test-dev-rolebinding.yaml":                                 createRoleBinding(m.Environments[0], "cicd", "pipelines"),
 		"environments/test-dev/env/base/kustomization.yaml":                                        &res.Kustomization{Resources: []string{"test-dev-environment.yaml", "test-dev-rolebinding.yaml"}},
-		"environments/test-dev/env/overlays/kustomization.yaml":                                    &res.Kustomization{Bases: []string{"../base"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/kustomization.yaml":             &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/base/kustomization.yaml":        &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml":    &res.Kustomization{Bases: []string{"../base"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/kustomization.yaml":          &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/base/kustomization.yaml":     &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/env/overlays/kustomization.yaml":                                    &res.Kustomization{Resources: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/kustomization.yaml":             &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/base/kustomization.yaml":        &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml":    &res.Kustomization{Resources: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/kustomization.yaml":          &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/base/kustomization.yaml":     &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{Resources: []string{"../base"}},
 	}
 
 	if diff := cmp.Diff(want, files); diff != "" {
@@ -64,31 +62,28 @@ func TestBuildEnvironmentFilesWithEnvironmentsToApps(t *testing.T) {
 	}
 	want := res.Resources{
 		"environments/test-dev/apps/my-app-1/base/kustomization.yaml": &res.Kustomization{
-			Bases: []string{
+			Resources: []string{
 				"../services/service-http",
 				"../services/service-metrics",
 			},
 		},
 		"environments/test-dev/apps/my-app-1/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"overlays"},
-			CommonLabels: map[string]string{
-				vcsSourceLabel: "example/example",
-			},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{vcsSourceLabel: "example/example"}, IncludeSelectors: true}},
 		},
-		"environments/test-dev/apps/my-app-1/overlays/kustomization.yaml": &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/overlays/kustomization.yaml": &res.Kustomization{Resources: []string{"../base"}},
 		"environments/test-dev/env/base/test-dev-environment.yaml":        namespaces.Create("test-dev", testGitOpsRepoURL),
 		"environments/test-dev/env/base/test-dev-rolebinding.yaml":        createRoleBinding(m.Environments[0], "cicd", "pipelines"),
 		"environments/test-dev/env/base/kustomization.yaml": &res.Kustomization{
-			Resources: []string{"test-dev-environment.yaml", "test-dev-rolebinding.yaml"},
-			Bases:     []string{"../../apps/my-app-1/overlays"},
+			Resources: []string{"test-dev-environment.yaml", "test-dev-rolebinding.yaml", "../../apps/my-app-1/overlays"},
 		},
-		"environments/test-dev/env/overlays/kustomization.yaml":                                    &res.Kustomization{Bases: []string{"../base"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/kustomization.yaml":             &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/base/kustomization.yaml":        &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml":    &res.Kustomization{Bases: []string{"../base"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/kustomization.yaml":          &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/base/kustomization.yaml":     &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/env/overlays/kustomization.yaml":                                    &res.Kustomization{Resources: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/kustomization.yaml":             &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/base/kustomization.yaml":        &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml":    &res.Kustomization{Resources: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/kustomization.yaml":          &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/base/kustomization.yaml":     &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{Resources: []string{"../base"}},
 	}
 
 	if diff := cmp.Diff(want, files); diff != "" {
@@ -177,27 +172,25 @@ func TestBuildEnvironmentFilesWithNoCICDEnv(t *testing.T) {
 
 	want := res.Resources{
 		"environments/test-dev/apps/my-app-1/base/kustomization.yaml": &res.Kustomization{
-			Bases: []string{
+			Resources: []string{
 				"../services/service-http",
 				"../services/service-metrics",
 			},
 		},
 		"environments/test-dev/apps/my-app-1/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"overlays"},
-			CommonLabels: map[string]string{
-				vcsSourceLabel: "example/example",
-			},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{vcsSourceLabel: "example/example"}, IncludeSelectors: true}},
 		},
-		"environments/test-dev/apps/my-app-1/overlays/kustomization.yaml":                          &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/overlays/kustomization.yaml":                          &res.Kustomization{Resources: []string{"../base"}},
 		"environments/test-dev/env/base/test-dev-environment.yaml":                                 namespaces.Create("test-dev", testGitOpsRepoURL),
 		"environments/test-dev/env/base/kustomization.yaml":                                        &res.Kustomization{Resources: []string{"test-dev-environment.yaml"}},
-		"environments/test-dev/env/overlays/kustomization.yaml":                                    &res.Kustomization{Bases: []string{"../base"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/kustomization.yaml":             &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/base/kustomization.yaml":        &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml":    &res.Kustomization{Bases: []string{"../base"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/kustomization.yaml":          &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/base/kustomization.yaml":     &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/env/overlays/kustomization.yaml":                                    &res.Kustomization{Resources: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/kustomization.yaml":             &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/base/kustomization.yaml":        &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml":    &res.Kustomization{Resources: []string{"../base"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/kustomization.yaml":          &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/base/kustomization.yaml":     &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{Resources: []string{"../base"}},
 	}
 
 	if diff := cmp.Diff(want, files); diff != "" {
@@ -205,6 +198,34 @@ func TestBuildEnvironmentFilesWithNoCICDEnv(t *testing.T) {
 	}
 }
 
+func TestBuildEnvironmentsSetsServiceImages(t *testing.T) {
+	m := buildManifest()
+	services := m.Environments[0].Apps[0].Services
+	services[0].Image = &config.ServiceImage{Name: "nginxinc/nginx-unprivileged", Repo: "quay.io/example/http", Tag: "v1.2.0"}
+	services[1].Image = &config.ServiceImage{Name: "quay.io/example/metrics", Repo: "quay.io/example/metrics", Digest: "sha256:3e1ad6b6b2b2"}
+
+	files, err := Build(ioutils.NewMemoryFilesystem(), m, "pipelines", AppsToEnvironments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := res.Resources{
+		"environments/test-dev/apps/my-app-1/services/service-http/overlays/kustomization.yaml": &res.Kustomization{
+			Resources: []string{"../base"},
+			Images:    []res.Image{{Name: "nginxinc/nginx-unprivileged", NewName: "quay.io/example/http", NewTag: "v1.2.0"}},
+		},
+		"environments/test-dev/apps/my-app-1/services/service-metrics/overlays/kustomization.yaml": &res.Kustomization{
+			Resources: []string{"../base"},
+			Images:    []res.Image{{Name: "quay.io/example/metrics", Digest: "sha256:3e1ad6b6b2b2"}},
+		},
+	}
+	for path, kustomization := range want {
+		if diff := cmp.Diff(kustomization, files[path]); diff != "" {
+			t.Fatalf("%s didn't match: %s\n", path, diff)
+		}
+	}
+}
+
 func TestListFiles(t *testing.T) {
 	var appFs = ioutils.NewMemoryFilesystem()
 	var envPath = "environments/test-dev"
@@ -6,6 +6,7 @@ import (
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
 	"github.com/redhat-developer/kam/pkg/pipelines/scm"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 // Filters for interceptors
@@ -20,7 +21,7 @@ const (
 )
 
 var (
-	eventListenerTypeMeta = meta.TypeMeta("EventListener", "triggers.tekton.dev/v1alpha1")
+	eventListenerTypeMeta = meta.TypeMeta("EventListener", tekton.V1Beta1.TriggersAPIVersion())
 )
 
 // Generate will create the required eventlisteners.
@@ -29,6 +30,10 @@ func Generate(repo scm.Repository, ns, saName, secretName string) (triggersv1.Ev
 	if err != nil {
 		return triggersv1.EventListener{}, err
 	}
+	prTrigger, err := repo.CreatePRTrigger("ci-dryrun-from-pr", secretName, ns, "ci-dryrun-from-pr-template", []string{repo.PRBindingName()})
+	if err != nil {
+		return triggersv1.EventListener{}, err
+	}
 	return triggersv1.EventListener{
 		TypeMeta:   eventListenerTypeMeta,
 		ObjectMeta: createListenerObjectMeta("cicd-event-listener", ns),
@@ -36,6 +41,7 @@ func Generate(repo scm.Repository, ns, saName, secretName string) (triggersv1.Ev
 			ServiceAccountName: saName,
 			Triggers: []triggersv1.EventListenerTrigger{
 				pushTrigger,
+				prTrigger,
 			},
 		},
 	}, nil
@@ -19,19 +19,30 @@ type Repository struct {
 	name string
 }
 
-// NewRepository creates a new Git repository object
+// NewRepository creates a new Git repository object, the driver is identified
+// with the go-scm default identifier.
 func NewRepository(rawURL, token string) (*Repository, error) {
+	return NewRepositoryWithIdentifier(rawURL, token, factory.DefaultIdentifier)
+}
+
+// NewRepositoryWithIdentifier creates a new Git repository object, the driver
+// is identified from the repository host with the provided identifier.
+func NewRepositoryWithIdentifier(rawURL, token string, id factory.HostDriverIdentifier) (*Repository, error) {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse repository URL %q: %w", rawURL, err)
 	}
-	parsed.User = url.UserPassword("", token)
-	client, err := factory.FromRepoURL(parsed.String())
+	driver, err := id.Identify(parsed.Host)
+	if err != nil {
+		return nil, err
+	}
+	serverURL := &url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: "/"}
+	client, err := factory.NewClient(driver, serverURL.String(), token)
 	if err != nil {
 		return nil, err
 	}
 
-	repoName, err := GetRepoName(parsed)
+	repoName, err := repoNameForDriver(parsed, driver)
 	if err != nil {
 		return nil, fmt.Errorf("unable to get the repo name from %q: %w", rawURL, err)
 	}
@@ -47,7 +58,7 @@ func (r *Repository) ListWebhooks(listenerURL string) ([]string, error) {
 
 	ids := []string{}
 	for _, hook := range hooks {
-		if hook.Target == listenerURL {
+		if isSameTarget(hook.Target, listenerURL) {
 			ids = append(ids, hook.ID)
 		}
 	}
@@ -69,6 +80,21 @@ func (r *Repository) DeleteWebhooks(ids []string) ([]string, error) {
 	return deleted, nil
 }
 
+// isSameTarget compares a webhook target with the listener URL, ignoring any
+// query string, Gitea stores the webhook secret as a query parameter on the
+// target URL.
+func isSameTarget(target, listenerURL string) bool {
+	if target == listenerURL {
+		return true
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	parsed.RawQuery = ""
+	return parsed.String() == listenerURL
+}
+
 // CreateWebhook creates a new webhook in the repository
 // It returns ID of the created webhook
 func (r *Repository) CreateWebhook(listenerURL, secret string) (string, error) {
@@ -90,6 +116,11 @@ func (r *Repository) CreateWebhook(listenerURL, secret string) (string, error) {
 // GetRepoName takes a URL of the form https://github.com/my-org/my-repo.git and
 // attempts to determine the name of the repo from this, i.e. "my-org/my-repo".
 func GetRepoName(u *url.URL) (string, error) {
+	driver, _ := factory.DefaultIdentifier.Identify(u.Host)
+	return repoNameForDriver(u, driver)
+}
+
+func repoNameForDriver(u *url.URL, driver string) (string, error) {
 	var components []string
 	for _, s := range strings.Split(u.Path, "/") {
 		if s != "" {
@@ -100,6 +131,9 @@ func GetRepoName(u *url.URL) (string, error) {
 		return "", errors.New("failed to get Git repo: " + u.Path)
 	}
 	components[len(components)-1] = strings.TrimSuffix(components[len(components)-1], ".git")
+	if driver == "stash" {
+		return stashRepoName(u, components)
+	}
 	for _, s := range components {
 		if strings.Contains(s, ".") {
 			return "", errors.New("failed to get Git repo: " + u.Path)
@@ -107,3 +141,12 @@ func GetRepoName(u *url.URL) (string, error) {
 	}
 	return strings.Join(components, "/"), nil
 }
+
+// Bitbucket Server clone URLs are of the form /scm/<project>/<repo>.git, the
+// API refers to the repository as <project>/<repo>.
+func stashRepoName(u *url.URL, components []string) (string, error) {
+	if len(components) != 3 || components[0] != "scm" {
+		return "", errors.New("failed to get Bitbucket Server repo, expected /scm/<project>/<repo>.git: " + u.Path)
+	}
+	return strings.Join(components[1:], "/"), nil
+}
@@ -153,6 +153,78 @@ func TestCreateWebHook(t *testing.T) {
 	}
 }
 
+func TestListWebHooksForGitea(t *testing.T) {
+	defer gock.Off()
+	defer restoreIdentifier(factory.DefaultIdentifier)
+	factory.DefaultIdentifier = factory.NewDriverIdentifier(factory.Mapping("gitea.example.com", "gitea"))
+	mockGiteaVersion()
+
+	gock.New("https://gitea.example.com").
+		Get("/api/v1/repos/foo/bar/hooks").
+		Reply(200).
+		Type("application/json").
+		File("testdata/gitea-hooks.json")
+
+	repo, err := NewRepository("https://gitea.example.com/foo/bar.git", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := repo.ListWebhooks("http://example.com/webhook")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"1"}, ids); diff != "" {
+		t.Errorf("driver errMsg mismatch got\n%s", diff)
+	}
+}
+
+func TestCreateWebHookForGitea(t *testing.T) {
+	defer gock.Off()
+	defer restoreIdentifier(factory.DefaultIdentifier)
+	factory.DefaultIdentifier = factory.NewDriverIdentifier(factory.Mapping("gitea.example.com", "gitea"))
+	mockGiteaVersion()
+
+	gock.New("https://gitea.example.com").
+		Post("/api/v1/repos/foo/bar/hooks").
+		Reply(201).
+		Type("application/json").
+		File("testdata/gitea-hook.json")
+
+	repo, err := NewRepository("https://gitea.example.com/foo/bar.git", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := repo.CreateWebhook("http://example.com/webhook", "mysecret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if created != "1" {
+		t.Errorf("failed to create webhook, got %q, want %q", created, "1")
+	}
+}
+
+func TestIsSameTarget(t *testing.T) {
+	targetTests := []struct {
+		target string
+		want   bool
+	}{
+		{"http://example.com/webhook", true},
+		{"http://example.com/webhook?secret=test", true},
+		{"http://example.com/other-webhook", false},
+		{"https://example.com/webhook", false},
+	}
+
+	for _, tt := range targetTests {
+		if got := isSameTarget(tt.target, "http://example.com/webhook"); got != tt.want {
+			t.Errorf("isSameTarget(%q) got %v, want %v", tt.target, got, tt.want)
+		}
+	}
+}
+
 func TestGetRepoName(t *testing.T) {
 	urlTests := []struct {
 		url      string
@@ -179,3 +251,83 @@ func TestGetRepoName(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRepoNameForStash(t *testing.T) {
+	defer restoreIdentifier(factory.DefaultIdentifier)
+	factory.DefaultIdentifier = factory.NewDriverIdentifier(factory.Mapping("bitbucket.example.com", "stash"))
+
+	u, err := url.Parse("https://bitbucket.example.com/scm/project/testing.git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := GetRepoName(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo != "project/testing" {
+		t.Errorf("repo got %s, want %s", repo, "project/testing")
+	}
+}
+
+func TestRepoNameForStashDriver(t *testing.T) {
+	urlTests := []struct {
+		url      string
+		wantRepo string
+		wantErr  string
+	}{
+		{"https://bitbucket.example.com/scm/project/testing.git", "project/testing", ""},
+		{"https://bitbucket.example.com/scm/project/testing.repo.git", "project/testing.repo", ""},
+		{"https://bitbucket.example.com/project/testing.git", "", "failed to get Bitbucket Server repo, expected /scm/<project>/<repo>.git: /project/testing.git"},
+		{"https://bitbucket.example.com/scm/project/sub/testing.git", "", "failed to get Bitbucket Server repo, expected /scm/<project>/<repo>.git: /scm/project/sub/testing.git"},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			repo, err := repoNameForDriver(u, "stash")
+			if tt.wantErr == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("got error %v, want %s", err, tt.wantErr)
+			}
+			if repo != tt.wantRepo {
+				t.Errorf("repo got %s, want %s", repo, tt.wantRepo)
+			}
+		})
+	}
+}
+
+func TestNewRepositoryWithIdentifierDoesNotChangeDefault(t *testing.T) {
+	defer restoreIdentifier(factory.DefaultIdentifier)
+	factory.DefaultIdentifier = factory.NewDriverIdentifier()
+
+	id := factory.NewDriverIdentifier(factory.Mapping("bitbucket.example.com", "stash"))
+	repo, err := NewRepositoryWithIdentifier("https://bitbucket.example.com/scm/project/testing.git", "token", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.name != "project/testing" {
+		t.Errorf("repo got %s, want %s", repo.name, "project/testing")
+	}
+	if _, err := factory.DefaultIdentifier.Identify("bitbucket.example.com"); err == nil {
+		t.Fatal("default identifier was changed")
+	}
+}
+
+func restoreIdentifier(f factory.HostDriverIdentifier) {
+	factory.DefaultIdentifier = f
+}
+
+// The Gitea client checks the server version when it's created.
+func mockGiteaVersion() {
+	gock.New("https://gitea.example.com").
+		Get("/api/v1/version").
+		Persist().
+		Reply(200).
+		Type("application/json").
+		BodyString(`{"version":"1.15.0"}`)
+}
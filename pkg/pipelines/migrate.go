@@ -0,0 +1,180 @@
+package pipelines
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+// MigrateParameters is a struct that provides flags for the Migrate command.
+type MigrateParameters struct {
+	PipelinesFolderPath string
+	OutputPath          string
+	DryRun              bool // Only report the changes, nothing is written.
+}
+
+// migration upgrades the manifest, and the files in the pipelines folder and
+// the output folder, from one version to the next.
+type migration func(fs afero.Fs, pipelinesFolder, outputPath string, m *config.Manifest) error
+
+// migrations are keyed by the manifest version that they upgrade from, each
+// migration upgrades to the next version, versions that only need the rebuild
+// after the migration to upgrade the generated files have no migration.
+var migrations = map[int]migration{
+	1: migrateKustomizations,
+	2: migrateStrictFields,
+}
+
+// Migrate upgrades the manifest in the pipelines folder to the latest version,
+// and rebuilds the resources, so that the generated files are upgraded too.
+//
+// It returns the differences to the files on disk, sorted by path, these are
+// written unless DryRun is set.
+func Migrate(o *MigrateParameters, appFs afero.Fs) ([]FileDiff, error) {
+	overlay := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(appFs), afero.NewMemMapFs())
+	if err := migrate(o, overlay); err != nil {
+		return nil, err
+	}
+	root, err := homedir.Expand(o.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	before, err := readFiles(appFs, root)
+	if err != nil {
+		return nil, err
+	}
+	after, err := readFiles(overlay, root)
+	if err != nil {
+		return nil, err
+	}
+	diffs, err := diffFiles(before, after)
+	if err != nil || o.DryRun || len(diffs) == 0 {
+		return diffs, err
+	}
+	return diffs, migrate(o, appFs)
+}
+
+func migrate(o *MigrateParameters, fs afero.Fs) error {
+	root, err := homedir.Expand(o.PipelinesFolderPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	outputPath, err := homedir.Expand(o.OutputPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	// the unknown fields in older manifests are reported by migrateStrictFields.
+	body, err := afero.ReadFile(fs, filepath.Join(root, pipelinesFile))
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	m, err := config.ParseWithWarnings(bytes.NewReader(body), func(error) {})
+	if err != nil {
+		return fmt.Errorf("failed to load manifest: %w", err)
+	}
+	if m.Version == config.ManifestVersion {
+		return nil
+	}
+	if err := migrateManifest(fs, root, outputPath, m); err != nil {
+		return err
+	}
+	if _, err := yaml.WriteResources(fs, root, map[string]interface{}{pipelinesFile: m}); err != nil {
+		return err
+	}
+	_, err = BuildResources(&BuildParameters{PipelinesFolderPath: o.PipelinesFolderPath, OutputPath: o.OutputPath}, fs)
+	return err
+}
+
+// migrateManifest applies the migrations from the manifest's version to the
+// latest version in order.
+func migrateManifest(fs afero.Fs, pipelinesFolder, outputPath string, m *config.Manifest) error {
+	for m.Version < config.ManifestVersion {
+		if migrate, ok := migrations[m.Version]; ok {
+			if err := migrate(fs, pipelinesFolder, outputPath, m); err != nil {
+				return fmt.Errorf("failed to migrate the manifest from version %d: %w", m.Version, err)
+			}
+		}
+		m.Version++
+	}
+	return nil
+}
+
+// migrateKustomizations replaces the deprecated bases and commonLabels in the
+// kustomizations in the output folder with resources and labels, the
+// kustomizations that are generated are rewritten by the rebuild after the
+// migration, but the CI/CD overlays, and any kustomizations that were added to
+// the GitOps repository, are not generated.
+//
+// The kustomizations are rewritten as maps, so that fields that are not in
+// res.Kustomization are kept.
+func migrateKustomizations(fs afero.Fs, pipelinesFolder, outputPath string, m *config.Manifest) error {
+	files, err := readFiles(fs, outputPath)
+	if err != nil {
+		return err
+	}
+	for name, body := range files {
+		if path.Base(name) != Kustomize {
+			continue
+		}
+		fixed, changed, err := fixKustomization(body)
+		if err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", name, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := afero.WriteFile(fs, filepath.Join(outputPath, filepath.FromSlash(name)), fixed, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateStrictFields upgrades to the version that rejects fields that are
+// not part of the manifest, the manifest is rewritten from the parsed manifest,
+// which would drop these fields, so the migration fails until they're fixed.
+func migrateStrictFields(fs afero.Fs, pipelinesFolder, outputPath string, m *config.Manifest) error {
+	body, err := afero.ReadFile(fs, filepath.Join(pipelinesFolder, pipelinesFile))
+	if err != nil {
+		return err
+	}
+	if err := config.CheckFields(body); err != nil {
+		return fmt.Errorf("fix the unknown fields in %s before migrating: %w", pipelinesFile, err)
+	}
+	return nil
+}
+
+// fixKustomization does the same as res.Kustomization.Fix, for a kustomization
+// that has fields that are not in res.Kustomization, it returns false if there
+// are no deprecated fields.
+func fixKustomization(body []byte) ([]byte, bool, error) {
+	k := map[string]interface{}{}
+	if err := sigsyaml.Unmarshal(body, &k); err != nil {
+		return nil, false, err
+	}
+	bases, hasBases := k["bases"].([]interface{})
+	commonLabels, hasLabels := k["commonLabels"].(map[string]interface{})
+	if !hasBases && !hasLabels {
+		return body, false, nil
+	}
+	if hasBases {
+		resources, _ := k["resources"].([]interface{})
+		k["resources"] = append(resources, bases...)
+		delete(k, "bases")
+	}
+	if hasLabels {
+		labels, _ := k["labels"].([]interface{})
+		k["labels"] = append(labels, map[string]interface{}{"pairs": commonLabels, "includeSelectors": true})
+		delete(k, "commonLabels")
+	}
+	fixed, err := sigsyaml.Marshal(k)
+	return fixed, true, err
+}
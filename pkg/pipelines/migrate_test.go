@@ -0,0 +1,143 @@
+package pipelines
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/test"
+)
+
+const unversionedManifest = `gitops_url: https://github.com/org/gitops.git
+environments:
+- name: test-dev
+`
+
+func TestMigrate(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, afero.WriteFile(fakeFs, "/pipelines.yaml", []byte(unversionedManifest), 0644))
+	o := &MigrateParameters{PipelinesFolderPath: "/", OutputPath: "/", DryRun: true}
+
+	diffs, err := Migrate(o, fakeFs)
+	assertNoError(t, err)
+	if !hasDiff(diffs, pipelinesFile, DiffChanged) || !hasDiff(diffs, "environments/test-dev/env/base/kustomization.yaml", DiffAdded) {
+		t.Fatalf("Migrate() dry-run got diffs %#v", diffs)
+	}
+	body, err := afero.ReadFile(fakeFs, "/pipelines.yaml")
+	assertNoError(t, err)
+	if string(body) != unversionedManifest {
+		t.Fatalf("Migrate() dry-run wrote to the filesystem:\n%s", body)
+	}
+
+	o.DryRun = false
+	_, err = Migrate(o, fakeFs)
+	assertNoError(t, err)
+	m, err := config.LoadManifest(fakeFs, "/")
+	assertNoError(t, err)
+	if m.Version != config.ManifestVersion {
+		t.Fatalf("Migrate() got version %d, want %d", m.Version, config.ManifestVersion)
+	}
+	assertFileExists(t, fakeFs, "/environments/test-dev/env/base/kustomization.yaml", true)
+
+	diffs, err = Migrate(o, fakeFs)
+	assertNoError(t, err)
+	if len(diffs) != 0 {
+		t.Fatalf("Migrate() of the latest version got diffs %#v", diffs)
+	}
+}
+
+func TestMigrateWithUnknownFields(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	manifest := "version: 2\n" + unversionedManifest + "  pipeline: typo\n"
+	assertNoError(t, afero.WriteFile(fakeFs, "/pipelines.yaml", []byte(manifest), 0644))
+
+	_, err := Migrate(&MigrateParameters{PipelinesFolderPath: "/", OutputPath: "/"}, fakeFs)
+	test.AssertErrorMatch(t, `(?s)failed to migrate the manifest from version 2: fix the unknown fields in pipelines.yaml before migrating: .*line 5: unknown field "environments.test-dev.pipeline"`, err)
+	body, err := afero.ReadFile(fakeFs, "/pipelines.yaml")
+	assertNoError(t, err)
+	if diff := cmp.Diff(manifest, string(body)); diff != "" {
+		t.Fatalf("Migrate() changed the manifest:\n%s", diff)
+	}
+}
+
+func TestMigrateManifest(t *testing.T) {
+	defer func(saved map[int]migration) {
+		migrations = saved
+	}(migrations)
+
+	applied := []int{}
+	record := func(fs afero.Fs, pipelinesFolder, outputPath string, m *config.Manifest) error {
+		applied = append(applied, m.Version)
+		return nil
+	}
+	migrations = map[int]migration{0: record, 2: record}
+	m := &config.Manifest{}
+	assertNoError(t, migrateManifest(ioutils.NewMemoryFilesystem(), "/", "/", m))
+	if diff := cmp.Diff([]int{0, 2}, applied); diff != "" || m.Version != config.ManifestVersion {
+		t.Fatalf("migrateManifest() applied %v, got version %d", applied, m.Version)
+	}
+
+	migrations = map[int]migration{1: func(fs afero.Fs, pipelinesFolder, outputPath string, m *config.Manifest) error {
+		return errors.New("failed")
+	}}
+	err := migrateManifest(ioutils.NewMemoryFilesystem(), "/", "/", &config.Manifest{})
+	test.AssertErrorMatch(t, "failed to migrate the manifest from version 1: failed", err)
+}
+
+func TestMigrateKustomizations(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	deprecated := `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+bases:
+- ../base
+resources:
+- 01-secret.yaml
+commonLabels:
+  app.kubernetes.io/part-of: taxi
+namePrefix: dev-
+`
+	current := "resources:\n- ../base\n"
+	assertNoError(t, afero.WriteFile(fakeFs, "/gitops/config/cicd/overlays/kustomization.yaml", []byte(deprecated), 0644))
+	assertNoError(t, afero.WriteFile(fakeFs, "/gitops/config/cicd/base/kustomization.yaml", []byte(current), 0644))
+
+	assertNoError(t, migrateKustomizations(fakeFs, "/gitops", "/gitops", &config.Manifest{Version: 1}))
+
+	body, err := afero.ReadFile(fakeFs, "/gitops/config/cicd/overlays/kustomization.yaml")
+	assertNoError(t, err)
+	got := map[string]interface{}{}
+	assertNoError(t, sigsyaml.Unmarshal(body, &got))
+	want := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  []interface{}{"01-secret.yaml", "../base"},
+		"labels": []interface{}{
+			map[string]interface{}{
+				"pairs":            map[string]interface{}{"app.kubernetes.io/part-of": "taxi"},
+				"includeSelectors": true,
+			},
+		},
+		"namePrefix": "dev-",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("migrateKustomizations() failed:\n%s", diff)
+	}
+	body, err = afero.ReadFile(fakeFs, "/gitops/config/cicd/base/kustomization.yaml")
+	assertNoError(t, err)
+	if string(body) != current {
+		t.Fatalf("migrateKustomizations() rewrote a current kustomization:\n%s", body)
+	}
+}
+
+func hasDiff(diffs []FileDiff, path, status string) bool {
+	for _, d := range diffs {
+		if d.Path == path && d.Status == status {
+			return true
+		}
+	}
+	return false
+}
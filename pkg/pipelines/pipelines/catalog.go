@@ -0,0 +1,169 @@
+package pipelines
+
+import (
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// buildImageTask is the name of the task that pushes the image in every
+// template, the GitOps repository is updated after it.
+const buildImageTask = "build-image"
+
+const koImage = "ghcr.io/ko-build/ko:v0.12.0"
+
+// koScript builds and pushes a Go service with ko, the IMAGE is split into the
+// repository and the tag, as ko appends the tag, and the digest is taken from
+// the reference that ko prints.
+const koScript = `#!/bin/sh
+set -e
+image="$(params.IMAGE)"
+insecure=""
+if [ "$(params.TLSVERIFY)" = "false" ]; then
+  insecure="--insecure-registry"
+fi
+export KO_DOCKER_REPO="${image%:*}"
+ref="$(ko build --bare --tags "${image##*:}" ${insecure} ./)"
+printf "%s" "${ref##*@}" > "$(results.IMAGE_DIGEST.path)"
+printf "%s" "${image}" > "$(results.IMAGE_URL.path)"
+`
+
+// Template is a pipeline in the catalog of pipelines that build and push the
+// image for a service.
+type Template struct {
+	Name        string
+	Description string
+	buildTasks  func(runAfter string) []pipelinev1.PipelineTask
+}
+
+// DefaultTemplate builds the image from a Dockerfile with buildah, it's the
+// template of the app-cd-pipeline.
+var DefaultTemplate = &Template{
+	Name:        "buildah",
+	Description: "Build the image from the Dockerfile in the repository with buildah.",
+	buildTasks: func(runAfter string) []pipelinev1.PipelineTask {
+		return []pipelinev1.PipelineTask{createBuildImageTask(buildImageTask, runAfter)}
+	},
+}
+
+// Templates is the catalog of pipeline templates, sorted by name.
+var Templates = []*Template{
+	DefaultTemplate,
+	{
+		Name:        "ko",
+		Description: "Build the image for a Go application with ko, without a Dockerfile.",
+		buildTasks: func(runAfter string) []pipelinev1.PipelineTask {
+			return []pipelinev1.PipelineTask{createKoTask(buildImageTask, runAfter)}
+		},
+	},
+	{
+		Name:        "maven-buildah",
+		Description: "Package a Java application with Maven, and build the image from the Dockerfile in the repository with buildah.",
+		buildTasks: func(runAfter string) []pipelinev1.PipelineTask {
+			return []pipelinev1.PipelineTask{
+				createMavenTask("build-package", runAfter),
+				createBuildImageTask(buildImageTask, "build-package"),
+			}
+		},
+	},
+	{
+		Name:        "s2i-java",
+		Description: "Build the image for a Java application with Source-to-Image, without a Dockerfile.",
+		buildTasks: func(runAfter string) []pipelinev1.PipelineTask {
+			return []pipelinev1.PipelineTask{createS2ITask(buildImageTask, "s2i-java", runAfter)}
+		},
+	},
+	{
+		Name:        "s2i-nodejs",
+		Description: "Build the image for a Node.js application with Source-to-Image, without a Dockerfile.",
+		buildTasks: func(runAfter string) []pipelinev1.PipelineTask {
+			return []pipelinev1.PipelineTask{createS2ITask(buildImageTask, "s2i-nodejs", runAfter)}
+		},
+	},
+}
+
+// LookupTemplate returns the named template from the catalog, or nil if
+// there's no template with the name.
+func LookupTemplate(name string) *Template {
+	for _, t := range Templates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// TemplateNames returns the names of the templates in the catalog.
+func TemplateNames() []string {
+	names := []string{}
+	for _, t := range Templates {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func createS2ITask(name, clusterTask, runAfter string) pipelinev1.PipelineTask {
+	return pipelinev1.PipelineTask{
+		Name:    name,
+		TaskRef: createTaskRef(clusterTask, pipelinev1.ClusterTaskKind),
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
+		},
+		RunAfter: []string{runAfter},
+		Params: []pipelinev1.Param{
+			createTaskParam("TLSVERIFY", "$(params.TLSVERIFY)"),
+			createTaskParam("IMAGE", "$(params.IMAGE)"),
+		},
+	}
+}
+
+// createMavenTask packages the application with the default goals, the Maven
+// settings are in a sub-path of the workspace, so that the defaults are used.
+func createMavenTask(name, runAfter string) pipelinev1.PipelineTask {
+	return pipelinev1.PipelineTask{
+		Name:    name,
+		TaskRef: createTaskRef("maven", pipelinev1.ClusterTaskKind),
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
+			{Name: "maven-settings", Workspace: pipelineWorkspace, SubPath: "maven-settings"},
+		},
+		RunAfter: []string{runAfter},
+	}
+}
+
+// createKoTask builds the image with an embedded task, as there's no ko
+// ClusterTask.
+func createKoTask(name, runAfter string) pipelinev1.PipelineTask {
+	return pipelinev1.PipelineTask{
+		Name: name,
+		TaskSpec: &pipelinev1.EmbeddedTask{
+			TaskSpec: pipelinev1.TaskSpec{
+				Params: paramSpecs("IMAGE", "TLSVERIFY"),
+				Workspaces: []pipelinev1.WorkspaceDeclaration{
+					{Name: "source", Description: "The workspace with the service's source."},
+				},
+				Results: []pipelinev1.TaskResult{
+					{Name: "IMAGE_DIGEST", Description: "The digest of the built image."},
+					{Name: "IMAGE_URL", Description: "The URL of the built image."},
+				},
+				Steps: []pipelinev1.Step{
+					{
+						Container: corev1.Container{
+							Name:       "build-and-push",
+							Image:      koImage,
+							WorkingDir: "$(workspaces.source.path)",
+						},
+						Script: koScript,
+					},
+				},
+			},
+		},
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
+		},
+		RunAfter: []string{runAfter},
+		Params: []pipelinev1.Param{
+			createTaskParam("IMAGE", "$(params.IMAGE)"),
+			createTaskParam("TLSVERIFY", "$(params.TLSVERIFY)"),
+		},
+	}
+}
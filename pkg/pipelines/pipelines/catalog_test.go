@@ -0,0 +1,85 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCreateTemplatePipeline(t *testing.T) {
+	name := types.NamespacedName{Name: "test-pipeline", Namespace: "test-ns"}
+	templateTests := []struct {
+		template string
+		want     []string
+	}{
+		{"buildah", []string{"buildah"}},
+		{"ko", []string{""}},
+		{"maven-buildah", []string{"maven", "buildah"}},
+		{"s2i-java", []string{"s2i-java"}},
+		{"s2i-nodejs", []string{"s2i-nodejs"}},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.template, func(rt *testing.T) {
+			p := CreateTemplatePipeline(name, LookupTemplate(tt.template))
+
+			tasks := p.Spec.Tasks
+			if diff := cmp.Diff(PendingCommitStatusTask, tasks[0].Name); diff != "" {
+				rt.Fatalf("CreateTemplatePipeline() failed:\n%s", diff)
+			}
+			if diff := cmp.Diff([]string{"clone-gitops", "update-image"}, []string{tasks[len(tasks)-2].Name, tasks[len(tasks)-1].Name}); diff != "" {
+				rt.Fatalf("CreateTemplatePipeline() failed to update the GitOps repository:\n%s", diff)
+			}
+			build := tasks[2 : len(tasks)-2]
+			got := []string{}
+			for _, task := range build {
+				ref := ""
+				if task.TaskRef != nil {
+					ref = task.TaskRef.Name
+				}
+				got = append(got, ref)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				rt.Fatalf("CreateTemplatePipeline() build tasks failed:\n%s", diff)
+			}
+			if diff := cmp.Diff(buildImageTask, build[len(build)-1].Name); diff != "" {
+				rt.Fatalf("CreateTemplatePipeline() failed to push the image:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreateTemplatePipelineWithDefault(t *testing.T) {
+	name := types.NamespacedName{Name: "test-pipeline", Namespace: "test-ns"}
+
+	if diff := cmp.Diff(CreateAppCDPipeline(name), CreateTemplatePipeline(name, LookupTemplate("buildah"))); diff != "" {
+		t.Fatalf("CreateTemplatePipeline() failed:\n%s", diff)
+	}
+}
+
+func TestCreateKoTask(t *testing.T) {
+	task := createKoTask("build-image", "clone-source")
+
+	want := []pipelinev1.Param{
+		createTaskParam("IMAGE", "$(params.IMAGE)"),
+		createTaskParam("TLSVERIFY", "$(params.TLSVERIFY)"),
+	}
+	if diff := cmp.Diff(want, task.Params); diff != "" {
+		t.Fatalf("createKoTask() params failed:\n%s", diff)
+	}
+	if diff := cmp.Diff(koImage, task.TaskSpec.Steps[0].Image); diff != "" {
+		t.Fatalf("createKoTask() image failed:\n%s", diff)
+	}
+}
+
+func TestLookupTemplate(t *testing.T) {
+	if tmpl := LookupTemplate("gradle"); tmpl != nil {
+		t.Fatalf("LookupTemplate() got %#v for an unknown template", tmpl)
+	}
+	want := []string{"buildah", "ko", "maven-buildah", "s2i-java", "s2i-nodejs"}
+	if diff := cmp.Diff(want, TemplateNames()); diff != "" {
+		t.Fatalf("TemplateNames() failed:\n%s", diff)
+	}
+}
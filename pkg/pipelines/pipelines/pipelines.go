@@ -9,15 +9,18 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/tasks"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
 )
 
 var (
-	pipelineTypeMeta = meta.TypeMeta("Pipeline", "tekton.dev/v1beta1")
+	pipelineTypeMeta = meta.TypeMeta("Pipeline", tekton.V1Beta1.PipelinesAPIVersion())
 )
 
 const (
 	pipelineWorkspace = "shared-data"
+	gitOpsSubPath     = "gitops"
 	// PendingCommitStatusTask is a task that sets pending commit status
 	PendingCommitStatusTask = "set-pending-status"
 )
@@ -54,6 +57,41 @@ func CreateAppCIPipeline(name types.NamespacedName) *pipelinev1.Pipeline {
 	}
 }
 
+// CreateAppCIPRPipeline creates a pipeline that builds the image for a pull
+// request, the built image is not pushed to the image repository.
+func CreateAppCIPRPipeline(name types.NamespacedName) *pipelinev1.Pipeline {
+	buildTask := createBuildImageTask("build-image", "clone-source")
+	buildTask.Params = append(buildTask.Params, createTaskParam("SKIP_PUSH", "true"))
+	return &pipelinev1.Pipeline{
+		TypeMeta:   pipelineTypeMeta,
+		ObjectMeta: meta.ObjectMeta(name),
+		Spec: pipelinev1.PipelineSpec{
+			Params: paramSpecs(
+				"REPO",
+				"COMMIT_SHA",
+				"TLSVERIFY",
+				"BUILD_EXTRA_ARGS",
+				"IMAGE",
+				"GIT_REF",
+				"COMMIT_DATE",
+				"COMMIT_AUTHOR",
+				"COMMIT_MESSAGE",
+				"GIT_REPO"),
+			Tasks: []pipelinev1.PipelineTask{
+				createCommitStatusPipelineTask(PendingCommitStatusTask, "pending", "The build has started"),
+				createGitCloneTask("clone-source"),
+				buildTask,
+			},
+			Workspaces: []pipelinev1.PipelineWorkspaceDeclaration{
+				{Name: pipelineWorkspace, Description: "This workspace will receive the cloned git repo."},
+			},
+			Finally: []pipelinev1.PipelineTask{
+				createCommitStatusPipelineTask("set-final-status", "$(tasks.build-image.status)", "The build is complete"),
+			},
+		},
+	}
+}
+
 func createBuildImageTask(name, runAfter string) pipelinev1.PipelineTask {
 	return pipelinev1.PipelineTask{
 		Name:    name,
@@ -71,6 +109,12 @@ func createBuildImageTask(name, runAfter string) pipelinev1.PipelineTask {
 }
 
 func createGitCloneTask(name string) pipelinev1.PipelineTask {
+	return createGitCloneTaskForRevision(name, "$(params.GIT_REF)")
+}
+
+// createGitCloneTaskForRevision clones the revision of the repository into
+// the workspace.
+func createGitCloneTaskForRevision(name, revision string) pipelinev1.PipelineTask {
 	// The output workspace mapping here comes from the git-clone task.
 	return pipelinev1.PipelineTask{
 		Name:    name,
@@ -80,54 +124,60 @@ func createGitCloneTask(name string) pipelinev1.PipelineTask {
 		},
 		Params: []pipelinev1.Param{
 			createTaskParam("url", "$(params.GIT_REPO)"),
-			createTaskParam("revision", "$(params.GIT_REF)"),
+			createTaskParam("revision", revision),
 		},
 		RunAfter: []string{PendingCommitStatusTask},
 	}
 }
 
-// CreateCDPipeline creates a CD pipeline.
+// CreateCDPipeline creates a CD pipeline, that clones the commit of the GitOps
+// repository and applies it.
 func CreateCDPipeline(name types.NamespacedName, stageNamespace string) *pipelinev1.Pipeline {
+	clone := createGitCloneTaskForRevision("clone-source", "$(params.COMMIT_SHA)")
+	clone.RunAfter = nil
 	return &pipelinev1.Pipeline{
 		TypeMeta:   pipelineTypeMeta,
 		ObjectMeta: meta.ObjectMeta(name),
 		Spec: pipelinev1.PipelineSpec{
-			Resources: []pipelinev1.PipelineDeclaredResource{
-				createPipelineDeclaredResource("source-repo", "git"),
-			},
+			Params: paramSpecs("COMMIT_SHA", "GIT_REPO"),
 			Tasks: []pipelinev1.PipelineTask{
-				createCDPipelineTask("apply-source"),
+				clone,
+				createCDPipelineTask("apply-source", "clone-source"),
+			},
+			Workspaces: []pipelinev1.PipelineWorkspaceDeclaration{
+				{Name: pipelineWorkspace, Description: "This workspace will receive the cloned git repo."},
 			},
 		},
 	}
 }
 
-func createCDPipelineTask(taskName string) pipelinev1.PipelineTask {
+func createCDPipelineTask(taskName, runAfter string) pipelinev1.PipelineTask {
 	return pipelinev1.PipelineTask{
 		Name:    taskName,
 		TaskRef: createTaskRef("deploy-from-source-task", pipelinev1.NamespacedTaskKind),
-		Resources: &pipelinev1.PipelineTaskResources{
-			Inputs: []pipelinev1.PipelineTaskInputResource{createInputTaskResource("source", "source-repo")},
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
 		},
+		RunAfter: []string{runAfter},
 	}
 }
 
-// CreateCIPipeline creates CI pipeline
+// CreateCIPipeline creates a CI pipeline, that clones the commit of the GitOps
+// repository and runs a server-side dry-run of applying it.
 func CreateCIPipeline(name types.NamespacedName, stageNamespace string) *pipelinev1.Pipeline {
 	return &pipelinev1.Pipeline{
 		TypeMeta:   pipelineTypeMeta,
 		ObjectMeta: meta.ObjectMeta(name),
 		Spec: pipelinev1.PipelineSpec{
-
-			Resources: []pipelinev1.PipelineDeclaredResource{
-				createPipelineDeclaredResource("source-repo", "git"),
-			},
-
 			Tasks: []pipelinev1.PipelineTask{
 				createCommitStatusPipelineTask(PendingCommitStatusTask, "pending", "The build has started"),
-				createCIPipelineTask("apply-source"),
+				createGitCloneTaskForRevision("clone-source", "$(params.COMMIT_SHA)"),
+				createCIPipelineTask("apply-source", "clone-source"),
 			},
 			Params: paramSpecs("REPO", "COMMIT_SHA", "GIT_REPO"),
+			Workspaces: []pipelinev1.PipelineWorkspaceDeclaration{
+				{Name: pipelineWorkspace, Description: "This workspace will receive the cloned git repo."},
+			},
 			Finally: []pipelinev1.PipelineTask{
 				createCommitStatusPipelineTask("set-final-status", "$(tasks.apply-source.status)", "The build is complete"),
 			},
@@ -135,55 +185,100 @@ func CreateCIPipeline(name types.NamespacedName, stageNamespace string) *pipelin
 	}
 }
 
-// CreateAppCDPipeline creates AppCDPipelin
-func CreateAppCDPipeline(name types.NamespacedName, deploymentPath, devNamespace string, isInternalRegistry bool) *pipelinev1.Pipeline {
+// CreateAppCDPipeline creates a pipeline that builds and pushes the image for
+// a service, and opens a pull request against the GitOps repository to update
+// the image in the service's overlay to the newly built image.
+func CreateAppCDPipeline(name types.NamespacedName) *pipelinev1.Pipeline {
+	return CreateTemplatePipeline(name, DefaultTemplate)
+}
+
+// CreateTemplatePipeline creates a pipeline like the app-cd-pipeline, that
+// builds and pushes the image with the tasks of the template in the catalog.
+func CreateTemplatePipeline(name types.NamespacedName, t *Template) *pipelinev1.Pipeline {
+	tasks := []pipelinev1.PipelineTask{
+		createCommitStatusPipelineTask(PendingCommitStatusTask, "pending", "The build has started"),
+		createGitCloneTask("clone-source"),
+	}
+	tasks = append(tasks, t.buildTasks("clone-source")...)
+	tasks = append(tasks,
+		createGitOpsCloneTask("clone-gitops", buildImageTask),
+		createUpdateImageTask("update-image", "clone-gitops"),
+	)
 	return &pipelinev1.Pipeline{
 		TypeMeta:   pipelineTypeMeta,
 		ObjectMeta: meta.ObjectMeta(name),
 		Spec: pipelinev1.PipelineSpec{
-			Resources: []pipelinev1.PipelineDeclaredResource{
-				createPipelineDeclaredResource("source-repo", "git"),
-				createPipelineDeclaredResource("runtime-image", "image"),
+			Params: paramSpecs(
+				"REPO",
+				"COMMIT_SHA",
+				"TLSVERIFY",
+				"BUILD_EXTRA_ARGS",
+				"IMAGE",
+				"GIT_REF",
+				"COMMIT_DATE",
+				"COMMIT_AUTHOR",
+				"COMMIT_MESSAGE",
+				"GIT_REPO",
+				"GITOPS_REPO",
+				"GITOPS_FULLNAME",
+				"GITOPS_DRIVER",
+				"GITOPS_ENV",
+				"GITOPS_APP",
+				"GITOPS_SERVICE"),
+			Tasks: tasks,
+			Workspaces: []pipelinev1.PipelineWorkspaceDeclaration{
+				{Name: pipelineWorkspace, Description: "This workspace will receive the cloned git repo."},
 			},
-			Tasks: []pipelinev1.PipelineTask{
-				createDevCDBuildImageTask("build-image"),
-				createDevCDDeployImageTask("deploy-image", devNamespace, deploymentPath),
+			Finally: []pipelinev1.PipelineTask{
+				createCommitStatusPipelineTask("set-final-status", "$(tasks."+buildImageTask+".status)", "The build is complete"),
 			},
 		},
 	}
 }
 
-func createCIPipelineTask(taskName string) pipelinev1.PipelineTask {
+// createGitOpsCloneTask clones the GitOps repository into a sub-directory of
+// the workspace, so that it doesn't replace the service's source.
+func createGitOpsCloneTask(name, runAfter string) pipelinev1.PipelineTask {
 	return pipelinev1.PipelineTask{
-		Name:    taskName,
-		TaskRef: createTaskRef("deploy-from-source-task", pipelinev1.NamespacedTaskKind),
-		Resources: &pipelinev1.PipelineTaskResources{
-			Inputs: []pipelinev1.PipelineTaskInputResource{createInputTaskResource("source", "source-repo")},
+		Name:    name,
+		TaskRef: createTaskRef("git-clone", pipelinev1.ClusterTaskKind),
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "output", Workspace: pipelineWorkspace, SubPath: gitOpsSubPath},
 		},
 		Params: []pipelinev1.Param{
-			createTaskParam("DRYRUN", "true"),
+			createTaskParam("url", "$(params.GITOPS_REPO)"),
 		},
-		RunAfter: []string{PendingCommitStatusTask},
+		RunAfter: []string{runAfter},
 	}
 }
 
-func createDevCDDeployImageTask(name, devNamespace, deploymentPath string) pipelinev1.PipelineTask {
+func createUpdateImageTask(name, runAfter string) pipelinev1.PipelineTask {
 	return pipelinev1.PipelineTask{
-		Name:     name,
-		TaskRef:  createTaskRef("deploy-using-kubectl-task", pipelinev1.NamespacedTaskKind),
-		RunAfter: []string{"build-image"},
-		Resources: &pipelinev1.PipelineTaskResources{
-			Inputs: []pipelinev1.PipelineTaskInputResource{
-				createInputTaskResource("source", "source-repo"),
-				createInputTaskResource("image", "runtime-image"),
-			},
+		Name:    name,
+		TaskRef: createTaskRef(tasks.UpdateImageTaskName, pipelinev1.NamespacedTaskKind),
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace, SubPath: gitOpsSubPath},
 		},
 		Params: []pipelinev1.Param{
-			createTaskParam("PATHTODEPLOYMENT", deploymentPath),
-			createTaskParam("YAMLPATHTOIMAGE", "spec.template.spec.containers[0].image"),
-			createTaskParam("NAMESPACE", devNamespace),
+			createTaskParam("GIT_REPO", "$(params.GITOPS_REPO)"),
+			createTaskParam("REPO", "$(params.GITOPS_FULLNAME)"),
+			createTaskParam("DRIVER", "$(params.GITOPS_DRIVER)"),
+			createTaskParam("ENV_NAME", "$(params.GITOPS_ENV)"),
+			createTaskParam("APP_NAME", "$(params.GITOPS_APP)"),
+			createTaskParam("SERVICE_NAME", "$(params.GITOPS_SERVICE)"),
+			createTaskParam("IMAGE", "$(params.IMAGE)"),
+			createTaskParam("BRANCH", "update-image-$(context.pipelineRun.name)"),
 		},
+		RunAfter: []string{runAfter},
+	}
+}
+
+func createCIPipelineTask(taskName, runAfter string) pipelinev1.PipelineTask {
+	task := createCDPipelineTask(taskName, runAfter)
+	task.Params = []pipelinev1.Param{
+		createTaskParam("DRYRUN", "true"),
 	}
+	return task
 }
 
 func createCommitStatusPipelineTask(name, state, desc string) pipelinev1.PipelineTask {
@@ -200,30 +295,6 @@ func createCommitStatusPipelineTask(name, state, desc string) pipelinev1.Pipelin
 	}
 }
 
-func createInputTaskResource(name, resource string) pipelinev1.PipelineTaskInputResource {
-	return pipelinev1.PipelineTaskInputResource{
-		Name:     name,
-		Resource: resource,
-	}
-}
-
-func createDevCDBuildImageTask(name string) pipelinev1.PipelineTask {
-	return pipelinev1.PipelineTask{
-		Name:    name,
-		TaskRef: createTaskRef("buildah", pipelinev1.ClusterTaskKind),
-		Params: []pipelinev1.Param{
-			createTaskParam("TLSVERIFY", "true"),
-		},
-	}
-}
-
-func createOutputTaskResource(name, resource string) pipelinev1.PipelineTaskOutputResource {
-	return pipelinev1.PipelineTaskOutputResource{
-		Name:     name,
-		Resource: resource,
-	}
-}
-
 func createTaskRef(name string, kind pipelinev1.TaskKind) *pipelinev1.TaskRef {
 	return &pipelinev1.TaskRef{
 		Name: name,
@@ -242,10 +313,6 @@ func createTaskParam(name, value string) pipelinev1.Param {
 	}
 }
 
-func createPipelineDeclaredResource(name, resourceType string) pipelinev1.PipelineDeclaredResource {
-	return pipelinev1.PipelineDeclaredResource{Name: name, Type: resourceType}
-}
-
 func metadataLabelArgs() string {
 	labels := map[string]string{
 		triggers.GitCommitID:      "$(params.COMMIT_SHA)",
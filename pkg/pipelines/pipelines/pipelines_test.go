@@ -117,3 +117,58 @@ func TestCreateAppCIPipeline(t *testing.T) {
 		t.Fatalf("CreateAppCIPipeline failed:\n%s", diff)
 	}
 }
+
+func TestCreateAppCIPRPipeline(t *testing.T) {
+	name := types.NamespacedName{Name: "test-pipeline", Namespace: "test-ns"}
+	p := CreateAppCIPRPipeline(name)
+
+	want := CreateAppCIPipeline(name)
+	want.Spec.Tasks[2].Params = append(want.Spec.Tasks[2].Params, createTaskParam("SKIP_PUSH", "true"))
+
+	if diff := cmp.Diff(want, p); diff != "" {
+		t.Fatalf("CreateAppCIPRPipeline failed:\n%s", diff)
+	}
+}
+
+func TestCreateAppCDPipeline(t *testing.T) {
+	name := types.NamespacedName{Name: "test-pipeline", Namespace: "test-ns"}
+	p := CreateAppCDPipeline(name)
+
+	want := CreateAppCIPipeline(name)
+	want.Spec.Params = append(want.Spec.Params, paramSpecs("GITOPS_REPO", "GITOPS_FULLNAME", "GITOPS_DRIVER", "GITOPS_ENV", "GITOPS_APP", "GITOPS_SERVICE")...)
+	want.Spec.Tasks = append(want.Spec.Tasks,
+		pipelinev1.PipelineTask{
+			Name:     "clone-gitops",
+			RunAfter: []string{"build-image"},
+			TaskRef:  &pipelinev1.TaskRef{Name: "git-clone", Kind: "ClusterTask"},
+			Params: []pipelinev1.Param{
+				createTaskParam("url", "$(params.GITOPS_REPO)"),
+			},
+			Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+				{Name: "output", Workspace: pipelineWorkspace, SubPath: "gitops"},
+			},
+		},
+		pipelinev1.PipelineTask{
+			Name:     "update-image",
+			RunAfter: []string{"clone-gitops"},
+			TaskRef:  &pipelinev1.TaskRef{Name: "update-image-pull-request", Kind: "Task"},
+			Params: []pipelinev1.Param{
+				createTaskParam("GIT_REPO", "$(params.GITOPS_REPO)"),
+				createTaskParam("REPO", "$(params.GITOPS_FULLNAME)"),
+				createTaskParam("DRIVER", "$(params.GITOPS_DRIVER)"),
+				createTaskParam("ENV_NAME", "$(params.GITOPS_ENV)"),
+				createTaskParam("APP_NAME", "$(params.GITOPS_APP)"),
+				createTaskParam("SERVICE_NAME", "$(params.GITOPS_SERVICE)"),
+				createTaskParam("IMAGE", "$(params.IMAGE)"),
+				createTaskParam("BRANCH", "update-image-$(context.pipelineRun.name)"),
+			},
+			Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+				{Name: "source", Workspace: pipelineWorkspace, SubPath: "gitops"},
+			},
+		},
+	)
+
+	if diff := cmp.Diff(want, p); diff != "" {
+		t.Fatalf("CreateAppCDPipeline failed:\n%s", diff)
+	}
+}
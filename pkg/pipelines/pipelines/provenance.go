@@ -0,0 +1,62 @@
+package pipelines
+
+import (
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+// imageResultsClusterTasks are the ClusterTasks that the templates push the
+// image with, that declare the IMAGE_URL and IMAGE_DIGEST results, the s2i
+// ClusterTasks only declare IMAGE_DIGEST in some OpenShift Pipelines releases.
+var imageResultsClusterTasks = map[string]bool{
+	"buildah": true,
+}
+
+// AddProvenanceResults adds the IMAGE_URL and IMAGE_DIGEST results of the
+// task that pushes the image to the pipeline's results, these are the type
+// hints that Tekton Chains generates the provenance of PipelineRuns from.
+//
+// The results are only added if the task declares both of them, otherwise
+// the PipelineRuns would fail to resolve the results.
+func AddProvenanceResults(p *pipelinev1.Pipeline) {
+	if !hasImageResults(p, "IMAGE_URL", "IMAGE_DIGEST") {
+		return
+	}
+	p.Spec.Results = append(p.Spec.Results,
+		pipelinev1.PipelineResult{
+			Name:        "IMAGE_URL",
+			Description: "The URL of the built image.",
+			Value:       "$(tasks." + buildImageTask + ".results.IMAGE_URL)",
+		},
+		pipelinev1.PipelineResult{
+			Name:        "IMAGE_DIGEST",
+			Description: "The digest of the built image.",
+			Value:       "$(tasks." + buildImageTask + ".results.IMAGE_DIGEST)",
+		},
+	)
+}
+
+// hasImageResults returns true if the task that pushes the image declares all
+// the named results.
+func hasImageResults(p *pipelinev1.Pipeline, names ...string) bool {
+	for _, task := range p.Spec.Tasks {
+		if task.Name != buildImageTask {
+			continue
+		}
+		if task.TaskRef != nil {
+			return task.TaskRef.Kind == pipelinev1.ClusterTaskKind && imageResultsClusterTasks[task.TaskRef.Name]
+		}
+		if task.TaskSpec != nil {
+			results := map[string]bool{}
+			for _, r := range task.TaskSpec.Results {
+				results[r.Name] = true
+			}
+			for _, name := range names {
+				if !results[name] {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
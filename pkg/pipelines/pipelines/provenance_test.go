@@ -0,0 +1,63 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestAddProvenanceResults(t *testing.T) {
+	p := CreateAppCIPipeline(types.NamespacedName{Name: "app-ci-pipeline", Namespace: "test-ns"})
+	AddProvenanceResults(p)
+
+	want := []pipelinev1.PipelineResult{
+		{Name: "IMAGE_URL", Description: "The URL of the built image.", Value: "$(tasks.build-image.results.IMAGE_URL)"},
+		{Name: "IMAGE_DIGEST", Description: "The digest of the built image.", Value: "$(tasks.build-image.results.IMAGE_DIGEST)"},
+	}
+	if diff := cmp.Diff(want, p.Spec.Results); diff != "" {
+		t.Fatalf("AddProvenanceResults() failed:\n%s", diff)
+	}
+}
+
+func TestAddProvenanceResultsForTemplates(t *testing.T) {
+	name := types.NamespacedName{Name: "test-pipeline", Namespace: "test-ns"}
+	templateTests := []struct {
+		template string
+		want     []string
+	}{
+		{"buildah", []string{"IMAGE_URL", "IMAGE_DIGEST"}},
+		{"ko", []string{"IMAGE_URL", "IMAGE_DIGEST"}},
+		{"maven-buildah", []string{"IMAGE_URL", "IMAGE_DIGEST"}},
+		{"s2i-java", []string{}},
+		{"s2i-nodejs", []string{}},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.template, func(rt *testing.T) {
+			p := CreateTemplatePipeline(name, LookupTemplate(tt.template))
+			AddProvenanceResults(p)
+
+			got := []string{}
+			for _, r := range p.Spec.Results {
+				got = append(got, r.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				rt.Fatalf("AddProvenanceResults() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestKoTaskResults(t *testing.T) {
+	task := createKoTask(buildImageTask, "clone-source")
+
+	want := []pipelinev1.TaskResult{
+		{Name: "IMAGE_DIGEST", Description: "The digest of the built image."},
+		{Name: "IMAGE_URL", Description: "The URL of the built image."},
+	}
+	if diff := cmp.Diff(want, task.TaskSpec.Results); diff != "" {
+		t.Fatalf("createKoTask() failed to declare the image results:\n%s", diff)
+	}
+}
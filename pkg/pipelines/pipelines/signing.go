@@ -0,0 +1,141 @@
+package pipelines
+
+import (
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	generateSBOMTask = "generate-sbom"
+	signImageTask    = "sign-image"
+
+	syftImage   = "docker.io/anchore/syft:v0.62.1"
+	cosignImage = "gcr.io/projectsigstore/cosign:v1.13.1"
+
+	sbomPath      = "$(workspaces.source.path)/sbom.spdx.json"
+	cosignKeyPath = "/etc/cosign"
+
+	// The image is referred to by the digest that was built, the tag can be
+	// moved to another image before it's signed.
+	imageByDigest = "$(params.IMAGE)@$(params.IMAGE_DIGEST)"
+)
+
+// AddImageSigning adds tasks after the image is built, that generate an SPDX
+// SBOM for the image with syft, and sign the image and attest the SBOM with
+// cosign, with the key in the named Secret.
+//
+// The tasks that ran after the image was built run after it's signed.
+//
+// The image is signed by its digest, so the tasks are only added if the task
+// that pushes the image declares the IMAGE_DIGEST result, it returns false if
+// the image isn't signed.
+func AddImageSigning(p *pipelinev1.Pipeline, secretName string) bool {
+	if !hasImageResults(p, "IMAGE_DIGEST") {
+		return false
+	}
+	tasks := []pipelinev1.PipelineTask{}
+	for _, task := range p.Spec.Tasks {
+		task.RunAfter = replaceRunAfter(task.RunAfter, buildImageTask, signImageTask)
+		tasks = append(tasks, task)
+		if task.Name == buildImageTask {
+			tasks = append(tasks,
+				createGenerateSBOMTask(generateSBOMTask, buildImageTask),
+				createSignImageTask(signImageTask, generateSBOMTask, secretName))
+		}
+	}
+	p.Spec.Tasks = tasks
+	return true
+}
+
+func createGenerateSBOMTask(name, runAfter string) pipelinev1.PipelineTask {
+	return pipelinev1.PipelineTask{
+		Name: name,
+		TaskSpec: &pipelinev1.EmbeddedTask{
+			TaskSpec: pipelinev1.TaskSpec{
+				Params: paramSpecs("IMAGE", "IMAGE_DIGEST"),
+				Workspaces: []pipelinev1.WorkspaceDeclaration{
+					{Name: "source", Description: "The workspace the SBOM is written to."},
+				},
+				Steps: []pipelinev1.Step{
+					{
+						Container: corev1.Container{
+							Name:  "syft",
+							Image: syftImage,
+							Args:  []string{imageByDigest, "--output", "spdx-json", "--file", sbomPath},
+						},
+					},
+				},
+			},
+		},
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
+		},
+		RunAfter: []string{runAfter},
+		Params: []pipelinev1.Param{
+			createTaskParam("IMAGE", "$(params.IMAGE)"),
+			createTaskParam("IMAGE_DIGEST", "$(tasks."+buildImageTask+".results.IMAGE_DIGEST)"),
+		},
+	}
+}
+
+// createSignImageTask signs the image, and attests the SBOM, the key is
+// mounted from the Secret, and its password is read by cosign from the
+// environment.
+func createSignImageTask(name, runAfter, secretName string) pipelinev1.PipelineTask {
+	key := cosignKeyPath + "/cosign.key"
+	container := func(name string, args ...string) corev1.Container {
+		return corev1.Container{
+			Name:  name,
+			Image: cosignImage,
+			Args:  args,
+			Env: []corev1.EnvVar{
+				{
+					Name: "COSIGN_PASSWORD",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+							Key:                  "cosign.password",
+						},
+					},
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "cosign-key", MountPath: cosignKeyPath, ReadOnly: true},
+			},
+		}
+	}
+	return pipelinev1.PipelineTask{
+		Name: name,
+		TaskSpec: &pipelinev1.EmbeddedTask{
+			TaskSpec: pipelinev1.TaskSpec{
+				Params: paramSpecs("IMAGE", "IMAGE_DIGEST"),
+				Workspaces: []pipelinev1.WorkspaceDeclaration{
+					{Name: "source", Description: "The workspace with the SBOM."},
+				},
+				Steps: []pipelinev1.Step{
+					{Container: container("sign", "sign", "--key", key, imageByDigest)},
+					{Container: container("attest", "attest", "--key", key, "--type", "spdxjson", "--predicate", sbomPath, imageByDigest)},
+				},
+				Volumes: []corev1.Volume{
+					{
+						Name: "cosign-key",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: secretName,
+								Items:      []corev1.KeyToPath{{Key: "cosign.key", Path: "cosign.key"}},
+							},
+						},
+					},
+				},
+			},
+		},
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
+		},
+		RunAfter: []string{runAfter},
+		Params: []pipelinev1.Param{
+			createTaskParam("IMAGE", "$(params.IMAGE)"),
+			createTaskParam("IMAGE_DIGEST", "$(tasks."+buildImageTask+".results.IMAGE_DIGEST)"),
+		},
+	}
+}
@@ -0,0 +1,148 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestAddImageSigning(t *testing.T) {
+	signingTests := []struct {
+		name     string
+		pipeline func(types.NamespacedName) *pipelinev1.Pipeline
+		want     [][]string
+	}{
+		{
+			"app-ci-pipeline",
+			CreateAppCIPipeline,
+			[][]string{
+				{PendingCommitStatusTask},
+				{"clone-source", PendingCommitStatusTask},
+				{"build-image", "clone-source"},
+				{"generate-sbom", "build-image"},
+				{"sign-image", "generate-sbom"},
+			},
+		},
+		{
+			"app-cd-pipeline",
+			CreateAppCDPipeline,
+			[][]string{
+				{PendingCommitStatusTask},
+				{"clone-source", PendingCommitStatusTask},
+				{"build-image", "clone-source"},
+				{"generate-sbom", "build-image"},
+				{"sign-image", "generate-sbom"},
+				{"clone-gitops", "sign-image"},
+				{"update-image", "clone-gitops"},
+			},
+		},
+	}
+
+	for _, tt := range signingTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			p := tt.pipeline(types.NamespacedName{Name: tt.name, Namespace: "test-ns"})
+			if !AddImageSigning(p, "cosign-key") {
+				rt.Fatal("AddImageSigning() didn't sign the image")
+			}
+
+			got := [][]string{}
+			for _, task := range p.Spec.Tasks {
+				got = append(got, append([]string{task.Name}, task.RunAfter...))
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				rt.Fatalf("AddImageSigning() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCreateSignImageTask(t *testing.T) {
+	task := createSignImageTask("sign-image", "generate-sbom", "cosign-key")
+
+	want := []corev1.Volume{
+		{
+			Name: "cosign-key",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "cosign-key",
+					Items:      []corev1.KeyToPath{{Key: "cosign.key", Path: "cosign.key"}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, task.TaskSpec.Volumes); diff != "" {
+		t.Fatalf("createSignImageTask() failed to mount the key:\n%s", diff)
+	}
+	args := [][]string{}
+	for _, step := range task.TaskSpec.Steps {
+		args = append(args, step.Args)
+		if diff := cmp.Diff("cosign-key", step.Env[0].ValueFrom.SecretKeyRef.Name); diff != "" {
+			t.Fatalf("createSignImageTask() failed to read the password:\n%s", diff)
+		}
+	}
+	wantArgs := [][]string{
+		{"sign", "--key", "/etc/cosign/cosign.key", "$(params.IMAGE)@$(params.IMAGE_DIGEST)"},
+		{"attest", "--key", "/etc/cosign/cosign.key", "--type", "spdxjson", "--predicate", "$(workspaces.source.path)/sbom.spdx.json", "$(params.IMAGE)@$(params.IMAGE_DIGEST)"},
+	}
+	if diff := cmp.Diff(wantArgs, args); diff != "" {
+		t.Fatalf("createSignImageTask() failed:\n%s", diff)
+	}
+	wantParams := []pipelinev1.Param{
+		createTaskParam("IMAGE", "$(params.IMAGE)"),
+		createTaskParam("IMAGE_DIGEST", "$(tasks.build-image.results.IMAGE_DIGEST)"),
+	}
+	if diff := cmp.Diff(wantParams, task.Params); diff != "" {
+		t.Fatalf("createSignImageTask() failed to pass the digest:\n%s", diff)
+	}
+}
+
+func TestCreateGenerateSBOMTask(t *testing.T) {
+	task := createGenerateSBOMTask("generate-sbom", "build-image")
+
+	wantArgs := []string{"$(params.IMAGE)@$(params.IMAGE_DIGEST)", "--output", "spdx-json", "--file", "$(workspaces.source.path)/sbom.spdx.json"}
+	if diff := cmp.Diff(wantArgs, task.TaskSpec.Steps[0].Args); diff != "" {
+		t.Fatalf("createGenerateSBOMTask() failed:\n%s", diff)
+	}
+	wantParams := []pipelinev1.Param{
+		createTaskParam("IMAGE", "$(params.IMAGE)"),
+		createTaskParam("IMAGE_DIGEST", "$(tasks.build-image.results.IMAGE_DIGEST)"),
+	}
+	if diff := cmp.Diff(wantParams, task.Params); diff != "" {
+		t.Fatalf("createGenerateSBOMTask() failed to pass the digest:\n%s", diff)
+	}
+}
+
+func TestAddImageSigningForTemplates(t *testing.T) {
+	name := types.NamespacedName{Name: "test-pipeline", Namespace: "test-ns"}
+	templateTests := []struct {
+		template string
+		want     bool
+	}{
+		{"buildah", true},
+		{"ko", true},
+		{"maven-buildah", true},
+		{"s2i-java", false},
+		{"s2i-nodejs", false},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.template, func(rt *testing.T) {
+			p := CreateTemplatePipeline(name, LookupTemplate(tt.template))
+			signed := AddImageSigning(p, "cosign-key")
+
+			if signed != tt.want {
+				rt.Fatalf("AddImageSigning() got %v, want %v", signed, tt.want)
+			}
+			tasks := map[string]bool{}
+			for _, task := range p.Spec.Tasks {
+				tasks[task.Name] = true
+			}
+			if tasks[signImageTask] != tt.want {
+				rt.Fatalf("AddImageSigning() sign-image task got %v, want %v", tasks[signImageTask], tt.want)
+			}
+		})
+	}
+}
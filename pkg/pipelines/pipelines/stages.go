@@ -0,0 +1,135 @@
+package pipelines
+
+import (
+	"fmt"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+)
+
+const sonarScannerImage = "docker.io/sonarsource/sonar-scanner-cli:4.7"
+
+// sonarScannerScript scans the source, the host and token are read by the
+// scanner from the environment, and waiting for the quality gate fails the
+// stage if the project doesn't pass it.
+const sonarScannerScript = `#!/bin/sh
+set -e
+sonar-scanner -Dsonar.projectKey="${SONAR_PROJECT_KEY}" -Dsonar.qualitygate.wait=true
+`
+
+// AddStages adds the pre-build stages to a pipeline, the stages are run in
+// order after the source is cloned, and the tasks that ran after the clone run
+// after the last stage.
+//
+// The commit status of each stage is set when the pipeline finishes, with the
+// stage's name in the status context.
+func AddStages(p *pipelinev1.Pipeline, stages []*config.Stage) error {
+	names := map[string]bool{}
+	cloneIndex := -1
+	for i, task := range p.Spec.Tasks {
+		names[task.Name] = true
+		if task.Name == "clone-source" {
+			cloneIndex = i
+		}
+	}
+	for _, task := range p.Spec.Finally {
+		names[task.Name] = true
+	}
+	if cloneIndex == -1 {
+		return fmt.Errorf("pipeline %s has no clone-source task to run the stages after", p.Name)
+	}
+
+	stageTasks := []pipelinev1.PipelineTask{}
+	statusTasks := []pipelinev1.PipelineTask{}
+	runAfter := "clone-source"
+	for _, stage := range stages {
+		statusName := fmt.Sprintf("set-%s-status", stage.Name)
+		for _, name := range []string{stage.Name, statusName} {
+			if names[name] {
+				return fmt.Errorf("stage %s has the same name as the task %s in pipeline %s", stage.Name, name, p.Name)
+			}
+			names[name] = true
+		}
+		stageTasks = append(stageTasks, createStageTask(stage, runAfter))
+		statusTasks = append(statusTasks, createStageStatusTask(statusName, stage.Name))
+		runAfter = stage.Name
+	}
+
+	tasks := append([]pipelinev1.PipelineTask{}, p.Spec.Tasks[:cloneIndex+1]...)
+	tasks = append(tasks, stageTasks...)
+	for _, task := range p.Spec.Tasks[cloneIndex+1:] {
+		task.RunAfter = replaceRunAfter(task.RunAfter, "clone-source", runAfter)
+		tasks = append(tasks, task)
+	}
+	p.Spec.Tasks = tasks
+	p.Spec.Finally = append(p.Spec.Finally, statusTasks...)
+	return nil
+}
+
+func createStageTask(stage *config.Stage, runAfter string) pipelinev1.PipelineTask {
+	step := pipelinev1.Step{
+		Container: corev1.Container{
+			Name:       stage.Name,
+			Image:      stage.Image,
+			WorkingDir: "$(workspaces.source.path)",
+		},
+		Script: stage.Script,
+	}
+	if stage.SonarQube != nil {
+		step.Container.Image = sonarScannerImage
+		step.Container.Env = sonarScannerEnv(stage.SonarQube)
+		step.Script = sonarScannerScript
+	}
+	return pipelinev1.PipelineTask{
+		Name: stage.Name,
+		TaskSpec: &pipelinev1.EmbeddedTask{
+			TaskSpec: pipelinev1.TaskSpec{
+				Workspaces: []pipelinev1.WorkspaceDeclaration{
+					{Name: "source", Description: "The workspace with the service's source."},
+				},
+				Steps: []pipelinev1.Step{step},
+			},
+		},
+		Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+			{Name: "source", Workspace: pipelineWorkspace},
+		},
+		RunAfter: []string{runAfter},
+	}
+}
+
+func sonarScannerEnv(scan *config.SonarQubeScan) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "SONAR_HOST_URL", Value: scan.HostURL},
+		{Name: "SONAR_PROJECT_KEY", Value: scan.ProjectKey},
+		{
+			Name: "SONAR_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: scan.TokenSecret},
+					Key:                  "token",
+				},
+			},
+		},
+	}
+}
+
+// createStageStatusTask sets the commit status for a stage, in a context of
+// its own, so that it doesn't replace the status of the build.
+func createStageStatusTask(name, stage string) pipelinev1.PipelineTask {
+	task := createCommitStatusPipelineTask(name, fmt.Sprintf("$(tasks.%s.status)", stage), fmt.Sprintf("The %s stage is complete", stage))
+	task.Params = append(task.Params, createTaskParam("CONTEXT", "kam/"+stage))
+	return task
+}
+
+func replaceRunAfter(runAfter []string, from, to string) []string {
+	replaced := []string{}
+	for _, name := range runAfter {
+		if name == from {
+			name = to
+		}
+		replaced = append(replaced, name)
+	}
+	return replaced
+}
@@ -0,0 +1,154 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/test"
+)
+
+func TestAddStages(t *testing.T) {
+	p := CreateAppCIPipeline(types.NamespacedName{Name: "app-ci-pipeline", Namespace: "test-ns"})
+	stages := []*config.Stage{
+		{Name: "unit-tests", Image: "golang:1.17", Script: "go test ./..."},
+		{Name: "lint", Image: "golangci/golangci-lint:v1.45"},
+	}
+
+	if err := AddStages(p, stages); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []pipelinev1.PipelineTask{
+		createCommitStatusPipelineTask(PendingCommitStatusTask, "pending", "The build has started"),
+		createGitCloneTask("clone-source"),
+		{
+			Name: "unit-tests",
+			TaskSpec: &pipelinev1.EmbeddedTask{
+				TaskSpec: pipelinev1.TaskSpec{
+					Workspaces: []pipelinev1.WorkspaceDeclaration{
+						{Name: "source", Description: "The workspace with the service's source."},
+					},
+					Steps: []pipelinev1.Step{
+						{
+							Container: corev1.Container{Name: "unit-tests", Image: "golang:1.17", WorkingDir: "$(workspaces.source.path)"},
+							Script:    "go test ./...",
+						},
+					},
+				},
+			},
+			Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+				{Name: "source", Workspace: pipelineWorkspace},
+			},
+			RunAfter: []string{"clone-source"},
+		},
+		{
+			Name: "lint",
+			TaskSpec: &pipelinev1.EmbeddedTask{
+				TaskSpec: pipelinev1.TaskSpec{
+					Workspaces: []pipelinev1.WorkspaceDeclaration{
+						{Name: "source", Description: "The workspace with the service's source."},
+					},
+					Steps: []pipelinev1.Step{
+						{
+							Container: corev1.Container{Name: "lint", Image: "golangci/golangci-lint:v1.45", WorkingDir: "$(workspaces.source.path)"},
+						},
+					},
+				},
+			},
+			Workspaces: []pipelinev1.WorkspacePipelineTaskBinding{
+				{Name: "source", Workspace: pipelineWorkspace},
+			},
+			RunAfter: []string{"unit-tests"},
+		},
+		createBuildImageTask("build-image", "lint"),
+	}
+	if diff := cmp.Diff(want, p.Spec.Tasks); diff != "" {
+		t.Fatalf("AddStages() tasks failed:\n%s", diff)
+	}
+
+	unitTestsStatus := createCommitStatusPipelineTask("set-unit-tests-status", "$(tasks.unit-tests.status)", "The unit-tests stage is complete")
+	unitTestsStatus.Params = append(unitTestsStatus.Params, createTaskParam("CONTEXT", "kam/unit-tests"))
+	lintStatus := createCommitStatusPipelineTask("set-lint-status", "$(tasks.lint.status)", "The lint stage is complete")
+	lintStatus.Params = append(lintStatus.Params, createTaskParam("CONTEXT", "kam/lint"))
+	wantFinally := []pipelinev1.PipelineTask{
+		createCommitStatusPipelineTask("set-final-status", "$(tasks.build-image.status)", "The build is complete"),
+		unitTestsStatus,
+		lintStatus,
+	}
+	if diff := cmp.Diff(wantFinally, p.Spec.Finally); diff != "" {
+		t.Fatalf("AddStages() finally tasks failed:\n%s", diff)
+	}
+}
+
+func TestAddStagesWithSonarQube(t *testing.T) {
+	p := CreateAppCDPipeline(types.NamespacedName{Name: "app-cd-pipeline", Namespace: "test-ns"})
+	stages := []*config.Stage{
+		{
+			Name: "sonarqube",
+			SonarQube: &config.SonarQubeScan{
+				HostURL:     "https://sonarqube.example.com",
+				ProjectKey:  "http-api",
+				TokenSecret: "sonarqube-token",
+			},
+		},
+	}
+
+	if err := AddStages(p, stages); err != nil {
+		t.Fatal(err)
+	}
+
+	step := p.Spec.Tasks[2].TaskSpec.Steps[0]
+	want := pipelinev1.Step{
+		Container: corev1.Container{
+			Name:       "sonarqube",
+			Image:      sonarScannerImage,
+			WorkingDir: "$(workspaces.source.path)",
+			Env: []corev1.EnvVar{
+				{Name: "SONAR_HOST_URL", Value: "https://sonarqube.example.com"},
+				{Name: "SONAR_PROJECT_KEY", Value: "http-api"},
+				{
+					Name: "SONAR_TOKEN",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "sonarqube-token"},
+							Key:                  "token",
+						},
+					},
+				},
+			},
+		},
+		Script: sonarScannerScript,
+	}
+	if diff := cmp.Diff(want, step); diff != "" {
+		t.Fatalf("AddStages() SonarQube step failed:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"sonarqube"}, p.Spec.Tasks[3].RunAfter); diff != "" {
+		t.Fatalf("AddStages() failed to build after the stages:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{buildImageTask}, p.Spec.Tasks[4].RunAfter); diff != "" {
+		t.Fatalf("AddStages() changed the GitOps clone:\n%s", diff)
+	}
+}
+
+func TestAddStagesErrors(t *testing.T) {
+	errorTests := []struct {
+		name   string
+		stages []*config.Stage
+		want   string
+	}{
+		{"stage with a task's name", []*config.Stage{{Name: "build-image", Image: "golang:1.17"}}, "stage build-image has the same name as the task build-image in pipeline app-ci-pipeline"},
+		{"stage with a status task's name", []*config.Stage{{Name: "final", Image: "golang:1.17"}}, "stage final has the same name as the task set-final-status in pipeline app-ci-pipeline"},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			p := CreateAppCIPipeline(types.NamespacedName{Name: "app-ci-pipeline", Namespace: "test-ns"})
+			test.AssertErrorMatch(rt, tt.want, AddStages(p, tt.stages))
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package pipelines
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/accesstoken"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+// PromoteOptions control how a service is promoted from one environment to
+// another.
+type PromoteOptions struct {
+	AppName             string // Optional, only needed if the service is in more than one application.
+	FromEnvName         string
+	ToEnvName           string
+	PipelinesFolderPath string
+	ServiceName         string
+}
+
+// PullRequestOptions control how the promotion is proposed as a pull request
+// to the GitOps repository.
+type PullRequestOptions struct {
+	BaseBranch         string // The branch that the pull request is opened against.
+	Branch             string // The branch to push the promotion to, if empty a name is generated.
+	GitHostAccessToken string // The auth token to use to open the pull request, if empty the token stored by keyring is used.
+}
+
+// PromoteResult is the files that a promotion changed, relative to the
+// pipelines folder, and sorted.
+type PromoteResult struct {
+	Copied  []string // The service's files that were copied to the target environment.
+	Written []string // Every file that was written, including the copied files.
+	Removed []string // The files that were removed from the target environment.
+}
+
+// Promote copies the service's configuration from one environment to another,
+// adding the service to the target environment in the manifest if it's not
+// already there, and regenerates the resources.
+func Promote(o *PromoteOptions, appFs afero.Fs) (*PromoteResult, error) {
+	if o.FromEnvName == o.ToEnvName {
+		return nil, fmt.Errorf("can't promote service %s from environment %s to itself", o.ServiceName, o.FromEnvName)
+	}
+	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
+	if err != nil {
+		return nil, err
+	}
+	from := m.GetEnvironment(o.FromEnvName)
+	if from == nil {
+		return nil, fmt.Errorf("environment %s does not exist", o.FromEnvName)
+	}
+	to := m.GetEnvironment(o.ToEnvName)
+	if to == nil {
+		return nil, fmt.Errorf("environment %s does not exist", o.ToEnvName)
+	}
+	app, err := findServiceApplication(from, o.AppName, o.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	if m.GetApplication(to.Name, app.Name) == nil || !hasService(m.GetApplication(to.Name, app.Name), o.ServiceName) {
+		// the service is only deployed to the target environment, it's built
+		// in the source environment, so it has no source repository there.
+		if err := m.AddService(to.Name, app.Name, &config.Service{Name: o.ServiceName}); err != nil {
+			return nil, err
+		}
+		if err := m.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := homedir.Expand(o.PipelinesFolderPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	copied, removed, err := copyServiceFiles(appFs, root,
+		config.PathForService(app, from, o.ServiceName),
+		config.PathForService(m.GetApplication(to.Name, app.Name), to, o.ServiceName),
+		from.Name, to.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	built, err := buildResources(appFs, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resources: %v", err)
+	}
+	// the images in the copied overlay are kept, so that they're promoted.
+	if err := keepOverlayImages(appFs, root, m, built); err != nil {
+		return nil, err
+	}
+	index, err := updateGeneratedFilesIndex(appFs, root, built)
+	if err != nil {
+		return nil, err
+	}
+	files := res.Merge(index, built)
+	files[pipelinesFile] = m
+	written, err := yaml.WriteResources(appFs, root, files)
+	if err != nil {
+		return nil, err
+	}
+	return &PromoteResult{Copied: copied, Written: uniqueSorted(append(written, copied...)), Removed: removed}, nil
+}
+
+func uniqueSorted(paths []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, path := range paths {
+		path = filepath.ToSlash(path)
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// findServiceApplication returns the application in the environment with the
+// service, if appName is empty, the service must be in a single application.
+func findServiceApplication(env *config.Environment, appName, svcName string) (*config.Application, error) {
+	found := []*config.Application{}
+	for _, app := range env.Apps {
+		if (appName == "" || app.Name == appName) && hasService(app, svcName) {
+			found = append(found, app)
+		}
+	}
+	switch len(found) {
+	case 0:
+		if appName != "" {
+			return nil, fmt.Errorf("service %s does not exist in application %s of environment %s", svcName, appName, env.Name)
+		}
+		return nil, fmt.Errorf("service %s does not exist in environment %s", svcName, env.Name)
+	case 1:
+		return found[0], nil
+	}
+	names := []string{}
+	for _, app := range found {
+		names = append(names, app.Name)
+	}
+	return nil, fmt.Errorf("service %s is in more than one application in environment %s (%s), please provide the application name", svcName, env.Name, strings.Join(names, ", "))
+}
+
+func hasService(app *config.Application, svcName string) bool {
+	for _, svc := range app.Services {
+		if svc.Name == svcName {
+			return true
+		}
+	}
+	return false
+}
+
+// copyServiceFiles copies the files below the source path to the target
+// path, both relative to root, resources in the source environment's
+// namespace are moved to the target environment's namespace, and files below
+// the target path that are not in the source are removed.
+//
+// It returns the copied and the removed paths relative to root, sorted.
+func copyServiceFiles(fs afero.Fs, root, source, target, fromNS, toNS string) ([]string, []string, error) {
+	sourceDir := filepath.Join(root, source)
+	if exists, err := afero.DirExists(fs, sourceDir); err != nil || !exists {
+		return nil, nil, fmt.Errorf("failed to find the configuration for the service in %s", source)
+	}
+	stale, err := listFiles(fs, filepath.Join(root, target))
+	if err != nil {
+		return nil, nil, err
+	}
+	copied := []string{}
+	err = afero.Walk(fs, sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(sourceDir, path)
+		if err != nil {
+			return err
+		}
+		body, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		body, err = moveNamespace(body, fromNS, toNS)
+		if err != nil {
+			return fmt.Errorf("failed to update the namespace in %s: %w", path, err)
+		}
+		dest := filepath.Join(root, target, rel)
+		if err := fs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to MkDirAll for %s: %v", dest, err)
+		}
+		if err := afero.WriteFile(fs, dest, body, info.Mode()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", dest, err)
+		}
+		copied = append(copied, filepath.ToSlash(filepath.Join(target, rel)))
+		delete(stale, dest)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	removed := []string{}
+	for path := range stale {
+		if err := fs.Remove(path); err != nil {
+			return nil, nil, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		removed = append(removed, filepath.ToSlash(rel))
+	}
+	sort.Strings(copied)
+	sort.Strings(removed)
+	return copied, removed, nil
+}
+
+// listFiles returns the paths of the files below dir, if dir does not exist,
+// no files are returned.
+func listFiles(fs afero.Fs, dir string) (map[string]bool, error) {
+	files := map[string]bool{}
+	if exists, err := afero.DirExists(fs, dir); err != nil || !exists {
+		return files, err
+	}
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files[path] = true
+		return nil
+	})
+	return files, err
+}
+
+// moveNamespace changes the namespace of the YAML resources in a file from one
+// namespace to another, other files, and resources in other namespaces are
+// left unchanged.
+//
+// Only the namespace values are replaced in the file, so that every document,
+// the comments and the order of the keys are kept.
+func moveNamespace(body []byte, from, to string) ([]byte, error) {
+	decoder := yamlv3.NewDecoder(bytes.NewReader(body))
+	namespaces := []*yamlv3.Node{}
+	for {
+		doc := yamlv3.Node{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// not a YAML file.
+			return body, nil
+		}
+		if ns := namespaceNode(&doc); ns != nil && ns.Value == from {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	if len(namespaces) == 0 {
+		return body, nil
+	}
+
+	lines := bytes.SplitAfter(body, []byte("\n"))
+	for _, ns := range namespaces {
+		line := lines[ns.Line-1]
+		start := len(string([]rune(string(line))[:ns.Column-1]))
+		old, updated := quoteScalar(ns, from), quoteScalar(ns, to)
+		if !bytes.HasPrefix(line[start:], []byte(old)) {
+			return nil, fmt.Errorf("unable to replace namespace %q on line %d", from, ns.Line)
+		}
+		lines[ns.Line-1] = append(append(append([]byte{}, line[:start]...), updated...), line[start+len(old):]...)
+	}
+	return bytes.Join(lines, nil), nil
+}
+
+// namespaceNode returns the metadata.namespace value of a YAML document, or nil
+// if it has none.
+func namespaceNode(doc *yamlv3.Node) *yamlv3.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	metadata := mappingValue(doc.Content[0], "metadata")
+	if metadata == nil {
+		return nil
+	}
+	ns := mappingValue(metadata, "namespace")
+	if ns == nil || ns.Kind != yamlv3.ScalarNode {
+		return nil
+	}
+	return ns
+}
+
+func mappingValue(n *yamlv3.Node, key string) *yamlv3.Node {
+	if n.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// quoteScalar returns the value as it's written in the file with the style of
+// the node.
+func quoteScalar(n *yamlv3.Node, value string) string {
+	switch n.Style {
+	case yamlv3.DoubleQuotedStyle:
+		return `"` + value + `"`
+	case yamlv3.SingleQuotedStyle:
+		return "'" + value + "'"
+	}
+	return value
+}
+
+// PromotePullRequest commits the files that the promotion changed to a new
+// branch of the GitOps repository that the pipelines folder is in, pushes it,
+// and opens a pull request.
+//
+// Only the changed files are committed, so that other changes in the
+// repository aren't proposed with the promotion.
+//
+// It returns the URL of the pull request.
+func PromotePullRequest(o *PromoteOptions, pr *PullRequestOptions, promoted *PromoteResult, f clientFactory, e executor, appFs afero.Fs) (string, error) {
+	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
+	if err != nil {
+		return "", err
+	}
+	accessToken := pr.GitHostAccessToken
+	if accessToken == "" {
+		accessToken, err = accesstoken.GetAccessToken(m.GitOpsURL)
+		if err != nil {
+			return "", fmt.Errorf("unable to use access-token from keyring/env-var: %v, please pass a valid token to --git-host-access-token", err)
+		}
+	}
+	u, err := url.Parse(m.GitOpsURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse GitOps repo URL %q: %w", m.GitOpsURL, err)
+	}
+	repo, err := orgRepoFromURL(m.GitOpsURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse GitOps repo URL %q: %w", m.GitOpsURL, err)
+	}
+	u.User = url.UserPassword("", accessToken)
+	client, err := f(u.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to create a client to access %q: %w", m.GitOpsURL, err)
+	}
+
+	branch := pr.Branch
+	if branch == "" {
+		branch = fmt.Sprintf("promote-%s-%s-to-%s", o.ServiceName, o.FromEnvName, o.ToEnvName)
+	}
+	title := fmt.Sprintf("Promote %s from %s to %s", o.ServiceName, o.FromEnvName, o.ToEnvName)
+	dir, prefix, err := repositoryRoot(e, o.PipelinesFolderPath)
+	if err != nil {
+		return "", err
+	}
+	commands := [][]string{
+		{"checkout", "-b", branch},
+		append([]string{"add", "--"}, repositoryPaths(prefix, promoted.Written)...),
+	}
+	if len(promoted.Removed) > 0 {
+		commands = append(commands, append([]string{"rm", "--cached", "--ignore-unmatch", "--quiet", "--"}, repositoryPaths(prefix, promoted.Removed)...))
+	}
+	commands = append(commands,
+		[]string{"commit", "-m", title},
+		[]string{"push", "-u", "origin", branch},
+	)
+	for _, args := range commands {
+		if out, err := e.execute(dir, "git", args...); err != nil {
+			return "", fmt.Errorf("failed to run git %s in %q %q: %s", args[0], dir, string(out), err)
+		}
+	}
+
+	created, _, err := client.PullRequests.Create(context.Background(), repo, &scm.PullRequestInput{
+		Title: title,
+		Head:  branch,
+		Base:  pr.BaseBranch,
+		Body:  fmt.Sprintf("Promotes the configuration of service %s from environment %s to environment %s.", o.ServiceName, o.FromEnvName, o.ToEnvName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create a pull request in %q: %w", repo, err)
+	}
+	return created.Link, nil
+}
+
+// repositoryRoot returns the root of the git repository that the pipelines
+// folder is in, and the path of the pipelines folder in the repository.
+func repositoryRoot(e executor, pipelinesFolder string) (string, string, error) {
+	out, err := e.execute(pipelinesFolder, "git", "rev-parse", "--show-toplevel", "--show-prefix")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find the git repository of %q %q: %s", pipelinesFolder, string(out), err)
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if lines[0] == "" {
+		return "", "", fmt.Errorf("failed to find the git repository of %q", pipelinesFolder)
+	}
+	prefix := ""
+	if len(lines) > 1 {
+		prefix = lines[1]
+	}
+	return lines[0], prefix, nil
+}
+
+// repositoryPaths returns the paths relative to the pipelines folder relative
+// to the root of the repository.
+func repositoryPaths(prefix string, paths []string) []string {
+	repoPaths := []string{}
+	for _, path := range paths {
+		repoPaths = append(repoPaths, filepath.ToSlash(filepath.Join(prefix, path)))
+	}
+	return repoPaths
+}
@@ -0,0 +1,261 @@
+package pipelines
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/spf13/afero"
+	"github.com/zalando/go-keyring"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/accesstoken"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/test"
+)
+
+func TestPromote(t *testing.T) {
+	fakeFs := bootstrapPromoteFixture(t)
+	devOverlay := "images:\n- name: nginxinc/nginx-unprivileged\n  newName: quay.io/example/http-api\n  newTag: v1.1.0\nresources:\n- ../base\n"
+	assertNoError(t, afero.WriteFile(fakeFs, "/gitops/environments/tst-dev/apps/app-http-api/services/http-api/overlays/kustomization.yaml", []byte(devOverlay), 0644))
+
+	promoted, err := Promote(&PromoteOptions{
+		FromEnvName:         "tst-dev",
+		ToEnvName:           "tst-stage",
+		PipelinesFolderPath: "/gitops",
+		ServiceName:         "http-api",
+	}, fakeFs)
+	assertNoError(t, err)
+
+	svcPath := "environments/tst-stage/apps/app-http-api/services/http-api"
+	want := []string{
+		svcPath + "/base/config/100-deployment.yaml",
+		svcPath + "/base/config/200-service.yaml",
+		svcPath + "/base/config/300-route.yaml",
+		svcPath + "/base/config/kustomization.yaml",
+		svcPath + "/base/kustomization.yaml",
+		svcPath + "/kustomization.yaml",
+		svcPath + "/overlays/kustomization.yaml",
+	}
+	if diff := cmp.Diff(want, promoted.Copied); diff != "" {
+		t.Fatalf("Promote() copied files failed:\n%s", diff)
+	}
+	for _, path := range append(want, "pipelines.yaml", "config/argocd/tst-stage-app-http-api-app.yaml") {
+		if !hasString(promoted.Written, path) {
+			t.Fatalf("Promote() didn't report writing %s", path)
+		}
+	}
+
+	m, err := config.LoadManifest(fakeFs, "/gitops")
+	assertNoError(t, err)
+	app := m.GetApplication("tst-stage", "app-http-api")
+	if app == nil || !hasService(app, "http-api") {
+		t.Fatalf("service was not added to the target environment: %#v", app)
+	}
+
+	deployment := map[string]interface{}{}
+	readYAML(t, fakeFs, "/gitops/"+svcPath+"/base/config/100-deployment.yaml", &deployment)
+	if ns := deployment["metadata"].(map[string]interface{})["namespace"]; ns != "tst-stage" {
+		t.Fatalf("deployment namespace got %q, want %q", ns, "tst-stage")
+	}
+	// the image in the overlay is promoted.
+	overlay := res.Kustomization{}
+	readYAML(t, fakeFs, "/gitops/"+svcPath+"/overlays/kustomization.yaml", &overlay)
+	if diff := cmp.Diff([]res.Image{{Name: "nginxinc/nginx-unprivileged", NewName: "quay.io/example/http-api", NewTag: "v1.1.0"}}, overlay.Images); diff != "" {
+		t.Fatalf("Promote() failed to promote the image:\n%s", diff)
+	}
+	assertFileExists(t, fakeFs, "/gitops/environments/tst-stage/apps/app-http-api/kustomization.yaml", true)
+	assertFileExists(t, fakeFs, "/gitops/config/argocd/tst-stage-app-http-api-app.yaml", true)
+
+	// Promoting again updates the existing service in place, and removes
+	// files that are no longer in the source environment.
+	stalePath := "/gitops/" + svcPath + "/base/config/400-stale.yaml"
+	assertNoError(t, afero.WriteFile(fakeFs, stalePath, []byte("kind: ConfigMap\n"), 0644))
+	promoted, err = Promote(&PromoteOptions{
+		FromEnvName:         "tst-dev",
+		ToEnvName:           "tst-stage",
+		PipelinesFolderPath: "/gitops",
+		ServiceName:         "http-api",
+	}, fakeFs)
+	assertNoError(t, err)
+	if diff := cmp.Diff([]string{svcPath + "/base/config/400-stale.yaml"}, promoted.Removed); diff != "" {
+		t.Fatalf("Promote() removed files failed:\n%s", diff)
+	}
+	m, err = config.LoadManifest(fakeFs, "/gitops")
+	assertNoError(t, err)
+	if l := len(m.GetApplication("tst-stage", "app-http-api").Services); l != 1 {
+		t.Fatalf("got %d services in the target environment, want 1", l)
+	}
+	assertFileExists(t, fakeFs, stalePath, false)
+}
+
+func TestPromoteErrors(t *testing.T) {
+	fakeFs := bootstrapPromoteFixture(t)
+
+	promoteTests := []struct {
+		name    string
+		options *PromoteOptions
+		errMsg  string
+	}{
+		{"same environment", &PromoteOptions{FromEnvName: "tst-dev", ToEnvName: "tst-dev", ServiceName: "http-api"}, "to itself"},
+		{"missing source environment", &PromoteOptions{FromEnvName: "tst-qa", ToEnvName: "tst-stage", ServiceName: "http-api"}, "environment tst-qa does not exist"},
+		{"missing target environment", &PromoteOptions{FromEnvName: "tst-dev", ToEnvName: "tst-qa", ServiceName: "http-api"}, "environment tst-qa does not exist"},
+		{"missing service", &PromoteOptions{FromEnvName: "tst-dev", ToEnvName: "tst-stage", ServiceName: "unknown"}, "service unknown does not exist in environment tst-dev"},
+		{"missing application", &PromoteOptions{AppName: "unknown", FromEnvName: "tst-dev", ToEnvName: "tst-stage", ServiceName: "http-api"}, "service http-api does not exist in application unknown"},
+	}
+
+	for _, tt := range promoteTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			tt.options.PipelinesFolderPath = "/gitops"
+			_, err := Promote(tt.options, fakeFs)
+			test.AssertErrorMatch(rt, tt.errMsg, err)
+		})
+	}
+}
+
+func TestFindServiceApplication(t *testing.T) {
+	env := &config.Environment{
+		Name: "dev",
+		Apps: []*config.Application{
+			{Name: "app-1", Services: []*config.Service{{Name: "svc"}}},
+			{Name: "app-2", Services: []*config.Service{{Name: "svc"}}},
+		},
+	}
+
+	_, err := findServiceApplication(env, "", "svc")
+	test.AssertErrorMatch(t, "more than one application in environment dev \\(app-1, app-2\\)", err)
+
+	app, err := findServiceApplication(env, "app-2", "svc")
+	assertNoError(t, err)
+	if app.Name != "app-2" {
+		t.Fatalf("got application %q, want %q", app.Name, "app-2")
+	}
+}
+
+func TestMoveNamespace(t *testing.T) {
+	nsTests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"source namespace", "metadata:\n  name: test\n  namespace: dev\n", "metadata:\n  name: test\n  namespace: stage\n"},
+		{"other namespace", "metadata:\n  name: test\n  namespace: cicd\n", "metadata:\n  name: test\n  namespace: cicd\n"},
+		{"no metadata", "bases:\n- ./config\n", "bases:\n- ./config\n"},
+		{"quoted namespace", "metadata:\n  namespace: 'dev' # env\n", "metadata:\n  namespace: 'stage' # env\n"},
+		{"not yaml", "{{ .Values }}: [\n", "{{ .Values }}: [\n"},
+		{
+			"multiple documents",
+			"# the service\nmetadata:\n  namespace: dev\n  name: svc\n---\nkind: ConfigMap\nmetadata:\n  name: cm\n  namespace: cicd\n---\nmetadata:\n  namespace: \"dev\"\n  name: route\n",
+			"# the service\nmetadata:\n  namespace: stage\n  name: svc\n---\nkind: ConfigMap\nmetadata:\n  name: cm\n  namespace: cicd\n---\nmetadata:\n  namespace: \"stage\"\n  name: route\n",
+		},
+	}
+
+	for _, tt := range nsTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			got, err := moveNamespace([]byte(tt.body), "dev", "stage")
+			assertNoError(rt, err)
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				rt.Fatalf("moveNamespace() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPromotePullRequest(t *testing.T) {
+	fakeFs := bootstrapPromoteFixture(t)
+	fakeClientFactory, fakeData := newMockClientFactory(t, "test-token")
+	// the pipelines folder is in the gitops folder of the repository.
+	e := newMockExecutor([]byte("/repo\ngitops/\n"))
+	o := &PromoteOptions{
+		FromEnvName:         "tst-dev",
+		ToEnvName:           "tst-stage",
+		PipelinesFolderPath: "/gitops",
+		ServiceName:         "http-api",
+	}
+	promoted := &PromoteResult{
+		Written: []string{"environments/tst-stage/env.yaml", "pipelines.yaml"},
+		Removed: []string{"environments/tst-stage/stale.yaml"},
+	}
+
+	_, err := PromotePullRequest(o, &PullRequestOptions{BaseBranch: "main", GitHostAccessToken: "test-token"}, promoted, fakeClientFactory, e, fakeFs)
+	assertNoError(t, err)
+
+	branch := "promote-http-api-tst-dev-to-tst-stage"
+	e.assertCommandsExecuted(t, []execution{
+		{BaseDir: "/gitops", Command: "git", Args: []string{"rev-parse", "--show-toplevel", "--show-prefix"}},
+		{BaseDir: "/repo", Command: "git", Args: []string{"checkout", "-b", branch}},
+		{BaseDir: "/repo", Command: "git", Args: []string{"add", "--", "gitops/environments/tst-stage/env.yaml", "gitops/pipelines.yaml"}},
+		{BaseDir: "/repo", Command: "git", Args: []string{"rm", "--cached", "--ignore-unmatch", "--quiet", "--", "gitops/environments/tst-stage/stale.yaml"}},
+		{BaseDir: "/repo", Command: "git", Args: []string{"commit", "-m", "Promote http-api from tst-dev to tst-stage"}},
+		{BaseDir: "/repo", Command: "git", Args: []string{"push", "-u", "origin", branch}},
+	})
+	want := map[int]*scm.PullRequestInput{
+		1: {
+			Title: "Promote http-api from tst-dev to tst-stage",
+			Head:  branch,
+			Base:  "main",
+			Body:  "Promotes the configuration of service http-api from environment tst-dev to environment tst-stage.",
+		},
+	}
+	if diff := cmp.Diff(want, fakeData.PullRequestsCreated); diff != "" {
+		t.Fatalf("pull request failed:\n%s", diff)
+	}
+}
+
+func TestRepositoryRoot(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	assertNoError(t, err)
+	pipelinesFolder := filepath.Join(root, "gitops")
+	assertNoError(t, os.Mkdir(pipelinesFolder, 0755))
+	e := cmdExecutor{}
+	if out, err := e.execute(root, "git", "init", "--quiet"); err != nil {
+		t.Fatalf("git init failed: %s: %s", out, err)
+	}
+
+	dir, prefix, err := repositoryRoot(e, pipelinesFolder)
+	assertNoError(t, err)
+	if diff := cmp.Diff([]string{root, "gitops/"}, []string{dir, prefix}); diff != "" {
+		t.Fatalf("repositoryRoot() failed:\n%s", diff)
+	}
+}
+
+func TestPromotePullRequestWithStoredToken(t *testing.T) {
+	keyring.MockInit()
+	assertNoError(t, accesstoken.SetAccessToken(testGitOpsRepo, "stored-token"))
+	fakeFs := bootstrapPromoteFixture(t)
+	fakeClientFactory, fakeData := newMockClientFactory(t, "stored-token")
+	o := &PromoteOptions{
+		FromEnvName:         "tst-dev",
+		ToEnvName:           "tst-stage",
+		PipelinesFolderPath: "/gitops",
+		ServiceName:         "http-api",
+	}
+
+	_, err := PromotePullRequest(o, &PullRequestOptions{Branch: "promote", BaseBranch: "main"}, &PromoteResult{Written: []string{"pipelines.yaml"}}, fakeClientFactory, newMockExecutor([]byte("/gitops\n\n")), fakeFs)
+	assertNoError(t, err)
+	if pr := fakeData.PullRequestsCreated[1]; pr == nil || pr.Head != "promote" {
+		t.Fatalf("pull request failed: %#v", pr)
+	}
+}
+
+func bootstrapPromoteFixture(t *testing.T) afero.Fs {
+	t.Helper()
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, Bootstrap(&BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+	}, fakeFs))
+	return fakeFs
+}
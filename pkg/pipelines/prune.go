@@ -0,0 +1,228 @@
+package pipelines
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+// generatedFilesPath is the index of the files that kam generates from the
+// manifest, relative to the root of the GitOps repository.
+const generatedFilesPath = ".kam/generated-files.yaml"
+
+// generatedFiles is the index of the files that were generated from the
+// manifest, this is used to find the files that are no longer generated when
+// services, applications or environments are removed from the manifest.
+type generatedFiles struct {
+	Files []string `json:"files"`
+}
+
+// readGeneratedFiles returns the paths recorded in the index below root, a
+// missing index is treated as an empty one.
+func readGeneratedFiles(fs afero.Fs, root string) ([]string, error) {
+	root, err := homedir.Expand(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	body, err := afero.ReadFile(fs, filepath.Join(root, generatedFilesPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read the generated files index: %w", err)
+	}
+	index := generatedFiles{}
+	if err := sigsyaml.Unmarshal(body, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse the generated files index %s: %w", generatedFilesPath, err)
+	}
+	for _, f := range index.Files {
+		if err := checkPathBelowRoot(root, f); err != nil {
+			return nil, fmt.Errorf("invalid path in the generated files index %s: %w", generatedFilesPath, err)
+		}
+	}
+	return index.Files, nil
+}
+
+// checkPathBelowRoot returns an error if the path isn't relative, or if it
+// isn't below root once it's joined to it.
+func checkPathBelowRoot(root, p string) error {
+	p = filepath.FromSlash(p)
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return fmt.Errorf("%s is not a relative path", p)
+	}
+	rel, err := filepath.Rel(root, filepath.Join(root, p))
+	if err != nil {
+		return fmt.Errorf("%s is not below %s: %w", p, root, err)
+	}
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is not below %s", p, root)
+	}
+	return nil
+}
+
+// staleFiles returns the previously generated paths that are not in the built
+// resources, sorted.
+func staleFiles(previous []string, built res.Resources) []string {
+	stale := []string{}
+	for _, p := range previous {
+		if _, ok := built[filepath.FromSlash(p)]; ok {
+			continue
+		}
+		if _, ok := built[p]; ok {
+			continue
+		}
+		stale = append(stale, p)
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// generatedFilesIndex returns a resource for the index of the built files,
+// along with any extra paths that should stay in the index.
+func generatedFilesIndex(built res.Resources, extra ...string) res.Resources {
+	seen := map[string]bool{}
+	files := []string{}
+	add := func(p string) {
+		p = filepath.ToSlash(p)
+		if p == generatedFilesPath || seen[p] {
+			return
+		}
+		seen[p] = true
+		files = append(files, p)
+	}
+	for k := range built {
+		add(k)
+	}
+	for _, k := range extra {
+		add(k)
+	}
+	sort.Strings(files)
+	return res.Resources{generatedFilesPath: generatedFiles{Files: files}}
+}
+
+// updateGeneratedFilesIndex returns the index of the built files, keeping the
+// paths from the existing index below root.
+//
+// This is used by commands that only add to the manifest, so that files which
+// are no longer generated can still be pruned by a later build.
+func updateGeneratedFilesIndex(fs afero.Fs, root string, built res.Resources) (res.Resources, error) {
+	previous, err := readGeneratedFiles(fs, root)
+	if err != nil {
+		return nil, err
+	}
+	return generatedFilesIndex(built, previous...), nil
+}
+
+// pruneFiles removes the files or directories below root, and any directories
+// left empty by removing them.
+func pruneFiles(fs afero.Fs, root string, files []string) error {
+	root, err := homedir.Expand(root)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	for _, f := range files {
+		if err := checkPathBelowRoot(root, f); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", f, err)
+		}
+		filename := filepath.Join(root, filepath.FromSlash(f))
+		if err := fs.RemoveAll(filename); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", f, err)
+		}
+		if err := removeEmptyParents(fs, root, filepath.Dir(filename)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeEmptyParents(fs afero.Fs, root, dir string) error {
+	prefix := filepath.Clean(root)
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	for dir = filepath.Clean(dir); strings.HasPrefix(dir, prefix); dir = filepath.Dir(dir) {
+		exists, err := afero.DirExists(fs, dir)
+		if err != nil {
+			return fmt.Errorf("failed to check directory %s: %w", dir, err)
+		}
+		if !exists {
+			continue
+		}
+		empty, err := afero.IsEmpty(fs, dir)
+		if err != nil {
+			return fmt.Errorf("failed to check directory %s: %w", dir, err)
+		}
+		if !empty {
+			return nil
+		}
+		if err := fs.Remove(dir); err != nil {
+			return fmt.Errorf("failed to remove directory %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// rebuildAfterRemoval writes the manifest and the resources built from it,
+// after something was removed from the manifest.
+//
+// The files that were built before the removal but are no longer built are
+// removed, along with the owned paths, which are relative to root, these are
+// the files that are not built from the manifest, e.g. the service's
+// configuration and sealed webhook secret. The secret files are the
+// unencrypted secrets, relative to the secrets folder next to root.
+func rebuildAfterRemoval(fs afero.Fs, root string, m *config.Manifest, before res.Resources, owned, secretFiles []string) error {
+	after, err := buildResources(fs, m)
+	if err != nil {
+		return fmt.Errorf("failed to build resources: %v", err)
+	}
+	removed := append(staleFiles(getResourceFiles(before), after), owned...)
+	if err := pruneFiles(fs, root, removed); err != nil {
+		return err
+	}
+	if err := pruneFiles(fs, filepath.Join(root, "..", "secrets"), secretFiles); err != nil {
+		return err
+	}
+	previous, err := readGeneratedFiles(fs, root)
+	if err != nil {
+		return err
+	}
+	if err := addCICDKustomization(fs, root, m.GetPipelinesConfig(), after, removed); err != nil {
+		return err
+	}
+	files := res.Merge(generatedFilesIndex(after, without(previous, removed)...), after)
+	files[pipelinesFile] = m
+	if _, err := yaml.WriteResources(fs, root, files); err != nil {
+		return err
+	}
+	return updateSOPSGenerator(fs, root, m.GetPipelinesConfig())
+}
+
+// without returns the paths that are not in, or below, any of the removed
+// paths.
+func without(paths, removed []string) []string {
+	result := []string{}
+	for _, p := range paths {
+		keep := true
+		for _, r := range removed {
+			r = filepath.ToSlash(r)
+			if p == r || strings.HasPrefix(p, r+"/") {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, p)
+		}
+	}
+	return result
+}
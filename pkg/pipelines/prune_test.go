@@ -0,0 +1,201 @@
+package pipelines
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+	"github.com/spf13/afero"
+)
+
+func TestStaleFiles(t *testing.T) {
+	built := res.Resources{
+		"environments/dev/env/base/kustomization.yaml": "",
+	}
+	previous := []string{
+		"environments/stage/env/base/kustomization.yaml",
+		"environments/dev/env/base/kustomization.yaml",
+		"config/argocd/stage-app-app.yaml",
+	}
+	want := []string{
+		"config/argocd/stage-app-app.yaml",
+		"environments/stage/env/base/kustomization.yaml",
+	}
+	if diff := cmp.Diff(want, staleFiles(previous, built)); diff != "" {
+		t.Fatalf("staleFiles() failed:\n%s", diff)
+	}
+}
+
+func TestGeneratedFilesIndex(t *testing.T) {
+	built := res.Resources{
+		"b.yaml": "",
+		"a.yaml": "",
+	}
+	want := res.Resources{
+		generatedFilesPath: generatedFiles{Files: []string{"a.yaml", "b.yaml", "c.yaml"}},
+	}
+	if diff := cmp.Diff(want, generatedFilesIndex(built, "c.yaml", "a.yaml")); diff != "" {
+		t.Fatalf("generatedFilesIndex() failed:\n%s", diff)
+	}
+}
+
+func TestPruneFiles(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, fakeFs.WriteFile("/repo/environments/stage/env/base/kustomization.yaml", []byte("{}"), 0644))
+	assertNoError(t, fakeFs.WriteFile("/repo/environments/dev/env/base/kustomization.yaml", []byte("{}"), 0644))
+
+	assertNoError(t, pruneFiles(fakeFs, "/repo", []string{"environments/stage/env/base/kustomization.yaml", "missing.yaml"}))
+
+	assertFileExists(t, fakeFs, "/repo/environments/stage", false)
+	assertFileExists(t, fakeFs, "/repo/environments/dev/env/base/kustomization.yaml", true)
+}
+
+func TestPruneFilesOutsideRoot(t *testing.T) {
+	pathTests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/etc/passwd", "failed to remove /etc/passwd: /etc/passwd is not a relative path"},
+		{"../outside.yaml", "failed to remove ../outside.yaml: ../outside.yaml is not below /repo"},
+		{"environments/../../outside.yaml", "failed to remove environments/../../outside.yaml: environments/../../outside.yaml is not below /repo"},
+		{".", "failed to remove .: . is not below /repo"},
+	}
+
+	for _, tt := range pathTests {
+		t.Run(tt.path, func(rt *testing.T) {
+			fakeFs := ioutils.NewMemoryFilesystem()
+			assertNoError(rt, fakeFs.WriteFile("/outside.yaml", []byte("{}"), 0644))
+			assertNoError(rt, fakeFs.WriteFile("/repo/pipelines.yaml", []byte("{}"), 0644))
+
+			err := pruneFiles(fakeFs, "/repo", []string{tt.path})
+			if err == nil || err.Error() != tt.wantErr {
+				rt.Fatalf("pruneFiles() got error %v, want %s", err, tt.wantErr)
+			}
+			assertFileExists(rt, fakeFs, "/outside.yaml", true)
+			assertFileExists(rt, fakeFs, "/repo/pipelines.yaml", true)
+		})
+	}
+}
+
+func TestReadGeneratedFilesOutsideRoot(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	_, err := yaml.WriteResources(fakeFs, "/repo", res.Resources{
+		generatedFilesPath: generatedFiles{Files: []string{"environments/dev/env/base/kustomization.yaml", "../outside.yaml"}},
+	})
+	assertNoError(t, err)
+
+	_, err = readGeneratedFiles(fakeFs, "/repo")
+	want := "invalid path in the generated files index .kam/generated-files.yaml: ../outside.yaml is not below /repo"
+	if err == nil || err.Error() != want {
+		t.Fatalf("readGeneratedFiles() got error %v, want %s", err, want)
+	}
+}
+
+func TestAddCICDKustomization(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	cfg := &config.PipelinesConfig{Name: "cicd"}
+	_, err := yaml.WriteResources(fakeFs, "/repo", res.Resources{
+		"config/cicd/base/kustomization.yaml": res.Kustomization{Resources: []string{"01-namespaces/cicd-environment.yaml", "05-bindings/github-pr-binding.yaml", "05-bindings/old-binding.yaml"}},
+	})
+	assertNoError(t, err)
+	// files that aren't in the kustomization, or being written, aren't added.
+	assertNoError(t, fakeFs.WriteFile("/repo/config/cicd/base/stray.yaml", []byte("{}"), 0644))
+	files := res.Resources{
+		"config/cicd/base/05-bindings/gitlab-pr-binding.yaml": "",
+		"config/cicd/base/04-tasks/s2i-task.yaml":             "",
+		"environments/dev/env/base/kustomization.yaml":        "",
+	}
+
+	assertNoError(t, addCICDKustomization(fakeFs, "/repo", cfg, files, []string{"config/cicd/base/05-bindings/old-binding.yaml"}))
+
+	want := res.Kustomization{Resources: []string{
+		"01-namespaces/cicd-environment.yaml",
+		"04-tasks/s2i-task.yaml",
+		"05-bindings/github-pr-binding.yaml",
+		"05-bindings/gitlab-pr-binding.yaml",
+	}}
+	if diff := cmp.Diff(want, files["config/cicd/base/kustomization.yaml"]); diff != "" {
+		t.Fatalf("addCICDKustomization() failed:\n%s", diff)
+	}
+}
+
+func TestBuildResourcesPrune(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	writeManifest := func(envs ...string) {
+		m := &config.Manifest{GitOpsURL: "https://github.com/org/gitops.git"}
+		for _, env := range envs {
+			m.Environments = append(m.Environments, &config.Environment{Name: env})
+		}
+		_, err := yaml.WriteResources(fakeFs, "/", res.Resources{pipelinesFile: m})
+		assertNoError(t, err)
+	}
+	writeManifest("test-dev", "test-stage")
+	stale, err := BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/"}, fakeFs)
+	assertNoError(t, err)
+	if len(stale) != 0 {
+		t.Fatalf("BuildResources() reported stale files on the first build: %v", stale)
+	}
+	stageKustomization := "/environments/test-stage/env/base/kustomization.yaml"
+	assertFileExists(t, fakeFs, stageKustomization, true)
+
+	writeManifest("test-dev")
+	stale, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/", DryRun: true}, fakeFs)
+	assertNoError(t, err)
+	if !hasPrefixes(stale, "environments/test-stage/") {
+		t.Fatalf("BuildResources() dry-run got stale files %v", stale)
+	}
+	assertFileExists(t, fakeFs, stageKustomization, true)
+
+	_, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/"}, fakeFs)
+	assertNoError(t, err)
+	assertFileExists(t, fakeFs, stageKustomization, true)
+	previous, err := readGeneratedFiles(fakeFs, "/")
+	assertNoError(t, err)
+	if !contains(previous, strings.TrimPrefix(stageKustomization, "/")) {
+		t.Fatalf("BuildResources() without prune dropped the stale files from the index: %v", previous)
+	}
+
+	_, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/", Prune: true}, fakeFs)
+	assertNoError(t, err)
+	assertFileExists(t, fakeFs, "/environments/test-stage", false)
+	assertFileExists(t, fakeFs, "/environments/test-dev/env/base/kustomization.yaml", true)
+	stale, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/", OutputPath: "/", DryRun: true}, fakeFs)
+	assertNoError(t, err)
+	if len(stale) != 0 {
+		t.Fatalf("BuildResources() after pruning got stale files %v", stale)
+	}
+}
+
+func contains(files []string, s string) bool {
+	for _, f := range files {
+		if f == s {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPrefixes(files []string, prefix string) bool {
+	if len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		if !strings.HasPrefix(f, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func assertFileExists(t *testing.T, fs afero.Fs, path string, want bool) {
+	t.Helper()
+	exists, err := afero.Exists(fs, path)
+	assertNoError(t, err)
+	if exists != want {
+		t.Fatalf("%s exists = %v, want %v", path, exists, want)
+	}
+}
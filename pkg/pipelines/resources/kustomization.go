@@ -4,15 +4,72 @@ import "sort"
 
 // Kustomization is a structural representation of the Kustomize file format.
 type Kustomization struct {
-	Resources    []string          `json:"resources,omitempty"`
-	Bases        []string          `json:"bases,omitempty"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Resources  []string `json:"resources,omitempty"`
+	Components []string `json:"components,omitempty"`
+	// Bases is deprecated, the bases are resources.
+	Bases []string `json:"bases,omitempty"`
+	// CommonLabels is deprecated, use Labels with IncludeSelectors.
 	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	Labels       []Label           `json:"labels,omitempty"`
+	Patches      []Patch           `json:"patches,omitempty"`
+	Images       []Image           `json:"images,omitempty"`
+	Generators   []string          `json:"generators,omitempty"`
+}
+
+// Label is a set of labels that are added to all the resources, and
+// optionally to their selectors and templates.
+type Label struct {
+	Pairs            map[string]string `json:"pairs,omitempty"`
+	IncludeSelectors bool              `json:"includeSelectors,omitempty"`
+	IncludeTemplates bool              `json:"includeTemplates,omitempty"`
+}
+
+// Patch is a strategic merge patch, or JSON patch, either inline or in a file,
+// applied to the resources that match the target.
+type Patch struct {
+	Path   string         `json:"path,omitempty"`
+	Patch  string         `json:"patch,omitempty"`
+	Target *PatchSelector `json:"target,omitempty"`
+}
+
+// PatchSelector selects the resources that a patch is applied to.
+type PatchSelector struct {
+	Group              string `json:"group,omitempty"`
+	Version            string `json:"version,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Namespace          string `json:"namespace,omitempty"`
+	LabelSelector      string `json:"labelSelector,omitempty"`
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+}
+
+// Image overrides the name, tag or digest of the images in the resources.
+type Image struct {
+	Name    string `json:"name,omitempty"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
 }
 
 func (k *Kustomization) AddResources(s ...string) {
 	k.Resources = removeDuplicatesAndSort(append(k.Resources, s...))
 }
 
+// Fix replaces the deprecated fields with their replacements, as kustomize edit
+// fix does, the bases are added to the resources, and the common labels are
+// added as labels that include the selectors, so the output is unchanged.
+func (k *Kustomization) Fix() {
+	if len(k.Bases) > 0 {
+		k.Resources = append(k.Resources, k.Bases...)
+		k.Bases = nil
+	}
+	if len(k.CommonLabels) > 0 {
+		k.Labels = append(k.Labels, Label{Pairs: k.CommonLabels, IncludeSelectors: true})
+		k.CommonLabels = nil
+	}
+}
+
 func removeDuplicatesAndSort(s []string) []string {
 	exists := make(map[string]bool)
 	out := []string{}
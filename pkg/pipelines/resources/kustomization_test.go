@@ -33,3 +33,20 @@ func Test_AddResource_sorts_elements(t *testing.T) {
 		t.Fatalf("failed to sort resources:\n%s", diff)
 	}
 }
+
+func TestFix(t *testing.T) {
+	k := Kustomization{
+		Resources:    []string{"deployment.yaml"},
+		Bases:        []string{"../base"},
+		CommonLabels: map[string]string{"app": "test"},
+	}
+	k.Fix()
+
+	want := Kustomization{
+		Resources: []string{"deployment.yaml", "../base"},
+		Labels:    []Label{{Pairs: map[string]string{"app": "test"}, IncludeSelectors: true}},
+	}
+	if diff := cmp.Diff(want, k); diff != "" {
+		t.Fatalf("failed to fix the kustomization:\n%s", diff)
+	}
+}
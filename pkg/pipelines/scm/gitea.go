@@ -0,0 +1,112 @@
+package scm
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
+)
+
+const (
+	giteaPushEventFilters = "(header.match('X-Gitea-Event', 'push') && body.repository.full_name == '%s')"
+	giteaPREventFilters   = "(header.match('X-Gitea-Event', 'pull_request') && body.action in ['opened', 'synchronized', 'reopened'] && body.repository.full_name == '%s')"
+	giteaType             = "gitea"
+
+	// Gitea signs webhook payloads with the GitHub compatible HMAC
+	// X-Hub-Signature headers, which are validated by the Tekton Triggers
+	// "github" interceptor.
+	giteaInterceptor = "github"
+)
+
+var (
+	giteaPROverlays = []triggersv1.CELOverlay{
+		{Key: "ref", Expression: "body.pull_request.head.ref"},
+	}
+)
+
+type giteaSpec struct {
+	pushBinding string
+	prBinding   string
+}
+
+func init() {
+	gits[giteaType] = newGitea
+}
+
+func newGitea(rawURL string) (Repository, error) {
+	path, err := processRawURL(rawURL, proccessGiteaPath)
+	if err != nil {
+		return nil, err
+	}
+	return &repository{url: rawURL, path: path, spec: &giteaSpec{pushBinding: "gitea-push-binding", prBinding: "gitea-pr-binding"}}, nil
+}
+
+func proccessGiteaPath(parsedURL *url.URL) (string, error) {
+	components, err := splitRepositoryPath(parsedURL)
+	if err != nil {
+		return "", err
+	}
+
+	if len(components) != 2 {
+		return "", invalidRepoPathError(giteaType, parsedURL.Path)
+	}
+	path := strings.Join(components, "/")
+	return path, nil
+}
+
+func (r *giteaSpec) pushBindingName() string {
+	return r.pushBinding
+}
+
+func (r *giteaSpec) pushBindingParams() []triggersv1.Param {
+	return []triggersv1.Param{
+		createBindingParam("gitrepositoryurl", "$(body.repository.clone_url)"),
+		createBindingParam("fullname", "$(body.repository.full_name)"),
+		createBindingParam(triggers.GitRef, "$(extensions.ref)"),
+		createBindingParam(triggers.GitCommitID, "$(body.after)"),
+		createBindingParam(triggers.GitCommitDate, "$(body.commits[0].timestamp)"),
+		createBindingParam(triggers.GitCommitMessage, "$(body.commits[0].message)"),
+		createBindingParam(triggers.GitCommitAuthor, "$(body.commits[0].author.name)"),
+	}
+}
+
+func (r *giteaSpec) pushEventFilters() string {
+	return giteaPushEventFilters
+}
+
+func (r *giteaSpec) pushEventOverlays() []triggersv1.CELOverlay {
+	return branchRefOverlay
+}
+
+func (r *giteaSpec) prBindingName() string {
+	return r.prBinding
+}
+
+func (r *giteaSpec) prBindingParams() []triggersv1.Param {
+	return []triggersv1.Param{
+		createBindingParam("gitrepositoryurl", "$(body.pull_request.head.repo.clone_url)"),
+		createBindingParam("fullname", "$(body.repository.full_name)"),
+		createBindingParam(triggers.GitRef, "$(extensions.ref)"),
+		createBindingParam(triggers.GitCommitID, "$(body.pull_request.head.sha)"),
+		createBindingParam(triggers.GitCommitDate, "$(body.pull_request.updated_at)"),
+		createBindingParam(triggers.GitCommitMessage, "$(body.pull_request.title)"),
+		createBindingParam(triggers.GitCommitAuthor, "$(body.pull_request.user.login)"),
+	}
+}
+
+func (r *giteaSpec) prEventFilters() string {
+	return giteaPREventFilters
+}
+
+func (r *giteaSpec) prEventOverlays() []triggersv1.CELOverlay {
+	return giteaPROverlays
+}
+
+func (r *giteaSpec) eventInterceptor(secretNamespace, secretName string) (*triggersv1.EventInterceptor, error) {
+	raw, err := secretParam(secretName, webhookSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return eventInterceptorWithSecret(giteaInterceptor, raw), nil
+}
@@ -10,11 +10,19 @@ import (
 
 const (
 	githubPushEventFilters = "(header.match('X-GitHub-Event', 'push') && body.repository.full_name == '%s')"
+	githubPREventFilters   = "(header.match('X-GitHub-Event', 'pull_request') && body.action in ['opened', 'synchronize', 'reopened'] && body.repository.full_name == '%s')"
 	githubType             = "github"
 )
 
+var (
+	githubPROverlays = []triggersv1.CELOverlay{
+		{Key: "ref", Expression: "body.pull_request.head.ref"},
+	}
+)
+
 type githubSpec struct {
 	pushBinding string
+	prBinding   string
 }
 
 func init() {
@@ -26,7 +34,7 @@ func newGitHub(rawURL string) (Repository, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &repository{url: rawURL, path: path, spec: &githubSpec{pushBinding: "github-push-binding"}}, nil
+	return &repository{url: rawURL, path: path, spec: &githubSpec{pushBinding: "github-push-binding", prBinding: "github-pr-binding"}}, nil
 }
 
 func proccessGitHubPath(parsedURL *url.URL) (string, error) {
@@ -62,6 +70,34 @@ func (r *githubSpec) pushEventFilters() string {
 	return githubPushEventFilters
 }
 
+func (r *githubSpec) pushEventOverlays() []triggersv1.CELOverlay {
+	return branchRefOverlay
+}
+
+func (r *githubSpec) prBindingName() string {
+	return r.prBinding
+}
+
+func (r *githubSpec) prBindingParams() []triggersv1.Param {
+	return []triggersv1.Param{
+		createBindingParam("gitrepositoryurl", "$(body.pull_request.head.repo.clone_url)"),
+		createBindingParam("fullname", "$(body.repository.full_name)"),
+		createBindingParam(triggers.GitRef, "$(extensions.ref)"),
+		createBindingParam(triggers.GitCommitID, "$(body.pull_request.head.sha)"),
+		createBindingParam(triggers.GitCommitDate, "$(body.pull_request.updated_at)"),
+		createBindingParam(triggers.GitCommitMessage, "$(body.pull_request.title)"),
+		createBindingParam(triggers.GitCommitAuthor, "$(body.pull_request.user.login)"),
+	}
+}
+
+func (r *githubSpec) prEventFilters() string {
+	return githubPREventFilters
+}
+
+func (r *githubSpec) prEventOverlays() []triggersv1.CELOverlay {
+	return githubPROverlays
+}
+
 func (r *githubSpec) eventInterceptor(secretNamespace, secretName string) (*triggersv1.EventInterceptor, error) {
 	raw, err := secretParam(secretName, webhookSecretKey)
 	if err != nil {
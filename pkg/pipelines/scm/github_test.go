@@ -67,7 +67,7 @@ func TestCreateCDTriggersForGithub(t *testing.T) {
 	assertNoError(t, err)
 	rawSecret, err := secretParam("secret", "webhook-secret-key")
 	assertNoError(t, err)
-	rawFilter, rawOverlays, err := celParams(githubPushEventFilters, "org/test")
+	rawFilter, rawOverlays, err := celParams(githubPushEventFilters, "org/test", branchRefOverlay)
 	assertNoError(t, err)
 	name := "test-template"
 	want := triggersv1.EventListenerTrigger{
@@ -167,3 +167,110 @@ func TestNewGitHubRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestCreatePRBindingForGithub(t *testing.T) {
+	repo, err := NewRepository("http://github.com/org/test")
+	assertNoError(t, err)
+	want := triggersv1.TriggerBinding{
+		TypeMeta: triggers.TriggerBindingTypeMeta,
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "github-pr-binding",
+			Namespace: "testns",
+		},
+		Spec: triggersv1.TriggerBindingSpec{
+			Params: []triggersv1.Param{
+				{
+					Name:  "gitrepositoryurl",
+					Value: "$(body.pull_request.head.repo.clone_url)",
+				},
+				{
+					Name:  "fullname",
+					Value: "$(body.repository.full_name)",
+				},
+				{
+					Name:  triggers.GitRef,
+					Value: "$(extensions.ref)",
+				},
+				{
+					Name:  triggers.GitCommitID,
+					Value: "$(body.pull_request.head.sha)",
+				},
+				{
+					Name:  triggers.GitCommitDate,
+					Value: "$(body.pull_request.updated_at)",
+				},
+				{
+					Name:  triggers.GitCommitMessage,
+					Value: "$(body.pull_request.title)",
+				},
+				{
+					Name:  triggers.GitCommitAuthor,
+					Value: "$(body.pull_request.user.login)",
+				},
+			},
+		},
+	}
+	got, name := repo.CreatePRBinding("testns")
+	if name != "github-pr-binding" {
+		t.Fatalf("CreatePRBinding() returned a wrong binding: want %v got %v", "github-pr-binding", name)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("CreatePRBinding() failed:\n%s", diff)
+	}
+}
+
+func TestCreatePRTriggerForGithub(t *testing.T) {
+	repo, err := NewRepository("http://github.com/org/test")
+	assertNoError(t, err)
+	rawSecret, err := secretParam("secret", "webhook-secret-key")
+	assertNoError(t, err)
+	rawFilter, rawOverlays, err := celParams(githubPREventFilters, "org/test", githubPROverlays)
+	assertNoError(t, err)
+	name := "test-template"
+	want := triggersv1.EventListenerTrigger{
+		Name: "test",
+		Bindings: []*triggersv1.EventListenerBinding{
+			{Ref: "test-binding"},
+		},
+		Template: &triggersv1.EventListenerTemplate{Ref: &name},
+		Interceptors: []*triggersv1.EventInterceptor{
+			&triggersv1.TriggerInterceptor{
+				Ref: triggersv1.InterceptorRef{
+					Name: "github",
+				},
+				Params: []triggersv1.InterceptorParams{
+					{
+						Name: "secretRef",
+						Value: apiextensionsv1.JSON{
+							Raw: rawSecret,
+						},
+					},
+				},
+			},
+			&triggersv1.TriggerInterceptor{
+				Ref: triggersv1.InterceptorRef{
+					Name: "cel",
+				},
+				Params: []triggersv1.InterceptorParams{
+					{
+						Name: "filter",
+						Value: apiextensionsv1.JSON{
+							Raw: rawFilter,
+						},
+					},
+					{
+						Name: "overlays",
+						Value: apiextensionsv1.JSON{
+							Raw: rawOverlays,
+						},
+					},
+				},
+			},
+		},
+	}
+	got, err := repo.CreatePRTrigger("test", "secret", "ns", "test-template", []string{"test-binding"})
+	assertNoError(t, err)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("CreatePRTrigger() failed:\n%s", diff)
+	}
+}
@@ -10,11 +10,19 @@ import (
 
 const (
 	gitlabPushEventFilters = "header.match('X-Gitlab-Event','Push Hook') && body.project.path_with_namespace == '%s'"
+	gitlabPREventFilters   = "header.match('X-Gitlab-Event','Merge Request Hook') && body.object_attributes.action in ['open', 'reopen', 'update'] && body.project.path_with_namespace == '%s'"
 	gitlabType             = "gitlab"
 )
 
+var (
+	gitlabPROverlays = []triggersv1.CELOverlay{
+		{Key: "ref", Expression: "body.object_attributes.source_branch"},
+	}
+)
+
 type gitlabSpec struct {
 	pushBinding string
+	prBinding   string
 }
 
 func init() {
@@ -26,7 +34,7 @@ func newGitLab(rawURL string) (Repository, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &repository{url: rawURL, path: path, spec: &gitlabSpec{pushBinding: "gitlab-push-binding"}}, nil
+	return &repository{url: rawURL, path: path, spec: &gitlabSpec{pushBinding: "gitlab-push-binding", prBinding: "gitlab-pr-binding"}}, nil
 }
 
 func proccessGitLabPath(parsedURL *url.URL) (string, error) {
@@ -61,6 +69,34 @@ func (r *gitlabSpec) pushEventFilters() string {
 	return gitlabPushEventFilters
 }
 
+func (r *gitlabSpec) pushEventOverlays() []triggersv1.CELOverlay {
+	return branchRefOverlay
+}
+
+func (r *gitlabSpec) prBindingName() string {
+	return r.prBinding
+}
+
+func (r *gitlabSpec) prBindingParams() []triggersv1.Param {
+	return []triggersv1.Param{
+		createBindingParam("gitrepositoryurl", "$(body.object_attributes.source.git_http_url)"),
+		createBindingParam("fullname", "$(body.project.path_with_namespace)"),
+		createBindingParam(triggers.GitRef, "$(extensions.ref)"),
+		createBindingParam(triggers.GitCommitID, "$(body.object_attributes.last_commit.id)"),
+		createBindingParam(triggers.GitCommitDate, "$(body.object_attributes.last_commit.timestamp)"),
+		createBindingParam(triggers.GitCommitMessage, "$(body.object_attributes.last_commit.message)"),
+		createBindingParam(triggers.GitCommitAuthor, "$(body.object_attributes.last_commit.author.name)"),
+	}
+}
+
+func (r *gitlabSpec) prEventFilters() string {
+	return gitlabPREventFilters
+}
+
+func (r *gitlabSpec) prEventOverlays() []triggersv1.CELOverlay {
+	return gitlabPROverlays
+}
+
 func (r *gitlabSpec) eventInterceptor(secretNamespace, secretName string) (*triggersv1.EventInterceptor, error) {
 	raw, err := secretParam(secretName, webhookSecretKey)
 	if err != nil {
@@ -68,7 +68,7 @@ func TestCreateCDTriggersForGitLab(t *testing.T) {
 	assertNoError(t, err)
 	rawSecret, err := secretParam("secret", "webhook-secret-key")
 	assertNoError(t, err)
-	rawFilter, rawOverlays, err := celParams(gitlabPushEventFilters, "org/test")
+	rawFilter, rawOverlays, err := celParams(gitlabPushEventFilters, "org/test", branchRefOverlay)
 	assertNoError(t, err)
 	name := "test-template"
 	want := triggersv1.EventListenerTrigger{
@@ -168,3 +168,110 @@ func TestNewGitlabRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestCreatePRBindingForGitLab(t *testing.T) {
+	repo, err := NewRepository("http://gitlab.com/org/test")
+	assertNoError(t, err)
+	want := triggersv1.TriggerBinding{
+		TypeMeta: triggers.TriggerBindingTypeMeta,
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "gitlab-pr-binding",
+			Namespace: "testns",
+		},
+		Spec: triggersv1.TriggerBindingSpec{
+			Params: []triggersv1.Param{
+				{
+					Name:  "gitrepositoryurl",
+					Value: "$(body.object_attributes.source.git_http_url)",
+				},
+				{
+					Name:  "fullname",
+					Value: "$(body.project.path_with_namespace)",
+				},
+				{
+					Name:  triggers.GitRef,
+					Value: "$(extensions.ref)",
+				},
+				{
+					Name:  triggers.GitCommitID,
+					Value: "$(body.object_attributes.last_commit.id)",
+				},
+				{
+					Name:  triggers.GitCommitDate,
+					Value: "$(body.object_attributes.last_commit.timestamp)",
+				},
+				{
+					Name:  triggers.GitCommitMessage,
+					Value: "$(body.object_attributes.last_commit.message)",
+				},
+				{
+					Name:  triggers.GitCommitAuthor,
+					Value: "$(body.object_attributes.last_commit.author.name)",
+				},
+			},
+		},
+	}
+	got, name := repo.CreatePRBinding("testns")
+	if name != "gitlab-pr-binding" {
+		t.Fatalf("CreatePRBinding() returned a wrong binding: want %v got %v", "gitlab-pr-binding", name)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("CreatePRBinding() failed:\n%s", diff)
+	}
+}
+
+func TestCreatePRTriggerForGitLab(t *testing.T) {
+	repo, err := NewRepository("http://gitlab.com/org/test")
+	assertNoError(t, err)
+	rawSecret, err := secretParam("secret", "webhook-secret-key")
+	assertNoError(t, err)
+	rawFilter, rawOverlays, err := celParams(gitlabPREventFilters, "org/test", gitlabPROverlays)
+	assertNoError(t, err)
+	name := "test-template"
+	want := triggersv1.EventListenerTrigger{
+		Name: "test",
+		Bindings: []*triggersv1.EventListenerBinding{
+			{Ref: "test-binding"},
+		},
+		Template: &triggersv1.EventListenerTemplate{Ref: &name},
+		Interceptors: []*triggersv1.EventInterceptor{
+			&triggersv1.TriggerInterceptor{
+				Ref: triggersv1.InterceptorRef{
+					Name: "gitlab",
+				},
+				Params: []triggersv1.InterceptorParams{
+					{
+						Name: "secretRef",
+						Value: apiextensionsv1.JSON{
+							Raw: rawSecret,
+						},
+					},
+				},
+			},
+			&triggersv1.TriggerInterceptor{
+				Ref: triggersv1.InterceptorRef{
+					Name: "cel",
+				},
+				Params: []triggersv1.InterceptorParams{
+					{
+						Name: "filter",
+						Value: apiextensionsv1.JSON{
+							Raw: rawFilter,
+						},
+					},
+					{
+						Name: "overlays",
+						Value: apiextensionsv1.JSON{
+							Raw: rawOverlays,
+						},
+					},
+				},
+			},
+		},
+	}
+	got, err := repo.CreatePRTrigger("test", "secret", "ns", "test-template", []string{"test-binding"})
+	assertNoError(t, err)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("CreatePRTrigger() failed:\n%s", diff)
+	}
+}
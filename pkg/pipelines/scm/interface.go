@@ -16,6 +16,15 @@ type Repository interface {
 	// Create an eventlistener trigger for Push event
 	CreatePushTrigger(name, secretName, secretNs, template string, bindings []string) (triggersv1.EventListenerTrigger, error)
 
+	// Get Pull Request TriggerBinding name for this repository provider
+	PRBindingName() string
+
+	// Create a TriggerBinding for Pull Request hooks
+	CreatePRBinding(namespace string) (triggersv1.TriggerBinding, string)
+
+	// Create an eventlistener trigger for Pull Request events
+	CreatePRTrigger(name, secretName, secretNs, template string, bindings []string) (triggersv1.EventListenerTrigger, error)
+
 	// Git Repository URL
 	URL() string
 }
@@ -23,8 +23,13 @@ type repository struct {
 type triggerSpec interface {
 	pushBindingParams() []triggersv1.Param
 	pushEventFilters() string
+	pushEventOverlays() []triggersv1.CELOverlay
 	eventInterceptor(secretNamespace, secretName string) (*triggersv1.EventInterceptor, error)
 	pushBindingName() string
+	prBindingParams() []triggersv1.Param
+	prEventFilters() string
+	prEventOverlays() []triggersv1.CELOverlay
+	prBindingName() string
 }
 
 // NewRepository returns a suitable Repository instance
@@ -43,15 +48,21 @@ func NewRepository(url string) (Repository, error) {
 	return git(url)
 }
 
+// IsSupportedDriver returns true if there is a Repository implementation for
+// the named go-scm driver.
+func IsSupportedDriver(name string) bool {
+	_, ok := gits[name]
+	return ok
+}
+
 // CreatePushBinding implements the Repository interface.
 func (r *repository) CreatePushBinding(ns string) (triggersv1.TriggerBinding, string) {
-	return triggersv1.TriggerBinding{
-		TypeMeta:   triggers.TriggerBindingTypeMeta,
-		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, r.spec.pushBindingName())),
-		Spec: triggersv1.TriggerBindingSpec{
-			Params: r.spec.pushBindingParams(),
-		},
-	}, r.spec.pushBindingName()
+	return createBinding(ns, r.spec.pushBindingName(), r.spec.pushBindingParams()), r.spec.pushBindingName()
+}
+
+// CreatePRBinding implements the Repository interface.
+func (r *repository) CreatePRBinding(ns string) (triggersv1.TriggerBinding, string) {
+	return createBinding(ns, r.spec.prBindingName(), r.spec.prBindingParams()), r.spec.prBindingName()
 }
 
 // CreatePushTrigger implements the Repository interface.
@@ -61,7 +72,18 @@ func (r *repository) CreatePushTrigger(name, secretName, secretNS, template stri
 		return triggersv1.EventListenerTrigger{}, err
 	}
 	return r.createTrigger(name, r.spec.pushEventFilters(),
-		template, bindings,
+		r.spec.pushEventOverlays(), template, bindings,
+		eventInterceptorForCEL)
+}
+
+// CreatePRTrigger implements the Repository interface.
+func (r *repository) CreatePRTrigger(name, secretName, secretNS, template string, bindings []string) (triggersv1.EventListenerTrigger, error) {
+	eventInterceptorForCEL, err := r.spec.eventInterceptor(secretNS, secretName)
+	if err != nil {
+		return triggersv1.EventListenerTrigger{}, err
+	}
+	return r.createTrigger(name, r.spec.prEventFilters(),
+		r.spec.prEventOverlays(), template, bindings,
 		eventInterceptorForCEL)
 }
 
@@ -75,8 +97,13 @@ func (r *repository) PushBindingName() string {
 	return r.spec.pushBindingName()
 }
 
-func (r *repository) createTrigger(name, filters, template string, bindings []string, interceptor *triggersv1.EventInterceptor) (triggersv1.EventListenerTrigger, error) {
-	eventInterceptor, err := createEventInterceptor(filters, r.path)
+// PRBindingName returns the name of the pull request binding.
+func (r *repository) PRBindingName() string {
+	return r.spec.prBindingName()
+}
+
+func (r *repository) createTrigger(name, filters string, overlays []triggersv1.CELOverlay, template string, bindings []string, interceptor *triggersv1.EventInterceptor) (triggersv1.EventListenerTrigger, error) {
+	eventInterceptor, err := createEventInterceptor(filters, r.path, overlays)
 	if err != nil {
 		return triggersv1.EventListenerTrigger{}, err
 	}
@@ -90,3 +117,13 @@ func (r *repository) createTrigger(name, filters, template string, bindings []st
 		Template: createListenerTemplate(&template),
 	}, nil
 }
+
+func createBinding(ns, name string, params []triggersv1.Param) triggersv1.TriggerBinding {
+	return triggersv1.TriggerBinding{
+		TypeMeta:   triggers.TriggerBindingTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, name)),
+		Spec: triggersv1.TriggerBindingSpec{
+			Params: params,
+		},
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm/factory"
 )
 
 func TestNewRepositoryGitHub(t *testing.T) {
@@ -41,6 +42,39 @@ func TestNewRepositoryForInvalidRepoType(t *testing.T) {
 	}
 }
 
+func TestNewRepositoryForGogs(t *testing.T) {
+	defer func(f factory.HostDriverIdentifier) {
+		factory.DefaultIdentifier = f
+	}(factory.DefaultIdentifier)
+	factory.DefaultIdentifier = factory.NewDriverIdentifier(factory.Mapping("gogs.example.com", "gogs"))
+
+	_, err := NewRepository("https://gogs.example.com/org/test.git")
+	if err != ErrGogsUnsupported {
+		t.Fatalf("NewRepository() got error %v, want %v", err, ErrGogsUnsupported)
+	}
+}
+
+func TestIsSupportedDriver(t *testing.T) {
+	driverTests := []struct {
+		driver string
+		want   bool
+	}{
+		{"github", true},
+		{"gitlab", true},
+		{"stash", true},
+		{"gitea", true},
+		{"bitbucket", false},
+		{"gogs", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range driverTests {
+		if got := IsSupportedDriver(tt.driver); got != tt.want {
+			t.Errorf("IsSupportedDriver(%q) got %v, want %v", tt.driver, got, tt.want)
+		}
+	}
+}
+
 func assertNoError(t *testing.T, err error) {
 	t.Helper()
 	if err != nil {
@@ -0,0 +1,122 @@
+package scm
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
+)
+
+const (
+	stashPushEventFilters = "header.match('X-Event-Key', 'repo:refs_changed') && (body.repository.project.key + '/' + body.repository.slug).lowerAscii() == '%s'"
+	stashPREventFilters   = "(header.match('X-Event-Key', 'pr:opened') || header.match('X-Event-Key', 'pr:from_ref_updated')) && (body.pullRequest.toRef.repository.project.key + '/' + body.pullRequest.toRef.repository.slug).lowerAscii() == '%s'"
+	stashType             = "stash"
+
+	// Bitbucket Server webhooks are validated by the Tekton Triggers
+	// "bitbucket" interceptor.
+	stashInterceptor = "bitbucket"
+)
+
+var (
+	stashPushOverlays = []triggersv1.CELOverlay{
+		{Key: "ref", Expression: "body.changes[0].ref.displayId"},
+		{Key: "clone_url", Expression: "body.repository.links.clone.filter(l, l.name == 'http')[0].href"},
+	}
+	stashPROverlays = []triggersv1.CELOverlay{
+		{Key: "ref", Expression: "body.pullRequest.fromRef.displayId"},
+		{Key: "clone_url", Expression: "body.pullRequest.fromRef.repository.links.clone.filter(l, l.name == 'http')[0].href"},
+	}
+)
+
+type stashSpec struct {
+	pushBinding string
+	prBinding   string
+}
+
+func init() {
+	gits[stashType] = newStash
+}
+
+func newStash(rawURL string) (Repository, error) {
+	path, err := processRawURL(rawURL, proccessStashPath)
+	if err != nil {
+		return nil, err
+	}
+	return &repository{url: rawURL, path: path, spec: &stashSpec{pushBinding: "stash-push-binding", prBinding: "stash-pr-binding"}}, nil
+}
+
+// Bitbucket Server clone URLs are of the form
+// https://bitbucket.example.com/scm/<project>/<repo>.git, the webhook payload
+// identifies the repository by project key and slug.
+func proccessStashPath(parsedURL *url.URL) (string, error) {
+	components, err := splitRepositoryPath(parsedURL)
+	if err != nil {
+		return "", err
+	}
+	if components[0] == "scm" {
+		components = components[1:]
+	}
+	if len(components) != 2 {
+		return "", invalidRepoPathError(stashType, parsedURL.Path)
+	}
+	path := strings.ToLower(strings.Join(components, "/"))
+	return path, nil
+}
+
+func (r *stashSpec) pushBindingName() string {
+	return r.pushBinding
+}
+
+func (r *stashSpec) pushBindingParams() []triggersv1.Param {
+	return []triggersv1.Param{
+		createBindingParam("gitrepositoryurl", "$(extensions.clone_url)"),
+		createBindingParam("fullname", "$(body.repository.project.key)/$(body.repository.slug)"),
+		createBindingParam(triggers.GitRef, "$(extensions.ref)"),
+		createBindingParam(triggers.GitCommitID, "$(body.changes[0].toHash)"),
+		createBindingParam(triggers.GitCommitDate, "$(body.date)"),
+		// The refs_changed payload doesn't carry the commit message.
+		createBindingParam(triggers.GitCommitMessage, "Push to $(body.changes[0].ref.displayId)"),
+		createBindingParam(triggers.GitCommitAuthor, "$(body.actor.displayName)"),
+	}
+}
+
+func (r *stashSpec) pushEventFilters() string {
+	return stashPushEventFilters
+}
+
+func (r *stashSpec) pushEventOverlays() []triggersv1.CELOverlay {
+	return stashPushOverlays
+}
+
+func (r *stashSpec) prBindingName() string {
+	return r.prBinding
+}
+
+func (r *stashSpec) prBindingParams() []triggersv1.Param {
+	return []triggersv1.Param{
+		createBindingParam("gitrepositoryurl", "$(extensions.clone_url)"),
+		createBindingParam("fullname", "$(body.pullRequest.toRef.repository.project.key)/$(body.pullRequest.toRef.repository.slug)"),
+		createBindingParam(triggers.GitRef, "$(extensions.ref)"),
+		createBindingParam(triggers.GitCommitID, "$(body.pullRequest.fromRef.latestCommit)"),
+		createBindingParam(triggers.GitCommitDate, "$(body.date)"),
+		createBindingParam(triggers.GitCommitMessage, "$(body.pullRequest.title)"),
+		createBindingParam(triggers.GitCommitAuthor, "$(body.actor.displayName)"),
+	}
+}
+
+func (r *stashSpec) prEventFilters() string {
+	return stashPREventFilters
+}
+
+func (r *stashSpec) prEventOverlays() []triggersv1.CELOverlay {
+	return stashPROverlays
+}
+
+func (r *stashSpec) eventInterceptor(secretNamespace, secretName string) (*triggersv1.EventInterceptor, error) {
+	raw, err := secretParam(secretName, webhookSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return eventInterceptorWithSecret(stashInterceptor, raw), nil
+}
@@ -0,0 +1,169 @@
+package scm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreatePushBindingForStash(t *testing.T) {
+	repo, err := newStash("https://bitbucket.example.com/scm/org/test.git")
+	assertNoError(t, err)
+	want := triggersv1.TriggerBinding{
+		TypeMeta: triggers.TriggerBindingTypeMeta,
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "stash-push-binding",
+			Namespace: "testns",
+		},
+		Spec: triggersv1.TriggerBindingSpec{
+			Params: []triggersv1.Param{
+				{
+					Name:  "gitrepositoryurl",
+					Value: "$(extensions.clone_url)",
+				},
+				{
+					Name:  "fullname",
+					Value: "$(body.repository.project.key)/$(body.repository.slug)",
+				},
+				{
+					Name:  triggers.GitRef,
+					Value: "$(extensions.ref)",
+				},
+				{
+					Name:  triggers.GitCommitID,
+					Value: "$(body.changes[0].toHash)",
+				},
+				{
+					Name:  triggers.GitCommitDate,
+					Value: "$(body.date)",
+				},
+				{
+					Name:  triggers.GitCommitMessage,
+					Value: "Push to $(body.changes[0].ref.displayId)",
+				},
+				{
+					Name:  triggers.GitCommitAuthor,
+					Value: "$(body.actor.displayName)",
+				},
+			},
+		},
+	}
+	got, name := repo.CreatePushBinding("testns")
+	if name != "stash-push-binding" {
+		t.Fatalf("CreatePushBinding() returned a wrong binding: want %v got %v", "stash-push-binding", name)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("CreatePushBinding() failed:\n%s", diff)
+	}
+}
+
+func TestCreateCDTriggersForStash(t *testing.T) {
+	repo, err := newStash("https://bitbucket.example.com/scm/ORG/test.git")
+	assertNoError(t, err)
+	rawSecret, err := secretParam("secret", "webhook-secret-key")
+	assertNoError(t, err)
+	rawFilter, rawOverlays, err := celParams(stashPushEventFilters, "org/test", stashPushOverlays)
+	assertNoError(t, err)
+	name := "test-template"
+	want := triggersv1.EventListenerTrigger{
+		Name: "test",
+		Bindings: []*triggersv1.EventListenerBinding{
+			{Ref: "test-binding"},
+		},
+		Template: &triggersv1.EventListenerTemplate{Ref: &name},
+		Interceptors: []*triggersv1.EventInterceptor{
+			&triggersv1.TriggerInterceptor{
+				Ref: triggersv1.InterceptorRef{
+					Name: "bitbucket",
+				},
+				Params: []triggersv1.InterceptorParams{
+					{
+						Name: "secretRef",
+						Value: apiextensionsv1.JSON{
+							Raw: rawSecret,
+						},
+					},
+				},
+			},
+			&triggersv1.TriggerInterceptor{
+				Ref: triggersv1.InterceptorRef{
+					Name: "cel",
+				},
+				Params: []triggersv1.InterceptorParams{
+					{
+						Name: "filter",
+						Value: apiextensionsv1.JSON{
+							Raw: rawFilter,
+						},
+					},
+					{
+						Name: "overlays",
+						Value: apiextensionsv1.JSON{
+							Raw: rawOverlays,
+						},
+					},
+				},
+			},
+		},
+	}
+	got, err := repo.CreatePushTrigger("test", "secret", "ns", "test-template", []string{"test-binding"})
+	assertNoError(t, err)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("CreateCDTrigger() failed:\n%s", diff)
+	}
+}
+
+func TestNewStashRepository(t *testing.T) {
+	tests := []struct {
+		url      string
+		repoPath string
+		errMsg   string
+	}{
+		{
+			"https://bitbucket.example.com",
+			"",
+			"invalid repository URL https://bitbucket.example.com: path is empty",
+		},
+		{
+			"https://bitbucket.example.com/scm/foo",
+			"",
+			"invalid repository path for stash: /scm/foo",
+		},
+		{
+			"https://bitbucket.example.com/scm/foo/bar/baz.git",
+			"",
+			"invalid repository path for stash: /scm/foo/bar/baz.git",
+		},
+		{
+			"https://bitbucket.example.com/scm/foo/bar.git",
+			"foo/bar",
+			"",
+		},
+		{
+			"https://bitbucket.example.com/scm/FOO/bar.git",
+			"foo/bar",
+			"",
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("Test %d", i), func(rt *testing.T) {
+			repo, err := newStash(tt.url)
+			if err != nil {
+				if diff := cmp.Diff(tt.errMsg, err.Error()); diff != "" {
+					rt.Fatalf("repo path errMsg mismatch: \n%s", diff)
+				}
+			}
+			if repo != nil {
+				if diff := cmp.Diff(tt.repoPath, repo.(*repository).path); diff != "" {
+					rt.Fatalf("repo path mismatch: got\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package scm
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
@@ -11,7 +12,14 @@ import (
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 )
 
+const gogsType = "gogs"
+
 var (
+	// ErrGogsUnsupported is returned for Gogs repositories, Gogs signs webhook
+	// payloads with an X-Gogs-Signature header, which none of the Tekton
+	// Triggers interceptors can validate, so there is no Gogs driver.
+	ErrGogsUnsupported = errors.New("unsupported Git repository type: gogs, Gogs webhook signatures can't be validated by Tekton Triggers, use Gitea instead")
+
 	branchRefOverlay = []triggersv1.CELOverlay{
 		{Key: "ref", Expression: "body.ref.split('/')[2]"},
 	}
@@ -22,6 +30,9 @@ func invalidRepoPathError(gitType, path string) error {
 }
 
 func unsupportedGitTypeError(gitType string) error {
+	if gitType == gogsType {
+		return ErrGogsUnsupported
+	}
 	return fmt.Errorf("unsupported Git repository type: %s", gitType)
 }
 
@@ -29,8 +40,8 @@ func invalidRepoURLError(repoURL, reason string) error {
 	return fmt.Errorf("invalid repository URL %s: %s", repoURL, reason)
 }
 
-func createEventInterceptor(filter, repoName string) (*triggersv1.EventInterceptor, error) {
-	rawFilter, rawOverlays, err := celParams(filter, repoName)
+func createEventInterceptor(filter, repoName string, overlays []triggersv1.CELOverlay) (*triggersv1.EventInterceptor, error) {
+	rawFilter, rawOverlays, err := celParams(filter, repoName, overlays)
 	if err != nil {
 		return nil, err
 	}
@@ -134,12 +145,12 @@ func secretParam(name, key string) ([]byte, error) {
 	})
 }
 
-func celParams(filter, repoName string) ([]byte, []byte, error) {
+func celParams(filter, repoName string, overlays []triggersv1.CELOverlay) ([]byte, []byte, error) {
 	rawFilter, err := json.Marshal(fmt.Sprintf(filter, repoName))
 	if err != nil {
 		return nil, nil, err
 	}
-	rawOverlays, err := json.Marshal(branchRefOverlay)
+	rawOverlays, err := json.Marshal(overlays)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -33,7 +33,7 @@ func TestCreateListenerTemplate(t *testing.T) {
 func TestCreateEventInterceptor(t *testing.T) {
 	filter := "sampleFilter %s"
 	repo := "sample"
-	rawFilter, rawOverlays, err := celParams(filter, repo)
+	rawFilter, rawOverlays, err := celParams(filter, repo, branchRefOverlay)
 	assertNoError(t, err)
 	validEventInterceptor := triggersv1.EventInterceptor{
 		Ref: triggersv1.InterceptorRef{
@@ -54,7 +54,7 @@ func TestCreateEventInterceptor(t *testing.T) {
 			},
 		},
 	}
-	eventInterceptor, err := createEventInterceptor("sampleFilter %s", "sample")
+	eventInterceptor, err := createEventInterceptor("sampleFilter %s", "sample", branchRefOverlay)
 	assertNoError(t, err)
 	if diff := cmp.Diff(validEventInterceptor, *eventInterceptor); diff != "" {
 		t.Fatalf("createEventInterceptor() failed:\n%s", diff)
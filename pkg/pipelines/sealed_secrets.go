@@ -0,0 +1,69 @@
+package pipelines
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
+)
+
+// sealedSecretsPath is the folder in the pipelines base that sealed secrets
+// are written to.
+const sealedSecretsPath = "03-secrets"
+
+// loadSealedSecretsCert returns the public key from the Sealed Secrets
+// certificate at the path.
+func loadSealedSecretsCert(fs afero.Fs, certPath string) (secrets.PublicKeyFunc, error) {
+	filename, err := homedir.Expand(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate path to file: %v", err)
+	}
+	f, err := fs.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Sealed Secrets certificate %#v: %s", filename, err)
+	}
+	defer f.Close()
+	return secrets.PublicKeyFromCert(f)
+}
+
+// sealSecrets seals the unsealed secrets with the public key from the
+// certificate.
+//
+// It returns the sealed secrets keyed by their path relative to the GitOps
+// repository, and their filenames relative to the pipelines base, for the
+// kustomization.
+func sealSecrets(fs afero.Fs, certPath string, cfg *config.PipelinesConfig, unsealed res.Resources) (res.Resources, []string, error) {
+	if cfg == nil {
+		return nil, nil, fmt.Errorf("failed to seal secrets: no pipelines configuration found")
+	}
+	publicKey, err := loadSealedSecretsCert(fs, certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	sealed := res.Resources{}
+	filenames := []string{}
+	for k, v := range unsealed {
+		secret, ok := v.(*corev1.Secret)
+		if !ok {
+			return nil, nil, fmt.Errorf("failed to seal %s: not a secret", k)
+		}
+		sealedSecret, err := secrets.CreateSealedSecret(secret, publicKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		filename := sealedSecretFilename(secret.Name)
+		sealed[filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg), "base", filename))] = sealedSecret
+		filenames = append(filenames, filename)
+	}
+	return sealed, filenames, nil
+}
+
+func sealedSecretFilename(name string) string {
+	return filepath.ToSlash(filepath.Join(sealedSecretsPath, name+".yaml"))
+}
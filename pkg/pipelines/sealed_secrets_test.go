@@ -0,0 +1,211 @@
+package pipelines
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
+	"github.com/redhat-developer/kam/test"
+)
+
+const testCertPath = "/certs/sealed-secrets.pem"
+
+func TestBootstrapWithSealedSecrets(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	writeSealedSecretsCert(t, fakeFs, testCertPath)
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		GitHostAccessToken:   "test-token",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SealedSecretsCert:    testCertPath,
+	}
+	assertNoError(t, Bootstrap(params, fakeFs))
+
+	exists, err := afero.DirExists(fakeFs, "/secrets")
+	assertNoError(t, err)
+	if exists {
+		t.Fatal("unsealed secrets were written to the secrets folder")
+	}
+
+	wantSecrets := []string{
+		"03-secrets/git-host-access-token.yaml",
+		"03-secrets/git-host-basic-auth-token.yaml",
+		"03-secrets/gitops-webhook-secret.yaml",
+		"03-secrets/webhook-secret-tst-dev-http-api.yaml",
+	}
+	base := "/gitops/config/tst-cicd/base"
+	for _, f := range wantSecrets {
+		sealed := secrets.SealedSecret{}
+		readYAML(t, fakeFs, filepath.Join(base, f), &sealed)
+		if sealed.Kind != "SealedSecret" || len(sealed.Spec.EncryptedData) == 0 {
+			t.Fatalf("%s is not a sealed secret: %#v", f, sealed)
+		}
+	}
+	k := res.Kustomization{}
+	readYAML(t, fakeFs, filepath.Join(base, Kustomize), &k)
+	for _, f := range wantSecrets {
+		if !contains(k.Resources, f) {
+			t.Errorf("base kustomization is missing %s: %v", f, k.Resources)
+		}
+	}
+}
+
+func TestBootstrapWithSealedSecretsAndCosignKey(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	writeSealedSecretsCert(t, fakeFs, testCertPath)
+	assertNoError(t, afero.WriteFile(fakeFs, "/keys/cosign.key", []byte("encrypted key"), 0600))
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SealedSecretsCert:    testCertPath,
+		CosignKey:            "/keys/cosign.key",
+		CosignPassword:       "secret",
+		SupplyChain:          true,
+	}
+	assertNoError(t, Bootstrap(params, fakeFs))
+
+	exists, err := afero.DirExists(fakeFs, "/secrets")
+	assertNoError(t, err)
+	if exists {
+		t.Fatal("unsealed secrets were written to the secrets folder")
+	}
+	wantSecrets := []config.Secret{
+		{Name: "cosign-key", Namespace: "tst-cicd"},
+		{Name: "signing-secrets", Namespace: "openshift-pipelines"},
+	}
+	base := "/gitops/config/tst-cicd/base"
+	k := res.Kustomization{}
+	readYAML(t, fakeFs, filepath.Join(base, Kustomize), &k)
+	for _, want := range wantSecrets {
+		f := sealedSecretFilename(want.Name)
+		sealed := secrets.SealedSecret{}
+		readYAML(t, fakeFs, filepath.Join(base, f), &sealed)
+		if sealed.Kind != "SealedSecret" || len(sealed.Spec.EncryptedData) == 0 {
+			t.Fatalf("%s is not a sealed secret: %#v", f, sealed)
+		}
+		if diff := cmp.Diff(want, config.Secret{Name: sealed.Name, Namespace: sealed.Namespace}); diff != "" {
+			t.Fatalf("sealed secret failed:\n%s", diff)
+		}
+		if !contains(k.Resources, f) {
+			t.Errorf("base kustomization is missing %s: %v", f, k.Resources)
+		}
+	}
+}
+
+func TestBootstrapWithMissingSealedSecretsCert(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SealedSecretsCert:    testCertPath,
+	}
+	err := Bootstrap(params, fakeFs)
+	test.AssertErrorMatch(t, "failed to read Sealed Secrets certificate", err)
+
+	exists, err := afero.Exists(fakeFs, "/gitops/pipelines.yaml")
+	assertNoError(t, err)
+	if exists {
+		t.Fatal("resources were written with an invalid certificate")
+	}
+}
+
+func TestAddServiceWithSealedSecrets(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	writeSealedSecretsCert(t, fakeFs, testCertPath)
+	assertNoError(t, Bootstrap(&BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SealedSecretsCert:    testCertPath,
+	}, fakeFs))
+
+	assertNoError(t, AddService(&AddServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "tst-dev",
+		GitRepoURL:          "http://github.com/org/new-svc",
+		PipelinesFolderPath: "/gitops",
+		WebhookSecret:       "123",
+		ServiceName:         "new-svc",
+		ImageRepo:           "quay.io/org/new-svc",
+		SealedSecretsCert:   testCertPath,
+	}, fakeFs))
+
+	sealedPath := "03-secrets/webhook-secret-tst-dev-new-svc.yaml"
+	base := "/gitops/config/tst-cicd/base"
+	sealed := secrets.SealedSecret{}
+	readYAML(t, fakeFs, filepath.Join(base, sealedPath), &sealed)
+	want := config.Secret{Name: "webhook-secret-tst-dev-new-svc", Namespace: "tst-cicd"}
+	if diff := cmp.Diff(want, config.Secret{Name: sealed.Name, Namespace: sealed.Namespace}); diff != "" {
+		t.Fatalf("sealed secret failed:\n%s", diff)
+	}
+	assertFileExists(t, fakeFs, "/secrets/webhook-secret-tst-dev-new-svc.yaml", false)
+	k := res.Kustomization{}
+	readYAML(t, fakeFs, filepath.Join(base, Kustomize), &k)
+	if !contains(k.Resources, sealedPath) {
+		t.Fatalf("base kustomization is missing %s: %v", sealedPath, k.Resources)
+	}
+
+	_, err := RemoveService(&RemoveServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "tst-dev",
+		PipelinesFolderPath: "/gitops",
+		ServiceName:         "new-svc",
+	}, fakeFs)
+	assertNoError(t, err)
+	assertFileExists(t, fakeFs, filepath.Join(base, sealedPath), false)
+}
+
+func writeSealedSecretsCert(t *testing.T, fs afero.Fs, path string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assertNoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assertNoError(t, err)
+	assertNoError(t, afero.WriteFile(fs, path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+}
+
+func readYAML(t *testing.T, fs afero.Fs, path string, v interface{}) {
+	t.Helper()
+	body, err := afero.ReadFile(fs, path)
+	assertNoError(t, err)
+	assertNoError(t, sigsyaml.Unmarshal(body, v))
+}
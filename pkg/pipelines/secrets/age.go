@@ -0,0 +1,47 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// AgeRecipient is an age X25519 public key, encoded as an "age1..." string.
+type AgeRecipient = age.X25519Recipient
+
+// ParseAgeRecipient parses an age X25519 recipient, in the "age1..." format.
+func ParseAgeRecipient(s string) (*AgeRecipient, error) {
+	return age.ParseX25519Recipient(s)
+}
+
+// ageEncrypt encrypts the plaintext to the recipients in the age format,
+// and returns it ASCII armored.
+func ageEncrypt(plaintext []byte, recipients ...*AgeRecipient) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no age recipients provided")
+	}
+	ageRecipients := []age.Recipient{}
+	for _, r := range recipients {
+		ageRecipients = append(ageRecipients, r)
+	}
+
+	buf := &bytes.Buffer{}
+	armored := armor.NewWriter(buf)
+	w, err := age.Encrypt(armored, ageRecipients...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %v", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %v", err)
+	}
+	if err := armored.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encrypt with age: %v", err)
+	}
+	return buf.Bytes(), nil
+}
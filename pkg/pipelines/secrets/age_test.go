@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/redhat-developer/kam/test"
+)
+
+func TestParseAgeRecipient(t *testing.T) {
+	_, recipient := generateAgeIdentity(t)
+
+	r, err := ParseAgeRecipient(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != recipient {
+		t.Fatalf("ParseAgeRecipient() got %s, want %s", r, recipient)
+	}
+}
+
+func TestParseAgeRecipientErrors(t *testing.T) {
+	identity, recipient := generateAgeIdentity(t)
+	recipientTests := []struct {
+		name      string
+		recipient string
+		errMsg    string
+	}{
+		{"no separator", "agexyz", "malformed recipient"},
+		{"invalid checksum", recipient[:len(recipient)-1] + "q", "malformed recipient"},
+		{"wrong type", identity.String(), "malformed recipient"},
+	}
+
+	for _, tt := range recipientTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			_, err := ParseAgeRecipient(tt.recipient)
+			test.AssertErrorMatch(rt, tt.errMsg, err)
+		})
+	}
+}
+
+func TestAgeEncrypt(t *testing.T) {
+	identity, recipient := generateAgeIdentity(t)
+	otherIdentity, otherRecipient := generateAgeIdentity(t)
+	recipients, err := ParseAgeRecipients(recipient + "," + otherRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// this is bigger than an age chunk of 64KiB.
+	plaintext := bytes.Repeat([]byte("testing"), 64*1024/4)
+
+	armored, err := ageEncrypt(plaintext, recipients...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(armored, []byte(armor.Header)) {
+		t.Fatalf("ageEncrypt() is not armored: %s", armored[:64])
+	}
+
+	for _, id := range []*age.X25519Identity{identity, otherIdentity} {
+		if diff := cmp.Diff(plaintext, ageDecrypt(t, id, armored)); diff != "" {
+			t.Fatalf("ageEncrypt() failed to round trip:\n%s", diff)
+		}
+	}
+}
+
+func TestAgeEncryptWithNoRecipients(t *testing.T) {
+	_, err := ageEncrypt([]byte("testing"))
+	test.AssertErrorMatch(t, "no age recipients provided", err)
+}
+
+// generateAgeIdentity returns an X25519 identity, and the age recipient for
+// it.
+func generateAgeIdentity(t *testing.T) (*age.X25519Identity, string) {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity, identity.Recipient().String()
+}
+
+// ageDecrypt decrypts an armored age file with the identity.
+func ageDecrypt(t *testing.T, identity age.Identity, armored []byte) []byte {
+	t.Helper()
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(armored)), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plaintext
+}
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+)
+
+const sessionKeyBytes = 32
+
+var (
+	sealedSecretTypeMeta = meta.TypeMeta("SealedSecret", "bitnami.com/v1alpha1")
+
+	// SealedSecretsService is the default name of the Sealed Secrets
+	// controller service.
+	SealedSecretsService = types.NamespacedName{Namespace: "kube-system", Name: "sealed-secrets-controller"}
+)
+
+// SealedSecret is a Bitnami Sealed Secret, which can only be decrypted by the
+// Sealed Secrets controller in the cluster.
+type SealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec SealedSecretSpec `json:"spec"`
+}
+
+// SealedSecretSpec is the specification of a SealedSecret.
+type SealedSecretSpec struct {
+	Template      SecretTemplateSpec `json:"template,omitempty"`
+	EncryptedData map[string]string  `json:"encryptedData"`
+}
+
+// SecretTemplateSpec describes the Secret that the controller will create
+// from a SealedSecret.
+type SecretTemplateSpec struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+// PublicKeyFromCert returns a PublicKeyFunc that returns the RSA public key
+// from the PEM encoded Sealed Secrets certificate, for any service.
+//
+// This allows sealing secrets without access to the cluster, the certificate
+// can be fetched with kubeseal --fetch-cert.
+func PublicKeyFromCert(in io.Reader) (PublicKeyFunc, error) {
+	data, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("failed to decode certificate: no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("failed to parse certificate: public key is not an RSA key")
+	}
+	return func(types.NamespacedName) (*rsa.PublicKey, error) {
+		return key, nil
+	}, nil
+}
+
+// CreateSealedSecret encrypts the data in the secret with the public key for
+// the Sealed Secrets service, the secret is sealed with the strict scope, so
+// it can only be unsealed with the same name and namespace.
+func CreateSealedSecret(secret *corev1.Secret, publicKey PublicKeyFunc) (*SealedSecret, error) {
+	key, err := publicKey(SealedSecretsService)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the public key for %s: %w", SealedSecretsService, err)
+	}
+	label := []byte(secret.Namespace + "/" + secret.Name)
+	encrypted := map[string]string{}
+	for k, v := range secretData(secret) {
+		ciphertext, err := hybridEncrypt(key, v, label)
+		if err != nil {
+			return nil, fmt.Errorf("failed to seal secret %s: %w", label, err)
+		}
+		encrypted[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return &SealedSecret{
+		TypeMeta:   sealedSecretTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(secret.Namespace, secret.Name)),
+		Spec: SealedSecretSpec{
+			Template: SecretTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secret.Name,
+					Namespace:   secret.Namespace,
+					Labels:      secret.Labels,
+					Annotations: secret.Annotations,
+				},
+				Type: secret.Type,
+			},
+			EncryptedData: encrypted,
+		},
+	}, nil
+}
+
+// secretData returns the data in the secret, with the StringData merged in,
+// as the API server does when the secret is written.
+func secretData(secret *corev1.Secret) map[string][]byte {
+	data := map[string][]byte{}
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+	for k, v := range secret.StringData {
+		data[k] = []byte(v)
+	}
+	return data
+}
+
+// hybridEncrypt encrypts the plaintext with a random AES-GCM session key,
+// which is encrypted with RSA-OAEP, in the format used by Sealed Secrets.
+func hybridEncrypt(key *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, sessionKeyBytes)
+	if _, err := io.ReadFull(rand.Reader, sessionKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, sessionKey, label)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, 2)
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(encryptedKey)))
+	ciphertext = append(ciphertext, encryptedKey...)
+	// the session key is only used once, so a zero nonce is safe.
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Seal(ciphertext, nonce, plaintext, nil), nil
+}
@@ -0,0 +1,177 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/test"
+)
+
+func TestCreateSealedSecret(t *testing.T) {
+	key := generateKey(t)
+	secret := createBasicAuthSecret(meta.NamespacedName("cicd", "github-auth"), testToken, meta.AddAnnotations(
+		map[string]string{
+			"tekton.dev/git-0": "https://github.com",
+		}),
+	)
+
+	sealed, err := CreateSealedSecret(secret, func(types.NamespacedName) (*rsa.PublicKey, error) {
+		return &key.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &SealedSecret{
+		TypeMeta: sealedSecretTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "github-auth",
+			Namespace: "cicd",
+		},
+		Spec: SealedSecretSpec{
+			Template: SecretTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "github-auth",
+					Namespace: "cicd",
+					Annotations: map[string]string{
+						"tekton.dev/git-0": "https://github.com",
+					},
+				},
+				Type: corev1.SecretTypeBasicAuth,
+			},
+		},
+	}
+	if diff := cmp.Diff(want, sealed, cmpopts.IgnoreFields(SealedSecretSpec{}, "EncryptedData")); diff != "" {
+		t.Fatalf("CreateSealedSecret() failed got\n%s", diff)
+	}
+
+	got := map[string]string{}
+	for k, v := range sealed.Spec.EncryptedData {
+		got[k] = string(unseal(t, key, v, "cicd/github-auth"))
+	}
+	wantData := map[string]string{
+		"username": "tekton",
+		"password": testToken,
+	}
+	if diff := cmp.Diff(wantData, got); diff != "" {
+		t.Fatalf("unsealed data failed got\n%s", diff)
+	}
+}
+
+func TestCreateSealedSecretWithPublicKeyError(t *testing.T) {
+	secret, err := createOpaqueSecret(meta.NamespacedName("cicd", "github-auth"), testToken, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = CreateSealedSecret(secret, func(types.NamespacedName) (*rsa.PublicKey, error) {
+		return nil, errors.New("test failure")
+	})
+	test.AssertErrorMatch(t, "failed to get the public key .* test failure", err)
+}
+
+func TestPublicKeyFromCert(t *testing.T) {
+	key := generateKey(t)
+	publicKey, err := PublicKeyFromCert(bytes.NewReader(generateCert(t, key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := publicKey(SealedSecretsService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PublicKey.Equal(got) {
+		t.Fatal("PublicKeyFromCert() returned a different public key")
+	}
+}
+
+func TestPublicKeyFromCertErrors(t *testing.T) {
+	certTests := []struct {
+		name   string
+		data   string
+		errMsg string
+	}{
+		{"no PEM data", "not a certificate", "no PEM encoded certificate found"},
+		{"wrong PEM type", "-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n", "no PEM encoded certificate found"},
+		{"invalid certificate", "-----BEGIN CERTIFICATE-----\nAAAA\n-----END CERTIFICATE-----\n", "failed to parse certificate"},
+	}
+
+	for _, tt := range certTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			_, err := PublicKeyFromCert(strings.NewReader(tt.data))
+			test.AssertErrorMatch(rt, tt.errMsg, err)
+		})
+	}
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func generateCert(t *testing.T, key *rsa.PrivateKey) []byte {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sealed-secret"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// unseal decrypts the value in the same way as the Sealed Secrets controller.
+func unseal(t *testing.T, key *rsa.PrivateKey, value, label string) []byte {
+	t.Helper()
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyLen := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+keyLen], []byte(label))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext[2+keyLen:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plaintext
+}
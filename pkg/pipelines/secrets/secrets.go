@@ -49,6 +49,20 @@ func CreateUnsealedBasicAuthSecret(name types.NamespacedName, token string,
 	return createBasicAuthSecret(name, token, opts...)
 }
 
+// CreateUnsealedCosignSecret creates a Secret with a cosign private key, and
+// its password, with the keys that cosign uses for Kubernetes Secrets.
+func CreateUnsealedCosignSecret(name types.NamespacedName, key []byte, password string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta:   secretTypeMeta,
+		ObjectMeta: meta.ObjectMeta(name),
+		Type:       corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"cosign.key":      key,
+			"cosign.password": []byte(password),
+		},
+	}
+}
+
 // createOpaqueSecret creates a Kubernetes v1/Secret with the provided name and
 // body, and type Opaque.
 func createOpaqueSecret(name types.NamespacedName, data, secretKey string) (*corev1.Secret, error) {
@@ -0,0 +1,258 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	yamlv2 "gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// SOPSEncryptedRegex is the regular expression for the keys that are
+	// encrypted in secrets, this leaves the metadata readable, which
+	// Kustomize and KSOPS need.
+	SOPSEncryptedRegex = "^(data|stringData)$"
+
+	// SOPSConfigFile is the name of the SOPS configuration file, which is
+	// looked up in the root of the GitOps repository.
+	SOPSConfigFile = ".sops.yaml"
+
+	sopsVersion    = "3.7.3"
+	sopsDataKeyLen = 32
+	sopsIVLen      = 32
+)
+
+// timeNow is used to timestamp SOPS encrypted files, it is replaced in tests.
+var timeNow = time.Now
+
+// SOPSMetadata is the sops section of a SOPS encrypted file.
+type SOPSMetadata struct {
+	Age            []SOPSAgeKey `json:"age"`
+	LastModified   string       `json:"lastmodified"`
+	MAC            string       `json:"mac"`
+	EncryptedRegex string       `json:"encrypted_regex,omitempty"`
+	Version        string       `json:"version"`
+}
+
+// SOPSAgeKey is the data key encrypted for an age recipient.
+type SOPSAgeKey struct {
+	Recipient        string `json:"recipient"`
+	EncryptedDataKey string `json:"enc"`
+}
+
+// SOPSConfig is the subset of the SOPS configuration file that is used to
+// find the age recipients for a file.
+type SOPSConfig struct {
+	CreationRules []SOPSCreationRule `json:"creation_rules"`
+}
+
+// SOPSCreationRule is a SOPS creation rule, the PathRegex is matched against
+// the path of the file relative to the configuration file.
+type SOPSCreationRule struct {
+	PathRegex string `json:"path_regex,omitempty"`
+	Age       string `json:"age,omitempty"`
+}
+
+// ParseAgeRecipients parses a list of age recipients, each value can contain
+// multiple comma separated recipients, as in SOPS configuration files.
+func ParseAgeRecipients(values ...string) ([]*AgeRecipient, error) {
+	recipients := []*AgeRecipient{}
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			r, err := ParseAgeRecipient(s)
+			if err != nil {
+				return nil, err
+			}
+			recipients = append(recipients, r)
+		}
+	}
+	return recipients, nil
+}
+
+// AgeRecipientsFromSOPSConfig returns the age recipients from the first
+// creation rule in the SOPS configuration that matches the path.
+func AgeRecipientsFromSOPSConfig(body []byte, path string) ([]*AgeRecipient, error) {
+	cfg := SOPSConfig{}
+	if err := yaml.Unmarshal(body, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", SOPSConfigFile, err)
+	}
+	path = filepath.ToSlash(path)
+	for _, rule := range cfg.CreationRules {
+		if rule.PathRegex != "" {
+			re, err := regexp.Compile(rule.PathRegex)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: invalid path_regex %q: %v", SOPSConfigFile, rule.PathRegex, err)
+			}
+			if !re.MatchString(path) {
+				continue
+			}
+		}
+		if rule.Age == "" {
+			return nil, fmt.Errorf("the %s creation rule for %s has no age recipients", SOPSConfigFile, path)
+		}
+		return ParseAgeRecipients(rule.Age)
+	}
+	return nil, fmt.Errorf("no %s creation rule matches %s", SOPSConfigFile, path)
+}
+
+// CreateSOPSSecret encrypts the data in the secret with SOPS, the data key is
+// encrypted for each of the age recipients.
+//
+// The data and stringData are encrypted, the rest of the secret is left in
+// plain text, but is covered by the MAC.
+func CreateSOPSSecret(secret *corev1.Secret, recipients []*AgeRecipient) (map[string]interface{}, error) {
+	body, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal secret: %v", err)
+	}
+	// The YAML is parsed into a MapSlice to walk the values in the same order
+	// that they're written out, and decrypted by SOPS, for the MAC.
+	doc := yamlv2.MapSlice{}
+	if err := yamlv2.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse secret: %v", err)
+	}
+	dataKey := make([]byte, sopsDataKeyLen)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	enc := &sopsEncrypter{
+		key:    dataKey,
+		regex:  regexp.MustCompile(SOPSEncryptedRegex),
+		digest: sha512.New(),
+	}
+	encrypted, err := enc.walkMap(doc, nil, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	lastModified := timeNow().UTC().Format(time.RFC3339)
+	mac, err := enc.encrypt(fmt.Sprintf("%X", enc.digest.Sum(nil)), "str", lastModified)
+	if err != nil {
+		return nil, err
+	}
+	metadata := SOPSMetadata{
+		LastModified:   lastModified,
+		MAC:            mac,
+		EncryptedRegex: SOPSEncryptedRegex,
+		Version:        sopsVersion,
+	}
+	for _, r := range recipients {
+		armored, err := ageEncrypt(dataKey, r)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Age = append(metadata.Age, SOPSAgeKey{Recipient: r.String(), EncryptedDataKey: string(armored)})
+	}
+	encrypted["sops"] = metadata
+	return encrypted, nil
+}
+
+// sopsEncrypter encrypts the values in a document, and calculates the MAC
+// over the plain text values, as SOPS does.
+type sopsEncrypter struct {
+	key    []byte
+	regex  *regexp.Regexp
+	digest hash.Hash
+}
+
+func (e *sopsEncrypter) walkMap(in yamlv2.MapSlice, path []string, encrypt bool) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, item := range in {
+		key := fmt.Sprint(item.Key)
+		if item.Value == nil {
+			continue
+		}
+		keyPath := append(append([]string{}, path...), key)
+		v, err := e.walk(item.Value, keyPath, encrypt || e.regex.MatchString(key))
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+func (e *sopsEncrypter) walk(in interface{}, path []string, encrypt bool) (interface{}, error) {
+	switch v := in.(type) {
+	case yamlv2.MapSlice:
+		return e.walkMap(v, path, encrypt)
+	case []interface{}:
+		out := []interface{}{}
+		for _, item := range v {
+			w, err := e.walk(item, path, encrypt)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, w)
+		}
+		return out, nil
+	}
+
+	plain, valueType, err := sopsValue(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", strings.Join(path, "."), err)
+	}
+	e.digest.Write([]byte(plain))
+	if !encrypt {
+		return in, nil
+	}
+	return e.encrypt(plain, valueType, strings.Join(path, ":")+":")
+}
+
+func (e *sopsEncrypter) encrypt(plain, valueType, additionalData string) (string, error) {
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, sopsIVLen)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, sopsIVLen)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, []byte(plain), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		valueType), nil
+}
+
+// sopsValue returns the plain text of a value, as SOPS encodes it, and its
+// SOPS type.
+func sopsValue(in interface{}) (string, string, error) {
+	switch v := in.(type) {
+	case string:
+		return v, "str", nil
+	case int:
+		return strconv.Itoa(v), "int", nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), "float", nil
+	case bool:
+		if v {
+			return "True", "bool", nil
+		}
+		return "False", "bool", nil
+	}
+	return "", "", fmt.Errorf("unsupported value type %T", in)
+}
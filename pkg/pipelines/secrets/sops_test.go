@@ -0,0 +1,360 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/google/go-cmp/cmp"
+	yamlv2 "gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/test"
+)
+
+var sopsValueRE = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+func TestCreateSOPSSecret(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time {
+		return time.Date(2021, time.March, 4, 10, 30, 0, 0, time.UTC)
+	}
+	identity, recipient := generateAgeIdentity(t)
+	recipients, err := ParseAgeRecipients(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := createBasicAuthSecret(meta.NamespacedName("cicd", "github-auth"), testToken, meta.AddAnnotations(
+		map[string]string{
+			"tekton.dev/git-0": "https://github.com",
+		}),
+	)
+
+	encrypted, err := CreateSOPSSecret(secret, recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The encrypted file is written out and read back, as SOPS would read it.
+	body, err := yaml.Marshal(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := yamlv2.MapSlice{}
+	if err := yamlv2.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	metadata := SOPSMetadata{}
+	if err := yaml.Unmarshal(body, &struct {
+		SOPS *SOPSMetadata `json:"sops"`
+	}{SOPS: &metadata}); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("2021-03-04T10:30:00Z", metadata.LastModified); diff != "" {
+		t.Fatalf("lastmodified failed:\n%s", diff)
+	}
+	if len(metadata.Age) != 1 || metadata.Age[0].Recipient != recipient {
+		t.Fatalf("age keys failed: %#v", metadata.Age)
+	}
+	dataKey := ageDecrypt(t, identity, []byte(metadata.Age[0].EncryptedDataKey))
+
+	digest := sha512.New()
+	decrypted := map[string]string{}
+	var walk func(in interface{}, path string)
+	walk = func(in interface{}, path string) {
+		switch v := in.(type) {
+		case yamlv2.MapSlice:
+			for _, item := range v {
+				if item.Key == "sops" {
+					continue
+				}
+				walk(item.Value, path+fmt.Sprint(item.Key)+":")
+			}
+		case string:
+			if m := sopsValueRE.FindStringSubmatch(v); m != nil {
+				v = sopsDecrypt(t, dataKey, m, path)
+			}
+			digest.Write([]byte(v))
+			decrypted[path] = v
+		default:
+			t.Fatalf("unexpected value %#v at %s", in, path)
+		}
+	}
+	walk(doc, "")
+
+	want := map[string]string{
+		"apiVersion:":                            "v1",
+		"kind:":                                  "Secret",
+		"metadata:annotations:tekton.dev/git-0:": "https://github.com",
+		"metadata:name:":                         "github-auth",
+		"metadata:namespace:":                    "cicd",
+		"stringData:password:":                   testToken,
+		"stringData:username:":                   "tekton",
+		"type:":                                  "kubernetes.io/basic-auth",
+	}
+	if diff := cmp.Diff(want, decrypted); diff != "" {
+		t.Fatalf("decrypted secret failed:\n%s", diff)
+	}
+	for _, k := range []string{"stringData:password:", "stringData:username:"} {
+		if plain := findValue(doc, k); sopsValueRE.FindStringSubmatch(plain) == nil {
+			t.Errorf("%s was not encrypted: %s", k, plain)
+		}
+	}
+	if plain := findValue(doc, "metadata:name:"); plain != "github-auth" {
+		t.Errorf("metadata was encrypted: %s", plain)
+	}
+
+	m := sopsValueRE.FindStringSubmatch(metadata.MAC)
+	if m == nil {
+		t.Fatalf("invalid mac %s", metadata.MAC)
+	}
+	if diff := cmp.Diff(fmt.Sprintf("%X", digest.Sum(nil)), sopsDecrypt(t, dataKey, m, metadata.LastModified)); diff != "" {
+		t.Fatalf("mac failed:\n%s", diff)
+	}
+}
+
+// The testdata was encrypted by sops, the values are decrypted, and the MAC
+// is recalculated in the same way as for CreateSOPSSecret.
+func TestSOPSSecretFromSOPS(t *testing.T) {
+	keyFile, err := os.Open("testdata/sops_age_key.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keyFile.Close()
+	identities, err := age.ParseIdentities(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := os.ReadFile("testdata/sops_secret.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := yamlv2.MapSlice{}
+	if err := yamlv2.Unmarshal(body, &doc); err != nil {
+		t.Fatal(err)
+	}
+	metadata := SOPSMetadata{}
+	if err := yaml.Unmarshal(body, &struct {
+		SOPS *SOPSMetadata `json:"sops"`
+	}{SOPS: &metadata}); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.EncryptedRegex != SOPSEncryptedRegex {
+		t.Fatalf("encrypted_regex got %q, want %q", metadata.EncryptedRegex, SOPSEncryptedRegex)
+	}
+	dataKey := ageDecrypt(t, identities[0], []byte(metadata.Age[0].EncryptedDataKey))
+
+	plain := sopsDecryptMap(t, dataKey, doc, "")
+	want := yamlv2.MapSlice{
+		{Key: "apiVersion", Value: "v1"},
+		{Key: "kind", Value: "Secret"},
+		{Key: "metadata", Value: yamlv2.MapSlice{
+			{Key: "name", Value: "github-auth"},
+			{Key: "namespace", Value: "cicd"},
+			{Key: "annotations", Value: yamlv2.MapSlice{{Key: "tekton.dev/git-0", Value: "https://github.com"}}},
+		}},
+		{Key: "type", Value: "kubernetes.io/basic-auth"},
+		{Key: "immutable", Value: true},
+		{Key: "data", Value: yamlv2.MapSlice{{Key: "token", Value: "dGVzdGluZw=="}}},
+		{Key: "stringData", Value: yamlv2.MapSlice{
+			{Key: "username", Value: "tekton"},
+			{Key: "password", Value: "testing"},
+		}},
+	}
+	if diff := cmp.Diff(want, plain); diff != "" {
+		t.Fatalf("decrypted secret failed:\n%s", diff)
+	}
+
+	enc := &sopsEncrypter{
+		key:    dataKey,
+		regex:  regexp.MustCompile(SOPSEncryptedRegex),
+		digest: sha512.New(),
+	}
+	if _, err := enc.walkMap(plain, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	m := sopsValueRE.FindStringSubmatch(metadata.MAC)
+	if m == nil {
+		t.Fatalf("invalid mac %s", metadata.MAC)
+	}
+	if diff := cmp.Diff(sopsDecrypt(t, dataKey, m, metadata.LastModified), fmt.Sprintf("%X", enc.digest.Sum(nil))); diff != "" {
+		t.Fatalf("mac failed:\n%s", diff)
+	}
+}
+
+// The secret created by CreateSOPSSecret is decrypted by sops, this is
+// skipped if sops is not installed.
+func TestCreateSOPSSecretDecryptsWithSOPS(t *testing.T) {
+	sops, err := exec.LookPath("sops")
+	if err != nil {
+		t.Skip("sops is not installed")
+	}
+	identity, recipient := generateAgeIdentity(t)
+	recipients, err := ParseAgeRecipients(recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := createBasicAuthSecret(meta.NamespacedName("cicd", "github-auth"), testToken)
+	encrypted, err := CreateSOPSSecret(secret, recipients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := yaml.Marshal(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "secret.yaml")
+	if err := os.WriteFile(filename, body, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(sops, "--decrypt", filename)
+	cmd.Env = append(os.Environ(), "SOPS_AGE_KEY="+identity.String())
+	decrypted, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("sops failed to decrypt: %v\n%s", err, body)
+	}
+	got := &corev1.Secret{}
+	if err := yaml.Unmarshal(decrypted, got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(secret, got); diff != "" {
+		t.Fatalf("sops decrypted secret failed:\n%s", diff)
+	}
+}
+
+func TestAgeRecipientsFromSOPSConfig(t *testing.T) {
+	_, cicdRecipient := generateAgeIdentity(t)
+	_, otherRecipient := generateAgeIdentity(t)
+	_, defaultRecipient := generateAgeIdentity(t)
+	cfg := fmt.Sprintf(`creation_rules:
+  - path_regex: config/cicd/.*\.yaml
+    encrypted_regex: ^(data|stringData)$
+    age: %s, %s
+  - age: %s
+`, cicdRecipient, otherRecipient, defaultRecipient)
+
+	configTests := []struct {
+		path string
+		want []string
+	}{
+		{"config/cicd/secrets/test.yaml", []string{cicdRecipient, otherRecipient}},
+		{"environments/dev/secret.yaml", []string{defaultRecipient}},
+	}
+
+	for _, tt := range configTests {
+		t.Run(tt.path, func(rt *testing.T) {
+			recipients, err := AgeRecipientsFromSOPSConfig([]byte(cfg), tt.path)
+			if err != nil {
+				rt.Fatal(err)
+			}
+			got := []string{}
+			for _, r := range recipients {
+				got = append(got, r.String())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				rt.Fatalf("AgeRecipientsFromSOPSConfig() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAgeRecipientsFromSOPSConfigErrors(t *testing.T) {
+	configTests := []struct {
+		name   string
+		cfg    string
+		errMsg string
+	}{
+		{"no matching rule", "creation_rules:\n  - path_regex: environments/.*\n    age: age1test\n", "no .sops.yaml creation rule matches"},
+		{"no age recipients", "creation_rules:\n  - pgp: ABCDEF\n", "has no age recipients"},
+		{"invalid regex", "creation_rules:\n  - path_regex: '('\n", "invalid path_regex"},
+		{"invalid recipient", "creation_rules:\n  - age: age1test\n", "malformed recipient"},
+	}
+
+	for _, tt := range configTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			_, err := AgeRecipientsFromSOPSConfig([]byte(tt.cfg), "config/cicd/secrets/test.yaml")
+			test.AssertErrorMatch(rt, tt.errMsg, err)
+		})
+	}
+}
+
+// sopsDecrypt decrypts a matched SOPS value with the data key, as SOPS does.
+func sopsDecrypt(t *testing.T, key []byte, m []string, additionalData string) string {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, sopsIVLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := gcm.Open(nil, decode(m[2]), append(decode(m[1]), decode(m[3])...), []byte(additionalData))
+	if err != nil {
+		t.Fatalf("failed to decrypt %s: %v", additionalData, err)
+	}
+	if m[4] != "str" {
+		t.Fatalf("unexpected type %s", m[4])
+	}
+	return string(plain)
+}
+
+// sopsDecryptMap decrypts the values in a SOPS encrypted document, and drops
+// the sops metadata.
+func sopsDecryptMap(t *testing.T, key []byte, in yamlv2.MapSlice, path string) yamlv2.MapSlice {
+	t.Helper()
+	out := yamlv2.MapSlice{}
+	for _, item := range in {
+		if path == "" && item.Key == "sops" {
+			continue
+		}
+		itemPath := path + fmt.Sprint(item.Key) + ":"
+		v := item.Value
+		switch value := v.(type) {
+		case yamlv2.MapSlice:
+			v = sopsDecryptMap(t, key, value, itemPath)
+		case string:
+			if m := sopsValueRE.FindStringSubmatch(value); m != nil {
+				v = sopsDecrypt(t, key, m, itemPath)
+			}
+		}
+		out = append(out, yamlv2.MapItem{Key: item.Key, Value: v})
+	}
+	return out
+}
+
+func findValue(doc yamlv2.MapSlice, path string) string {
+	for _, item := range doc {
+		key := fmt.Sprint(item.Key) + ":"
+		switch v := item.Value.(type) {
+		case yamlv2.MapSlice:
+			if len(path) > len(key) && path[:len(key)] == key {
+				return findValue(v, path[len(key):])
+			}
+		case string:
+			if path == key {
+				return v
+			}
+		}
+	}
+	return ""
+}
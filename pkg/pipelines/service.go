@@ -1,15 +1,17 @@
 package pipelines
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
-	"github.com/redhat-developer/kam/pkg/pipelines/environments"
 	"github.com/redhat-developer/kam/pkg/pipelines/eventlisteners"
 	"github.com/redhat-developer/kam/pkg/pipelines/imagerepo"
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/pipelines"
 	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
 	"github.com/redhat-developer/kam/pkg/pipelines/roles"
 	"github.com/redhat-developer/kam/pkg/pipelines/scm"
@@ -29,10 +31,23 @@ type AddServiceOptions struct {
 	PipelinesFolderPath string
 	ServiceName         string
 	WebhookSecret       string
+	SealedSecretsCert   string   // If set, the webhook secret is sealed with this Sealed Secrets certificate.
+	SOPS                bool     // If true, the webhook secret is encrypted with SOPS.
+	SOPSAgeRecipients   []string // The age recipients to encrypt the secret for, if empty they're read from the .sops.yaml in the pipelines folder.
+	PipelineTemplate    string   // The template in the catalog of pipeline templates that builds the service's image, defaults to buildah.
 }
 
 // AddService is the entry-point from the CLI for adding new services.
 func AddService(o *AddServiceOptions, appFs afero.Fs) error {
+	if o.SealedSecretsCert != "" && o.SOPS {
+		return errors.New("secrets can't be both sealed and encrypted with SOPS")
+	}
+	if o.PipelineTemplate != "" && o.GitRepoURL == "" {
+		return errors.New("a pipeline template can only be used for a service with a Git repository URL")
+	}
+	if _, err := pipelineTemplateTrigger(o.PipelineTemplate); err != nil {
+		return err
+	}
 	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
 	if err != nil {
 		return err
@@ -48,24 +63,102 @@ func AddService(o *AddServiceOptions, appFs afero.Fs) error {
 	}
 
 	files = res.Merge(cfgFiles, files)
+	if o.SealedSecretsCert != "" && len(otherResources) > 0 {
+		sealed, _, err := sealSecrets(appFs, o.SealedSecretsCert, m.GetPipelinesConfig(), otherResources)
+		if err != nil {
+			return err
+		}
+		files = res.Merge(sealed, files)
+		otherResources = res.Resources{}
+	}
+	if o.SOPS && len(otherResources) > 0 {
+		encrypted, err := encryptSOPSSecrets(appFs, o.PipelinesFolderPath, o.SOPSAgeRecipients, m.GetPipelinesConfig(), otherResources, files)
+		if err != nil {
+			return err
+		}
+		files = res.Merge(encrypted, files)
+		otherResources = res.Resources{}
+	}
 
+	if err := addCICDKustomization(appFs, o.PipelinesFolderPath, m.GetPipelinesConfig(), files, nil); err != nil {
+		return err
+	}
 	_, err = yaml.WriteResources(appFs, o.PipelinesFolderPath, files)
 	if err != nil {
 		return err
 	}
 	_, err = yaml.WriteResources(appFs, filepath.Join(o.PipelinesFolderPath, ".."), otherResources) // Don't call filepath.ToSlash
+	return err
+}
+
+// RemoveServiceOptions control how services are removed from the
+// configuration.
+type RemoveServiceOptions struct {
+	AppName             string
+	EnvName             string
+	PipelinesFolderPath string
+	ServiceName         string
+}
+
+// RemoveService removes a service from an environment, regenerates the
+// resources and removes the files that were generated for the service.
+//
+// It returns the removed service.
+func RemoveService(o *RemoveServiceOptions, appFs afero.Fs) (*config.Service, error) {
+	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	cfg := m.GetPipelinesConfig()
-	if cfg != nil {
-		base := filepath.ToSlash(filepath.Join(o.PipelinesFolderPath, config.PathForPipelines(cfg), "base"))
-		err = updateKustomization(appFs, base)
-		if err != nil {
-			return err
-		}
+	before, err := buildResources(appFs, m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resources: %v", err)
 	}
-	return nil
+	env := m.GetEnvironment(o.EnvName)
+	app := m.GetApplication(o.EnvName, o.AppName)
+	svc, err := m.RemoveService(o.EnvName, o.AppName, o.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	owned := []string{config.PathForService(app, env, svc.Name)}
+	if len(app.Services) == 0 {
+		owned = append(owned, config.PathForApplication(env, app))
+	}
+	owned = append(owned, serviceCICDFiles(m.GetPipelinesConfig(), env, app, svc)...)
+	if err := rebuildAfterRemoval(appFs, o.PipelinesFolderPath, m, before, owned, serviceSecretFiles(svc)); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+// serviceCICDFiles returns the paths of the files that were created for the
+// service's pipelines, relative to the pipelines folder.
+func serviceCICDFiles(cfg *config.PipelinesConfig, env *config.Environment, app *config.Application, svc *config.Service) []string {
+	if cfg == nil {
+		return nil
+	}
+	files := []string{
+		makeImageBindingPath(cfg, makeSvcImageBindingFilename(makeSvcImageBindingName(env.Name, app.Name, svc.Name))),
+		makeImageBindingPath(cfg, makeSvcImageBindingFilename(makeSvcCDBindingName(env.Name, app.Name, svc.Name))),
+	}
+	if svc.Webhook != nil && svc.Webhook.Secret != nil {
+		files = append(files,
+			filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg), "base", sealedSecretFilename(svc.Webhook.Secret.Name))),
+			filepath.ToSlash(filepath.Join(sopsSecretsDir(cfg), svc.Webhook.Secret.Name+".yaml")))
+	}
+	return files
+}
+
+// serviceSecretFiles returns the paths of the unencrypted secrets that were
+// created for the service, relative to the secrets folder next to the
+// pipelines folder.
+func serviceSecretFiles(svc *config.Service) []string {
+	if svc.Webhook == nil || svc.Webhook.Secret == nil {
+		return nil
+	}
+	return []string{svc.Webhook.Secret.Name + ".yaml"}
 }
 
 func serviceResources(m *config.Manifest, appFs afero.Fs, o *AddServiceOptions) (res.Resources, res.Resources, error) {
@@ -131,11 +224,26 @@ func serviceResources(m *config.Manifest, appFs afero.Fs, o *AddServiceOptions)
 			if err != nil {
 				return nil, nil, err
 			}
+			cdBindingName, _, cdBinding, err := createSvcCDBinding(m, cfg, env, &config.Application{Name: o.AppName}, o.ServiceName)
+			if err != nil {
+				return nil, nil, err
+			}
 
-			files = res.Merge(resources, files)
+			files = res.Merge(cdBinding, res.Merge(resources, files))
+			template, err := pipelineTemplateTrigger(o.PipelineTemplate)
+			if err != nil {
+				return nil, nil, err
+			}
+			sourceRepo, err := scm.NewRepository(o.GitRepoURL)
+			if err != nil {
+				return nil, nil, err
+			}
+			// the environment's bindings are the defaults if it only has stages.
+			envBindings := getPipelines(env, svc, sourceRepo).Integration.Bindings
 			svc.Pipelines = &config.Pipelines{
 				Integration: &config.TemplateBinding{
-					Bindings: append([]string{bindingName}, env.Pipelines.Integration.Bindings...),
+					Template: template,
+					Bindings: append([]string{bindingName, cdBindingName}, envBindings...),
 				},
 			}
 		}
@@ -155,7 +263,14 @@ func serviceResources(m *config.Manifest, appFs afero.Fs, o *AddServiceOptions)
 	if err != nil {
 		return nil, nil, err
 	}
-	return res.Merge(built, files), otherResources, nil
+	if err := keepOverlayImages(appFs, o.PipelinesFolderPath, m, built); err != nil {
+		return nil, nil, err
+	}
+	index, err := updateGeneratedFilesIndex(appFs, o.PipelinesFolderPath, built)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Merge(index, res.Merge(built, files)), otherResources, nil
 }
 
 func createImageRepoResources(m *config.Manifest, cfg *config.PipelinesConfig, env *config.Environment, p *AddServiceOptions) ([]string, res.Resources, string, error) {
@@ -185,6 +300,20 @@ func createImageRepoResources(m *config.Manifest, cfg *config.PipelinesConfig, e
 	return filenames, resources, bindingName, nil
 }
 
+// pipelineTemplateTrigger returns the TriggerTemplate that starts the
+// pipeline for the named template in the catalog, or the app-cd-template if
+// the name is empty.
+func pipelineTemplateTrigger(name string) (string, error) {
+	if name == "" {
+		return appCDTemplateName, nil
+	}
+	t := pipelines.LookupTemplate(name)
+	if t == nil {
+		return "", fmt.Errorf("unknown pipeline template %q, must be one of %s", name, strings.Join(pipelines.TemplateNames(), ", "))
+	}
+	return templateTriggerTemplateName(t), nil
+}
+
 func createService(serviceName, url string) *config.Service {
 	if url == "" {
 		return &config.Service{
@@ -197,17 +326,6 @@ func createService(serviceName, url string) *config.Service {
 	}
 }
 
-func updateKustomization(appFs afero.Fs, base string) error {
-	files := res.Resources{}
-	filenames, err := environments.ListFiles(appFs, base)
-	if err != nil {
-		return err
-	}
-	files[Kustomize] = &res.Kustomization{Resources: filenames.Items()}
-	_, err = yaml.WriteResources(appFs, base, files)
-	return err
-}
-
 func makeSvcImageBindingName(envName, appName, svcName string) string {
 	bindingName := fmt.Sprintf("%s-%s-%s", envName, appName, svcName)
 	if len(bindingName) > 54 {
@@ -228,7 +346,36 @@ func createSvcImageBinding(cfg *config.PipelinesConfig, env *config.Environment,
 	name := makeSvcImageBindingName(env.Name, appName, svcName)
 	filename := makeSvcImageBindingFilename(name)
 	resourceFilePath := makeImageBindingPath(cfg, filename)
-	return name, filename, res.Resources{resourceFilePath: triggers.CreateImageRepoBinding(cfg.Name, name, imageRepo, strconv.FormatBool(isTLSVerify))}
+	return name, filename, convertResources(res.Resources{resourceFilePath: triggers.CreateImageRepoBinding(cfg.Name, name, imageRepo, strconv.FormatBool(isTLSVerify))}, cfg.TektonAPIVersion)
+}
+
+func makeSvcCDBindingName(envName, appName, svcName string) string {
+	bindingName := fmt.Sprintf("%s-%s-%s", envName, appName, svcName)
+	if len(bindingName) > 51 {
+		bindingName = bindingName[:51]
+	}
+	return fmt.Sprintf("%s-cd-binding", bindingName)
+}
+
+// createSvcCDBinding returns the binding with the GitOps repository, and the
+// service that the app-cd-pipeline updates with the newly built image.
+func createSvcCDBinding(m *config.Manifest, cfg *config.PipelinesConfig, env *config.Environment, app *config.Application, svcName string) (string, string, res.Resources, error) {
+	fullName, err := orgRepoFromURL(m.GitOpsURL)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to parse GitOps repo URL %q: %w", m.GitOpsURL, err)
+	}
+	driver := gitOpsRepoDriver(m)
+	if driver == "" {
+		driver, err = scm.GetDriverName(m.GitOpsURL)
+		if err != nil {
+			return "", "", nil, err
+		}
+	}
+	name := makeSvcCDBindingName(env.Name, app.Name, svcName)
+	filename := makeSvcImageBindingFilename(name)
+	return name, filename, convertResources(res.Resources{
+		makeImageBindingPath(cfg, filename): triggers.CreateGitOpsRepoBinding(cfg.Name, name, m.GitOpsURL, fullName, driver, env.Name, app.Name, svcName),
+	}, cfg.TektonAPIVersion), nil
 }
 
 func getConfigFolder(m *config.Manifest, appFs afero.Fs, o *AddServiceOptions) (res.Resources, error) {
@@ -0,0 +1,214 @@
+package pipelines
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/krusty"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+// SetServiceImageOptions control how the image of a service is set.
+type SetServiceImageOptions struct {
+	AppName             string
+	EnvName             string
+	ServiceName         string
+	Image               string // The image to deploy, with a tag or digest.
+	ImageName           string // The image in the service's resources to replace, if empty it's found from the resources.
+	PipelinesFolderPath string
+}
+
+// SetServiceImage records the image for a service in the manifest, and sets
+// it in the service's overlay with a kustomize images entry.
+//
+// Only the manifest and the service's overlay are written, so that setting the
+// same image again doesn't change any files.
+func SetServiceImage(o *SetServiceImageOptions, appFs afero.Fs) error {
+	root, err := homedir.Expand(o.PipelinesFolderPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	m, err := config.LoadManifest(appFs, root)
+	if err != nil {
+		return err
+	}
+	svc, err := m.GetService(o.EnvName, o.AppName, o.ServiceName)
+	if err != nil {
+		return err
+	}
+	repo, tag, digest, err := parseImage(o.Image)
+	if err != nil {
+		return err
+	}
+	env := m.GetEnvironment(o.EnvName)
+	app := m.GetApplication(o.EnvName, o.AppName)
+	svcPath := filepath.ToSlash(config.PathForService(app, env, svc.Name))
+
+	// the name is kept once it's recorded, as the image in the base isn't
+	// changed by the overlay.
+	name := o.ImageName
+	if name == "" && svc.Image != nil {
+		name = svc.Image.Name
+	}
+	if name == "" {
+		name, err = serviceBaseImage(appFs, root, svcPath)
+		if err != nil {
+			return fmt.Errorf("failed to find the image of service %s: %w", svc.Name, err)
+		}
+	}
+	svc.Image = &config.ServiceImage{Name: name, Repo: repo, Tag: tag, Digest: digest}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	built, err := buildResources(appFs, m)
+	if err != nil {
+		return fmt.Errorf("failed to build resources: %v", err)
+	}
+	overlayPath := path.Join(svcPath, "overlays", Kustomize)
+	_, err = yaml.WriteResources(appFs, root, res.Resources{
+		pipelinesFile: m,
+		overlayPath:   built[overlayPath],
+	})
+	return err
+}
+
+// keepOverlayImages keeps the images in the overlays of the services that are
+// already in the GitOps repository, rather than the images from the manifest,
+// as the update-image task sets the image in the overlay when a new image is
+// built, only SetServiceImage replaces them with the image in the manifest.
+func keepOverlayImages(fs afero.Fs, root string, m *config.Manifest, built res.Resources) error {
+	for _, env := range m.Environments {
+		for _, app := range env.Apps {
+			for _, svc := range app.Services {
+				overlayPath := path.Join(filepath.ToSlash(config.PathForService(app, env, svc.Name)), "overlays", Kustomize)
+				generated, ok := built[overlayPath].(*res.Kustomization)
+				if !ok {
+					continue
+				}
+				existing, err := readKustomization(fs, root, overlayPath, res.Resources{})
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if len(existing.Images) > 0 {
+					kept := *generated
+					kept.Images = existing.Images
+					built[overlayPath] = &kept
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// serviceBaseImage renders the base of the service and returns the image of
+// its containers, without the tag or digest, it's an error if the containers
+// have more than one image, as the image to replace can't be chosen.
+func serviceBaseImage(appFs afero.Fs, root, svcPath string) (string, error) {
+	fSys, err := kustomizeFs(appFs, root)
+	if err != nil {
+		return "", err
+	}
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fSys, path.Join("/", svcPath, "base"))
+	if err != nil {
+		return "", err
+	}
+	images := map[string]bool{}
+	for _, r := range resources.Resources() {
+		obj, err := r.Map()
+		if err != nil {
+			return "", err
+		}
+		for _, image := range containerImages(obj) {
+			repo, _, _, err := parseImage(image)
+			if err != nil {
+				return "", err
+			}
+			images[repo] = true
+		}
+	}
+	found := []string{}
+	for image := range images {
+		found = append(found, image)
+	}
+	sort.Strings(found)
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no containers were found in %s", path.Join(svcPath, "base"))
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("the containers have more than one image, %s, the image to replace must be given", strings.Join(found, ", "))
+}
+
+// containerImages returns the images of the containers in a Pod, or in the Pod
+// template of a workload.
+func containerImages(obj map[string]interface{}) []string {
+	images := []string{}
+	for _, podSpec := range [][]string{
+		{"spec"},
+		{"spec", "template", "spec"},
+		{"spec", "jobTemplate", "spec", "template", "spec"},
+	} {
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _ := nestedValue(obj, append(podSpec, field)...).([]interface{})
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				if image, ok := container["image"].(string); ok && image != "" {
+					images = append(images, image)
+				}
+			}
+		}
+	}
+	return images
+}
+
+func nestedValue(obj map[string]interface{}, keys ...string) interface{} {
+	var value interface{} = obj
+	for _, key := range keys {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+	return value
+}
+
+// parseImage splits an image reference into the repository, and the tag and
+// digest, which are empty if they're not in the reference.
+func parseImage(image string) (string, string, string, error) {
+	invalid := fmt.Errorf("invalid image %q", image)
+	repo, digest := image, ""
+	if i := strings.Index(image, "@"); i >= 0 {
+		repo, digest = image[:i], image[i+1:]
+		if digest == "" {
+			return "", "", "", invalid
+		}
+	}
+	tag := ""
+	// the tag follows the last colon, unless it's in the registry's port.
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, tag = repo[:i], repo[i+1:]
+		if tag == "" {
+			return "", "", "", invalid
+		}
+	}
+	if repo == "" {
+		return "", "", "", invalid
+	}
+	return repo, tag, digest, nil
+}
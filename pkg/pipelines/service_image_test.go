@@ -0,0 +1,121 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/test"
+)
+
+const testServiceOverlay = "/gitops/environments/tst-dev/apps/app-http-api/services/http-api/overlays/kustomization.yaml"
+
+func TestSetServiceImage(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		GitHostAccessToken:   "test-token",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		OutputPath:           "/gitops",
+	}
+	fatalIfError(t, Bootstrap(params, fakeFs))
+	o := &SetServiceImageOptions{
+		EnvName:             "tst-dev",
+		AppName:             "app-http-api",
+		ServiceName:         "http-api",
+		Image:               "quay.io/example/http-api:v1.0.0",
+		PipelinesFolderPath: "/gitops",
+	}
+
+	assertNoError(t, SetServiceImage(o, fakeFs))
+	assertServiceImage(t, fakeFs,
+		&config.ServiceImage{Name: "nginxinc/nginx-unprivileged", Repo: "quay.io/example/http-api", Tag: "v1.0.0"},
+		res.Image{Name: "nginxinc/nginx-unprivileged", NewName: "quay.io/example/http-api", NewTag: "v1.0.0"})
+
+	// the image that's replaced is kept when the image is changed.
+	o.Image = "quay.io/example/http-api@sha256:3e1ad6b6b2b2"
+	assertNoError(t, SetServiceImage(o, fakeFs))
+	assertServiceImage(t, fakeFs,
+		&config.ServiceImage{Name: "nginxinc/nginx-unprivileged", Repo: "quay.io/example/http-api", Digest: "sha256:3e1ad6b6b2b2"},
+		res.Image{Name: "nginxinc/nginx-unprivileged", NewName: "quay.io/example/http-api", Digest: "sha256:3e1ad6b6b2b2"})
+
+	// the generated overlay is the same when the resources are rebuilt.
+	_, err := BuildResources(&BuildParameters{PipelinesFolderPath: "/gitops", OutputPath: "/gitops"}, fakeFs)
+	assertNoError(t, err)
+	assertServiceImage(t, fakeFs,
+		&config.ServiceImage{Name: "nginxinc/nginx-unprivileged", Repo: "quay.io/example/http-api", Digest: "sha256:3e1ad6b6b2b2"},
+		res.Image{Name: "nginxinc/nginx-unprivileged", NewName: "quay.io/example/http-api", Digest: "sha256:3e1ad6b6b2b2"})
+
+	// the image that the update-image task sets in the overlay is kept when
+	// the resources are rebuilt.
+	taskOverlay := "images:\n- name: \"nginxinc/nginx-unprivileged\"\n  newName: \"quay.io/example/http-api\"\n  newTag: \"v1.1.0\"\nresources:\n- ../base\n"
+	assertNoError(t, afero.WriteFile(fakeFs, testServiceOverlay, []byte(taskOverlay), 0644))
+	_, err = BuildResources(&BuildParameters{PipelinesFolderPath: "/gitops", OutputPath: "/gitops"}, fakeFs)
+	assertNoError(t, err)
+	assertServiceImage(t, fakeFs,
+		&config.ServiceImage{Name: "nginxinc/nginx-unprivileged", Repo: "quay.io/example/http-api", Digest: "sha256:3e1ad6b6b2b2"},
+		res.Image{Name: "nginxinc/nginx-unprivileged", NewName: "quay.io/example/http-api", NewTag: "v1.1.0"})
+
+	o.ServiceName = "unknown"
+	err = SetServiceImage(o, fakeFs)
+	test.AssertErrorMatch(t, "service unknown does not exist in application app-http-api of environment tst-dev", err)
+}
+
+func TestParseImage(t *testing.T) {
+	imageTests := []struct {
+		image   string
+		want    []string
+		wantErr string
+	}{
+		{"nginx", []string{"nginx", "", ""}, ""},
+		{"quay.io/example/app:v1", []string{"quay.io/example/app", "v1", ""}, ""},
+		{"registry:5000/example/app", []string{"registry:5000/example/app", "", ""}, ""},
+		{"registry:5000/example/app:v1@sha256:abc", []string{"registry:5000/example/app", "v1", "sha256:abc"}, ""},
+		{"example/app@sha256:abc", []string{"example/app", "", "sha256:abc"}, ""},
+		{"example/app:", nil, `invalid image "example/app:"`},
+		{"example/app@", nil, `invalid image "example/app@"`},
+		{":v1", nil, `invalid image ":v1"`},
+	}
+
+	for _, tt := range imageTests {
+		t.Run(tt.image, func(rt *testing.T) {
+			repo, tag, digest, err := parseImage(tt.image)
+			if !test.ErrorMatch(rt, tt.wantErr, err) {
+				rt.Fatalf("parseImage() got error %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if diff := cmp.Diff(tt.want, []string{repo, tag, digest}); diff != "" {
+				rt.Fatalf("parseImage() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func assertServiceImage(t *testing.T, fs afero.Fs, want *config.ServiceImage, wantImage res.Image) {
+	t.Helper()
+	m, err := config.LoadManifest(fs, "/gitops")
+	assertNoError(t, err)
+	svc, err := m.GetService("tst-dev", "app-http-api", "http-api")
+	assertNoError(t, err)
+	if diff := cmp.Diff(want, svc.Image); diff != "" {
+		t.Fatalf("service image failed:\n%s", diff)
+	}
+	body, err := afero.ReadFile(fs, testServiceOverlay)
+	assertNoError(t, err)
+	var overlay res.Kustomization
+	assertNoError(t, sigsyaml.Unmarshal(body, &overlay))
+	if diff := cmp.Diff([]res.Image{wantImage}, overlay.Images); diff != "" {
+		t.Fatalf("overlay images failed:\n%s", diff)
+	}
+}
@@ -17,7 +17,9 @@ import (
 	"github.com/redhat-developer/kam/pkg/pipelines/routes"
 	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
 	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	"github.com/redhat-developer/kam/test"
 	"github.com/spf13/afero"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
@@ -38,13 +40,13 @@ func TestServiceResourcesWithCICD(t *testing.T) {
 	}
 	want := res.Resources{
 		"environments/test-dev/apps/test-app/base/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"../services/test-svc", "../services/test"}},
+			Resources: []string{"../services/test-svc", "../services/test"}},
 		"environments/test-dev/apps/test-app/kustomization.yaml": &res.Kustomization{
-			Bases:        []string{"overlays"},
-			CommonLabels: map[string]string{"app.openshift.io/vcs-source": "org/test"},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{"app.openshift.io/vcs-source": "org/test"}, IncludeSelectors: true}},
 		},
 		"environments/test-dev/apps/test-app/overlays/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"../base"}},
+			Resources: []string{"../base"}},
 		"pipelines.yaml": &config.Manifest{
 			Config: &config.Config{
 				Pipelines: &config.PipelinesConfig{
@@ -79,7 +81,7 @@ func TestServiceResourcesWithCICD(t *testing.T) {
 										},
 									},
 									Pipelines: &config.Pipelines{
-										Integration: &config.TemplateBinding{Bindings: []string{"test-dev-test-app-test-binding", "github-push-binding"}},
+										Integration: &config.TemplateBinding{Template: "app-cd-template", Bindings: []string{"test-dev-test-app-test-binding", "test-dev-test-app-test-cd-binding", "github-push-binding"}},
 									},
 								},
 							},
@@ -122,17 +124,17 @@ func TestServiceResourcesWithArgoCD(t *testing.T) {
 
 	want := res.Resources{
 		"environments/test-dev/apps/test-app/base/kustomization.yaml": &res.Kustomization{
-			Bases: []string{
+			Resources: []string{
 				"../services/test-svc",
 				"../services/test",
 			},
 		},
 		"environments/test-dev/apps/test-app/kustomization.yaml": &res.Kustomization{
-			Bases:        []string{"overlays"},
-			CommonLabels: map[string]string{"app.openshift.io/vcs-source": "org/test"},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{"app.openshift.io/vcs-source": "org/test"}, IncludeSelectors: true}},
 		},
 		"environments/test-dev/apps/test-app/overlays/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"../base"},
+			Resources: []string{"../base"},
 		},
 		"pipelines.yaml": &config.Manifest{
 			Config: &config.Config{
@@ -190,16 +192,15 @@ func TestServiceResourcesWithoutArgoCD(t *testing.T) {
 	want := res.Resources{
 		"environments/test-dev/apps/test-app/base/kustomization.yaml": &res.Kustomization{
 
-			Bases: []string{"../services/test-svc", "../services/test"}},
+			Resources: []string{"../services/test-svc", "../services/test"}},
 		"environments/test-dev/apps/test-app/kustomization.yaml": &res.Kustomization{
-			Bases:        []string{"overlays"},
-			CommonLabels: map[string]string{"app.openshift.io/vcs-source": "org/test"},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{"app.openshift.io/vcs-source": "org/test"}, IncludeSelectors: true}},
 		},
 		"environments/test-dev/apps/test-app/overlays/kustomization.yaml": &res.Kustomization{
-			Bases: []string{"../base"}},
+			Resources: []string{"../base"}},
 		"environments/test-dev/env/base/kustomization.yaml": &res.Kustomization{
-			Resources: []string{"test-dev-environment.yaml"},
-			Bases:     []string{"../../apps/test-app/overlays"},
+			Resources: []string{"test-dev-environment.yaml", "../../apps/test-app/overlays"},
 		},
 		"pipelines.yaml": &config.Manifest{
 			GitOpsURL: "http://github.com/org/test",
@@ -250,15 +251,15 @@ func TestAddServiceWithoutApp(t *testing.T) {
 	fakeFs := ioutils.NewMemoryFilesystem()
 	m := buildManifest(false, false)
 	want := res.Resources{
-		"environments/test-dev/apps/new-app/base/kustomization.yaml":     &res.Kustomization{Bases: []string{"../services/test"}},
-		"environments/test-dev/apps/new-app/overlays/kustomization.yaml": &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/apps/new-app/base/kustomization.yaml":     &res.Kustomization{Resources: []string{"../services/test"}},
+		"environments/test-dev/apps/new-app/overlays/kustomization.yaml": &res.Kustomization{Resources: []string{"../base"}},
 		"environments/test-dev/apps/new-app/kustomization.yaml": &res.Kustomization{
-			Bases:        []string{"overlays"},
-			CommonLabels: map[string]string{"app.openshift.io/vcs-source": "org/test"},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{"app.openshift.io/vcs-source": "org/test"}, IncludeSelectors: true}},
 		},
-		"environments/test-dev/apps/new-app/services/test/base/kustomization.yaml":          &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/new-app/services/test/kustomization.yaml":               &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/new-app/services/test/overlays/kustomization.yaml":      &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/apps/new-app/services/test/base/kustomization.yaml":          &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/new-app/services/test/kustomization.yaml":               &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/new-app/services/test/overlays/kustomization.yaml":      &res.Kustomization{Resources: []string{"../base"}},
 		"environments/cicd/base/pipelines/03-secrets/webhook-secret-test-dev-test-svc.yaml": nil,
 		"pipelines.yaml": &config.Manifest{
 			GitOpsURL: "http://github.com/org/test",
@@ -347,6 +348,94 @@ func TestAddServiceFilePaths(t *testing.T) {
 	}
 }
 
+func TestAddServiceWithPipelineTemplate(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	outputPath := afero.GetTempDir(fakeFs, "test")
+	b, err := yaml.Marshal(buildManifest(true, true))
+	assertNoError(t, err)
+	assertNoError(t, afero.WriteFile(fakeFs, filepath.Join(outputPath, pipelinesFile), b, 0644))
+	o := &AddServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "test-dev",
+		GitRepoURL:          "http://github.com/org/test",
+		PipelinesFolderPath: outputPath,
+		WebhookSecret:       "123",
+		ServiceName:         "test",
+		PipelineTemplate:    "s2i-java",
+	}
+
+	assertNoError(t, AddService(o, fakeFs))
+	m, err := config.LoadManifest(fakeFs, outputPath)
+	assertNoError(t, err)
+	svc, err := m.GetService("test-dev", "new-app", "test")
+	assertNoError(t, err)
+	if svc.Pipelines.Integration.Template != "app-cd-s2i-java-template" {
+		t.Fatalf("AddService() got template %q, want %q", svc.Pipelines.Integration.Template, "app-cd-s2i-java-template")
+	}
+	for _, path := range []string{
+		"config/cicd/base/04-pipelines/app-cd-s2i-java-pipeline.yaml",
+		"config/cicd/base/06-templates/app-cd-s2i-java-build-from-push-template.yaml",
+	} {
+		assertFileExists(t, fakeFs, filepath.Join(outputPath, path), true)
+	}
+	body, err := afero.ReadFile(fakeFs, filepath.Join(outputPath, "config/cicd/base/kustomization.yaml"))
+	assertNoError(t, err)
+	var k res.Kustomization
+	assertNoError(t, yaml.Unmarshal(body, &k))
+	if !hasString(k.Resources, "04-pipelines/app-cd-s2i-java-pipeline.yaml") || !hasString(k.Resources, "06-templates/app-cd-s2i-java-build-from-push-template.yaml") {
+		t.Fatalf("AddService() didn't add the template to the kustomization: %v", k.Resources)
+	}
+
+	o.ServiceName, o.PipelineTemplate = "test2", "gradle"
+	err = AddService(o, fakeFs)
+	test.AssertErrorMatch(t, `unknown pipeline template "gradle", must be one of buildah, ko, maven-buildah, s2i-java, s2i-nodejs`, err)
+
+	o.GitRepoURL, o.PipelineTemplate = "", "ko"
+	err = AddService(o, fakeFs)
+	test.AssertErrorMatch(t, "a pipeline template can only be used for a service with a Git repository URL", err)
+}
+
+func TestAddServiceWithPipelineTemplateSignsImages(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	outputPath := afero.GetTempDir(fakeFs, "test")
+	m := buildManifest(true, true)
+	m.Config.Pipelines.ImageSigning = &config.ImageSigning{Secret: "cosign-key"}
+	b, err := yaml.Marshal(m)
+	assertNoError(t, err)
+	assertNoError(t, afero.WriteFile(fakeFs, filepath.Join(outputPath, pipelinesFile), b, 0644))
+
+	assertNoError(t, AddService(&AddServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "test-dev",
+		GitRepoURL:          "http://github.com/org/test",
+		PipelinesFolderPath: outputPath,
+		WebhookSecret:       "123",
+		ServiceName:         "test",
+		PipelineTemplate:    "maven-buildah",
+	}, fakeFs))
+
+	body, err := afero.ReadFile(fakeFs, filepath.Join(outputPath, "config/cicd/base/04-pipelines/app-cd-maven-buildah-pipeline.yaml"))
+	assertNoError(t, err)
+	var pipeline pipelinev1.Pipeline
+	assertNoError(t, yaml.Unmarshal(body, &pipeline))
+	names := []string{}
+	for _, task := range pipeline.Spec.Tasks {
+		names = append(names, task.Name)
+	}
+	if !hasString(names, "sign-image") || !hasString(names, "generate-sbom") {
+		t.Fatalf("AddService() pipeline template doesn't sign the image, got tasks %v", names)
+	}
+}
+
+func hasString(items []string, s string) bool {
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 func TestAddServiceReources(t *testing.T) {
 	fakeFs := ioutils.NewMemoryFilesystem()
 	outputPath := afero.GetTempDir(fakeFs, "test")
@@ -435,7 +524,7 @@ func TestServiceWithArgoCD(t *testing.T) {
 										},
 									},
 									Pipelines: &config.Pipelines{
-										Integration: &config.TemplateBinding{Bindings: []string{"test-dev-test-app-test-binding", "github-push-binding"}},
+										Integration: &config.TemplateBinding{Template: "app-cd-template", Bindings: []string{"test-dev-test-app-test-binding", "test-dev-test-app-test-cd-binding", "github-push-binding"}},
 									},
 								},
 							},
@@ -559,15 +648,15 @@ func TestAddServiceWithoutGitRepoUrl(t *testing.T) {
 	fakeFs := ioutils.NewMemoryFilesystem()
 	m := buildManifest(false, false)
 	want := res.Resources{
-		"environments/test-dev/apps/new-app/base/kustomization.yaml":     &res.Kustomization{Bases: []string{"../services/test"}},
-		"environments/test-dev/apps/new-app/overlays/kustomization.yaml": &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/apps/new-app/base/kustomization.yaml":     &res.Kustomization{Resources: []string{"../services/test"}},
+		"environments/test-dev/apps/new-app/overlays/kustomization.yaml": &res.Kustomization{Resources: []string{"../base"}},
 		"environments/test-dev/apps/new-app/kustomization.yaml": &res.Kustomization{
-			Bases:        []string{"overlays"},
-			CommonLabels: map[string]string{"app.openshift.io/vcs-source": "org/test"},
+			Resources: []string{"overlays"},
+			Labels:    []res.Label{{Pairs: map[string]string{"app.openshift.io/vcs-source": "org/test"}, IncludeSelectors: true}},
 		},
-		"environments/test-dev/apps/new-app/services/test/base/kustomization.yaml":          &res.Kustomization{Bases: []string{"./config"}},
-		"environments/test-dev/apps/new-app/services/test/kustomization.yaml":               &res.Kustomization{Bases: []string{"overlays"}},
-		"environments/test-dev/apps/new-app/services/test/overlays/kustomization.yaml":      &res.Kustomization{Bases: []string{"../base"}},
+		"environments/test-dev/apps/new-app/services/test/base/kustomization.yaml":          &res.Kustomization{Resources: []string{"./config"}},
+		"environments/test-dev/apps/new-app/services/test/kustomization.yaml":               &res.Kustomization{Resources: []string{"overlays"}},
+		"environments/test-dev/apps/new-app/services/test/overlays/kustomization.yaml":      &res.Kustomization{Resources: []string{"../base"}},
 		"environments/cicd/base/pipelines/03-secrets/webhook-secret-test-dev-test-svc.yaml": nil,
 		"pipelines.yaml": &config.Manifest{
 			GitOpsURL: "http://github.com/org/test",
@@ -705,3 +794,105 @@ func TestCreateSvcImageBinding(t *testing.T) {
 		t.Errorf("resources failed: %v", diff)
 	}
 }
+
+func TestCreateSvcCDBinding(t *testing.T) {
+	m := &config.Manifest{GitOpsURL: "https://gitlab.example.com/org/gitops.git", Config: &config.Config{
+		Git: &config.GitConfig{Drivers: map[string]string{"gitlab.example.com": "gitlab"}},
+	}}
+	cfg := &config.PipelinesConfig{Name: "cicd"}
+	env := &config.Environment{Name: "new-env"}
+
+	bindingName, bindingFilename, resources, err := createSvcCDBinding(m, cfg, env, &config.Application{Name: "newapp"}, "new-svc")
+	assertNoError(t, err)
+	if diff := cmp.Diff("new-env-newapp-new-svc-cd-binding", bindingName); diff != "" {
+		t.Errorf("bindingName failed: %v", diff)
+	}
+	if diff := cmp.Diff("05-bindings/new-env-newapp-new-svc-cd-binding.yaml", bindingFilename); diff != "" {
+		t.Errorf("bindingFilename failed: %v", diff)
+	}
+	wantResources := res.Resources{
+		"config/cicd/base/05-bindings/new-env-newapp-new-svc-cd-binding.yaml": triggers.CreateGitOpsRepoBinding(
+			"cicd", "new-env-newapp-new-svc-cd-binding", "https://gitlab.example.com/org/gitops.git", "org/gitops", "gitlab",
+			"new-env", "newapp", "new-svc"),
+	}
+	if diff := cmp.Diff(wantResources, resources); diff != "" {
+		t.Errorf("resources failed: %v", diff)
+	}
+}
+
+func TestRemoveService(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	outputPath := afero.GetTempDir(fakeFs, "test")
+	pipelinesPath := filepath.Join(outputPath, pipelinesFile) // Don't call filepath.ToSlash
+	b, err := yaml.Marshal(buildManifest(true, true))
+	assertNoError(t, err)
+	assertNoError(t, afero.WriteFile(fakeFs, pipelinesPath, b, 0644))
+	err = AddService(&AddServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "test-dev",
+		GitRepoURL:          "http://github.com/org/new-svc",
+		PipelinesFolderPath: outputPath,
+		WebhookSecret:       "123",
+		ServiceName:         "new-svc",
+		ImageRepo:           "quay.io/org/new-svc",
+	}, fakeFs)
+	assertNoError(t, err)
+	removedPaths := []string{
+		"environments/test-dev/apps/new-app",
+		"config/argocd/test-dev-new-app-app.yaml",
+		"config/cicd/base/05-bindings/test-dev-new-app-new-svc-binding.yaml",
+		"../secrets/webhook-secret-test-dev-new-svc.yaml",
+	}
+	for _, path := range removedPaths {
+		assertFileExists(t, fakeFs, filepath.Join(outputPath, path), true)
+	}
+
+	svc, err := RemoveService(&RemoveServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "test-dev",
+		PipelinesFolderPath: outputPath,
+		ServiceName:         "new-svc",
+	}, fakeFs)
+	assertNoError(t, err)
+	if svc.SourceURL != "http://github.com/org/new-svc" {
+		t.Fatalf("RemoveService() returned the wrong service: %#v", svc)
+	}
+
+	for _, path := range removedPaths {
+		assertFileExists(t, fakeFs, filepath.Join(outputPath, path), false)
+	}
+	assertFileExists(t, fakeFs, filepath.Join(outputPath, "config/argocd/test-dev-test-app-app.yaml"), true)
+	m, err := config.LoadManifest(fakeFs, outputPath)
+	assertNoError(t, err)
+	if app := m.GetApplication("test-dev", "new-app"); app != nil {
+		t.Fatalf("application was not removed from the manifest: %#v", app)
+	}
+	k := res.Kustomization{}
+	b, err = fakeFs.ReadFile(filepath.Join(outputPath, "config/cicd/base/kustomization.yaml"))
+	assertNoError(t, err)
+	assertNoError(t, yaml.Unmarshal(b, &k))
+	for _, r := range k.Resources {
+		if r == "05-bindings/test-dev-new-app-new-svc-binding.yaml" {
+			t.Fatalf("removed binding is still in the CICD kustomization: %v", k.Resources)
+		}
+	}
+}
+
+func TestRemoveServiceWithUnknownService(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	outputPath := afero.GetTempDir(fakeFs, "test")
+	b, err := yaml.Marshal(buildManifest(true, true))
+	assertNoError(t, err)
+	assertNoError(t, afero.WriteFile(fakeFs, filepath.Join(outputPath, pipelinesFile), b, 0644))
+
+	_, err = RemoveService(&RemoveServiceOptions{
+		AppName:             "test-app",
+		EnvName:             "test-dev",
+		PipelinesFolderPath: outputPath,
+		ServiceName:         "unknown",
+	}, fakeFs)
+	want := "service unknown does not exist in application test-app of environment test-dev"
+	if err == nil || err.Error() != want {
+		t.Fatalf("RemoveService() got error %v, want %q", err, want)
+	}
+}
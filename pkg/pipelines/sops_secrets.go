@@ -0,0 +1,196 @@
+package pipelines
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+	"github.com/redhat-developer/kam/pkg/pipelines/environments"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
+	"github.com/redhat-developer/kam/pkg/pipelines/yaml"
+)
+
+const (
+	// sopsSecretsPath is the folder next to the pipelines base that SOPS
+	// encrypted secrets are written to, it's a separate kustomization, so
+	// that the encrypted files aren't listed as resources in the base.
+	sopsSecretsPath = "secrets"
+
+	ksopsGeneratorFile = "secret-generator.yaml"
+)
+
+// ksopsGenerator is the KSOPS Kustomize generator, which decrypts the SOPS
+// encrypted files when the kustomization is built.
+type ksopsGenerator struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Metadata   ksopsGeneratorMeta `json:"metadata"`
+	Files      []string           `json:"files"`
+}
+
+type ksopsGeneratorMeta struct {
+	Name string `json:"name"`
+}
+
+// sopsSecretsDir returns the path to the SOPS encrypted secrets, relative to
+// the GitOps repository.
+func sopsSecretsDir(cfg *config.PipelinesConfig) string {
+	return filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg), sopsSecretsPath))
+}
+
+// encryptSOPSSecrets encrypts the unsealed secrets with SOPS for the age
+// recipients, or the recipients from the .sops.yaml if none are provided, and
+// returns them
+// with the KSOPS generator and kustomization for them, keyed by their path
+// relative to the GitOps repository at root.
+//
+// The files are merged with the existing encrypted secrets, and the pipelines
+// overlay is updated to include the secrets, using the overlay in files if
+// present, or the one on disk.
+func encryptSOPSSecrets(fs afero.Fs, root string, ageRecipients []string, cfg *config.PipelinesConfig, unsealed, files res.Resources) (res.Resources, error) {
+	if cfg == nil {
+		return nil, errors.New("failed to encrypt secrets: no pipelines configuration found")
+	}
+	root, err := homedir.Expand(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	dir := sopsSecretsDir(cfg)
+	encrypted := res.Resources{}
+	names := []string{}
+	for k, v := range unsealed {
+		secret, ok := v.(*corev1.Secret)
+		if !ok {
+			return nil, fmt.Errorf("failed to encrypt %s: not a secret", k)
+		}
+		filename := secret.Name + ".yaml"
+		path := filepath.ToSlash(filepath.Join(dir, filename))
+		recipients, err := sopsRecipients(fs, root, ageRecipients, path)
+		if err != nil {
+			return nil, err
+		}
+		encrypted[path], err = secrets.CreateSOPSSecret(secret, recipients)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, filename)
+	}
+	existing, err := listSOPSSecrets(fs, filepath.Join(root, dir))
+	if err != nil {
+		return nil, err
+	}
+	encrypted = res.Merge(sopsKustomization(dir, append(existing, names...)), encrypted)
+
+	overlayPath := filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg), "overlays", Kustomize))
+	overlay, err := readKustomization(fs, root, overlayPath, files)
+	if err != nil {
+		return nil, err
+	}
+	overlay.Fix()
+	overlay.Resources = addUnique(overlay.Resources, "../"+sopsSecretsPath)
+	encrypted[overlayPath] = overlay
+	return encrypted, nil
+}
+
+// sopsRecipients parses the age recipients, if there are none, they're read
+// from the .sops.yaml in the root of the GitOps repository.
+func sopsRecipients(fs afero.Fs, root string, ageRecipients []string, path string) ([]*secrets.AgeRecipient, error) {
+	if len(ageRecipients) > 0 {
+		return secrets.ParseAgeRecipients(ageRecipients...)
+	}
+	body, err := afero.ReadFile(fs, filepath.Join(root, secrets.SOPSConfigFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no age recipients provided and no %s found in %s", secrets.SOPSConfigFile, root)
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", secrets.SOPSConfigFile, err)
+	}
+	return secrets.AgeRecipientsFromSOPSConfig(body, path)
+}
+
+// updateSOPSGenerator rewrites the KSOPS generator for the encrypted secrets
+// that are in the directory, if the directory exists.
+func updateSOPSGenerator(fs afero.Fs, root string, cfg *config.PipelinesConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	dir := filepath.Join(root, sopsSecretsDir(cfg))
+	exists, err := afero.DirExists(fs, dir)
+	if err != nil || !exists {
+		return err
+	}
+	names, err := listSOPSSecrets(fs, dir)
+	if err != nil {
+		return err
+	}
+	_, err = yaml.WriteResources(fs, root, sopsKustomization(sopsSecretsDir(cfg), names))
+	return err
+}
+
+func listSOPSSecrets(fs afero.Fs, dir string) ([]string, error) {
+	files, err := environments.ListFiles(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	delete(files, ksopsGeneratorFile)
+	return files.Items(), nil
+}
+
+func sopsKustomization(dir string, names []string) res.Resources {
+	return res.Resources{
+		filepath.ToSlash(filepath.Join(dir, ksopsGeneratorFile)): ksopsGenerator{
+			APIVersion: "viaduct.ai/v1",
+			Kind:       "ksops",
+			Metadata:   ksopsGeneratorMeta{Name: "secret-generator"},
+			Files:      addUnique(nil, names...),
+		},
+		filepath.ToSlash(filepath.Join(dir, Kustomize)): res.Kustomization{
+			Generators: []string{ksopsGeneratorFile},
+		},
+	}
+}
+
+// readKustomization returns the kustomization at the path, relative to root,
+// from the files if it's being written, otherwise it's read from the
+// filesystem.
+func readKustomization(fs afero.Fs, root, path string, files res.Resources) (res.Kustomization, error) {
+	switch k := files[path].(type) {
+	case res.Kustomization:
+		return k, nil
+	case *res.Kustomization:
+		return *k, nil
+	}
+	k := res.Kustomization{}
+	body, err := afero.ReadFile(fs, filepath.Join(root, path))
+	if err != nil {
+		return k, fmt.Errorf("failed to read the kustomization %s: %w", path, err)
+	}
+	if err := sigsyaml.Unmarshal(body, &k); err != nil {
+		return k, fmt.Errorf("failed to parse the kustomization %s: %w", path, err)
+	}
+	return k, nil
+}
+
+// addUnique returns the values, with the new values that aren't already in
+// values appended, sorted.
+func addUnique(values []string, s ...string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, v := range append(append([]string{}, values...), s...) {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,186 @@
+package pipelines
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
+	"github.com/redhat-developer/kam/test"
+)
+
+const testAgeRecipient = "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"
+
+func TestBootstrapWithSOPS(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		GitHostAccessToken:   "test-token",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SOPS:                 true,
+		SOPSAgeRecipients:    []string{testAgeRecipient},
+	}
+	assertNoError(t, Bootstrap(params, fakeFs))
+
+	exists, err := afero.DirExists(fakeFs, "/secrets")
+	assertNoError(t, err)
+	if exists {
+		t.Fatal("unencrypted secrets were written to the secrets folder")
+	}
+	wantFiles := []string{
+		"git-host-access-token.yaml",
+		"git-host-basic-auth-token.yaml",
+		"gitops-webhook-secret.yaml",
+		"webhook-secret-tst-dev-http-api.yaml",
+	}
+	secretsDir := "/gitops/config/tst-cicd/secrets"
+	for _, f := range wantFiles {
+		encrypted := map[string]interface{}{}
+		readYAML(t, fakeFs, filepath.Join(secretsDir, f), &encrypted)
+		if _, ok := encrypted["sops"]; !ok {
+			t.Fatalf("%s is not encrypted with SOPS", f)
+		}
+	}
+	generator := ksopsGenerator{}
+	readYAML(t, fakeFs, filepath.Join(secretsDir, ksopsGeneratorFile), &generator)
+	if diff := cmp.Diff(wantFiles, generator.Files); diff != "" {
+		t.Fatalf("KSOPS generator failed:\n%s", diff)
+	}
+	k := res.Kustomization{}
+	readYAML(t, fakeFs, filepath.Join(secretsDir, Kustomize), &k)
+	if diff := cmp.Diff(res.Kustomization{Generators: []string{ksopsGeneratorFile}}, k); diff != "" {
+		t.Fatalf("secrets kustomization failed:\n%s", diff)
+	}
+	overlay := res.Kustomization{}
+	readYAML(t, fakeFs, "/gitops/config/tst-cicd/overlays/kustomization.yaml", &overlay)
+	if diff := cmp.Diff([]string{"../base", "../secrets"}, overlay.Resources); diff != "" {
+		t.Fatalf("pipelines overlay failed:\n%s", diff)
+	}
+}
+
+func TestBootstrapWithSOPSAndCosignKey(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, afero.WriteFile(fakeFs, "/keys/cosign.key", []byte("encrypted key"), 0600))
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SOPS:                 true,
+		SOPSAgeRecipients:    []string{testAgeRecipient},
+		CosignKey:            "/keys/cosign.key",
+		CosignPassword:       "secret",
+		SupplyChain:          true,
+	}
+	assertNoError(t, Bootstrap(params, fakeFs))
+
+	exists, err := afero.DirExists(fakeFs, "/secrets")
+	assertNoError(t, err)
+	if exists {
+		t.Fatal("unencrypted secrets were written to the secrets folder")
+	}
+	secretsDir := "/gitops/config/tst-cicd/secrets"
+	generator := ksopsGenerator{}
+	readYAML(t, fakeFs, filepath.Join(secretsDir, ksopsGeneratorFile), &generator)
+	for _, f := range []string{"cosign-key.yaml", "signing-secrets.yaml"} {
+		encrypted := map[string]interface{}{}
+		readYAML(t, fakeFs, filepath.Join(secretsDir, f), &encrypted)
+		if _, ok := encrypted["sops"]; !ok {
+			t.Fatalf("%s is not encrypted with SOPS", f)
+		}
+		if !contains(generator.Files, f) {
+			t.Errorf("KSOPS generator is missing %s: %v", f, generator.Files)
+		}
+	}
+}
+
+func TestBootstrapWithSOPSAndSealedSecrets(t *testing.T) {
+	err := Bootstrap(&BootstrapOptions{SOPS: true, SealedSecretsCert: testCertPath}, ioutils.NewMemoryFilesystem())
+	test.AssertErrorMatch(t, "can't be both sealed and encrypted", err)
+}
+
+func TestBootstrapWithSOPSWithoutRecipients(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SOPS:                 true,
+	}
+	err := Bootstrap(params, fakeFs)
+	test.AssertErrorMatch(t, "no age recipients provided and no .sops.yaml found", err)
+}
+
+func TestAddServiceWithSOPSConfig(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, afero.WriteFile(fakeFs, "/gitops/.sops.yaml", []byte(`creation_rules:
+  - path_regex: config/.*/secrets/.*\.yaml
+    encrypted_regex: ^(data|stringData)$
+    age: `+testAgeRecipient+`
+`), 0644))
+	assertNoError(t, Bootstrap(&BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		OutputPath:           "/gitops",
+		Overwrite:            true,
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		SOPS:                 true,
+	}, fakeFs))
+
+	assertNoError(t, AddService(&AddServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "tst-dev",
+		GitRepoURL:          "http://github.com/org/new-svc",
+		PipelinesFolderPath: "/gitops",
+		WebhookSecret:       "123",
+		ServiceName:         "new-svc",
+		ImageRepo:           "quay.io/org/new-svc",
+		SOPS:                true,
+	}, fakeFs))
+
+	secretsDir := "/gitops/config/tst-cicd/secrets"
+	assertFileExists(t, fakeFs, filepath.Join(secretsDir, "webhook-secret-tst-dev-new-svc.yaml"), true)
+	assertFileExists(t, fakeFs, "/secrets/webhook-secret-tst-dev-new-svc.yaml", false)
+	generator := ksopsGenerator{}
+	readYAML(t, fakeFs, filepath.Join(secretsDir, ksopsGeneratorFile), &generator)
+	want := []string{
+		"gitops-webhook-secret.yaml",
+		"webhook-secret-tst-dev-http-api.yaml",
+		"webhook-secret-tst-dev-new-svc.yaml",
+	}
+	if diff := cmp.Diff(want, generator.Files); diff != "" {
+		t.Fatalf("KSOPS generator failed:\n%s", diff)
+	}
+
+	_, err := RemoveService(&RemoveServiceOptions{
+		AppName:             "new-app",
+		EnvName:             "tst-dev",
+		PipelinesFolderPath: "/gitops",
+		ServiceName:         "new-svc",
+	}, fakeFs)
+	assertNoError(t, err)
+	assertFileExists(t, fakeFs, filepath.Join(secretsDir, "webhook-secret-tst-dev-new-svc.yaml"), false)
+	generator = ksopsGenerator{}
+	readYAML(t, fakeFs, filepath.Join(secretsDir, ksopsGeneratorFile), &generator)
+	if diff := cmp.Diff(want[:2], generator.Files); diff != "" {
+		t.Fatalf("KSOPS generator after removal failed:\n%s", diff)
+	}
+}
@@ -12,9 +12,9 @@ func CreateDeployFromSourceTask(ns, script string) pipelinev1.Task {
 		TypeMeta:   taskTypeMeta,
 		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, "deploy-from-source-task")),
 		Spec: pipelinev1.TaskSpec{
-			Params:    paramsForDeploymentFromSourceTask(),
-			Resources: createResourcesForDeployFromSourceTask(),
-			Steps:     createStepsForDeployFromSourceTask(script),
+			Params:     paramsForDeploymentFromSourceTask(),
+			Workspaces: workspacesForDeployFromSourceTask(),
+			Steps:      createStepsForDeployFromSourceTask(script),
 		},
 	}
 	return task
@@ -26,7 +26,7 @@ func createStepsForDeployFromSourceTask(script string) []pipelinev1.Step {
 			Container: createContainer(
 				"run-kubectl",
 				"quay.io/redhat-developer/k8s-kubectl",
-				"/workspace/source",
+				"$(workspaces.source.path)",
 				nil,
 				nil,
 			),
@@ -46,10 +46,8 @@ func paramsForDeploymentFromSourceTask() []pipelinev1.ParamSpec {
 	}
 }
 
-func createResourcesForDeployFromSourceTask() *pipelinev1.TaskResources {
-	return &pipelinev1.TaskResources{
-		Inputs: []pipelinev1.TaskResource{
-			createTaskResource("source", "git"),
-		},
+func workspacesForDeployFromSourceTask() []pipelinev1.WorkspaceDeclaration {
+	return []pipelinev1.WorkspaceDeclaration{
+		{Name: "source", Description: "The workspace with the clone of the GitOps repository."},
 	}
 }
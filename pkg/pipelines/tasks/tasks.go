@@ -5,21 +5,13 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 var (
-	taskTypeMeta = meta.TypeMeta("Task", "tekton.dev/v1beta1")
+	taskTypeMeta = meta.TypeMeta("Task", tekton.V1Beta1.PipelinesAPIVersion())
 )
 
-func createTaskResource(name, resourceType string) pipelinev1.TaskResource {
-	return pipelinev1.TaskResource{
-		ResourceDeclaration: pipelinev1.ResourceDeclaration{
-			Name: name,
-			Type: resourceType,
-		},
-	}
-}
-
 func createTaskParam(name, description string, paramType pipelinev1.ParamType) pipelinev1.ParamSpec {
 	return pipelinev1.ParamSpec{
 		Name:        name,
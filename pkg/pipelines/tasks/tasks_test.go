@@ -1,6 +1,10 @@
 package tasks
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -19,14 +23,16 @@ func TestDeployFromSourceTask(t *testing.T) {
 			Namespace: testNS,
 		},
 		Spec: pipelinev1.TaskSpec{
-			Params:    paramsForDeploymentFromSourceTask(),
-			Resources: createResourcesForDeployFromSourceTask(),
+			Params: paramsForDeploymentFromSourceTask(),
+			Workspaces: []pipelinev1.WorkspaceDeclaration{
+				{Name: "source", Description: "The workspace with the clone of the GitOps repository."},
+			},
 			Steps: []pipelinev1.Step{
 				{
 					Container: corev1.Container{
 						Name:       "run-kubectl",
 						Image:      "quay.io/redhat-developer/k8s-kubectl",
-						WorkingDir: "/workspace/source",
+						WorkingDir: "$(workspaces.source.path)",
 					},
 					Script: "test",
 				},
@@ -81,15 +87,120 @@ func TestCreateContainer(t *testing.T) {
 	}
 }
 
-func TestCreateTaskResource(t *testing.T) {
-	validTaskResource := pipelinev1.TaskResource{
-		ResourceDeclaration: pipelinev1.ResourceDeclaration{
-			Name: "sample",
-			Type: "git",
+func TestCreateUpdateImageTask(t *testing.T) {
+	task := CreateUpdateImageTask(testNS)
+
+	if diff := cmp.Diff(v1.ObjectMeta{Name: "update-image-pull-request", Namespace: testNS}, task.ObjectMeta); diff != "" {
+		t.Fatalf("CreateUpdateImageTask() metadata failed:\n%s", diff)
+	}
+	steps := []string{}
+	for _, s := range task.Spec.Steps {
+		steps = append(steps, s.Name)
+		// the params are only passed in the environment, and not substituted
+		// into the scripts.
+		if strings.Contains(s.Script, "$(params.") {
+			t.Errorf("CreateUpdateImageTask() step %s substitutes params in the script", s.Name)
+		}
+	}
+	if diff := cmp.Diff([]string{"check-driver", "set-image", "push-branch", "open-pull-request"}, steps); diff != "" {
+		t.Fatalf("CreateUpdateImageTask() steps failed:\n%s", diff)
+	}
+	if diff := cmp.Diff("$(params.GIT_IMAGE)", task.Spec.Steps[1].Image); diff != "" {
+		t.Fatalf("CreateUpdateImageTask() image failed:\n%s", diff)
+	}
+	want := []corev1.EnvVar{
+		{Name: "DRIVER", Value: "$(params.DRIVER)"},
+		{Name: "REPO", Value: "$(params.REPO)"},
+		{Name: "GIT_REPO", Value: "$(params.GIT_REPO)"},
+		{Name: "IMAGE", Value: "$(params.IMAGE)"},
+		{Name: "BRANCH", Value: "$(params.BRANCH)"},
+		{Name: "BASE_BRANCH", Value: "$(params.BASE_BRANCH)"},
+		{
+			Name: "GITHOSTACCESSTOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "$(params.GIT_TOKEN_SECRET_NAME)"},
+					Key:                  "$(params.GIT_TOKEN_SECRET_KEY)",
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, task.Spec.Steps[3].Env); diff != "" {
+		t.Fatalf("CreateUpdateImageTask() env failed:\n%s", diff)
+	}
+}
+
+func TestSetImageScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	scriptTests := []struct {
+		name    string
+		overlay string
+		image   string
+		want    string
+	}{
+		{
+			"tag",
+			"resources:\n- ../base\n",
+			"quay.io/org/taxi:v1.0.0",
+			"resources:\n- ../base\nimages:\n- name: \"nginxinc/nginx-unprivileged\"\n  newName: \"quay.io/org/taxi\"\n  newTag: \"v1.0.0\"\n",
+		},
+		{
+			"digest replaces the image",
+			"images:\n- name: nginxinc/nginx-unprivileged\n  newName: quay.io/org/taxi\n  newTag: v1.0.0\nresources:\n- ../base\n",
+			"quay.io/org/taxi@sha256:abc",
+			"resources:\n- ../base\nimages:\n- name: \"nginxinc/nginx-unprivileged\"\n  newName: \"quay.io/org/taxi\"\n  digest: \"sha256:abc\"\n",
 		},
+		{
+			"registry with a port",
+			"resources:\n- ../base\n",
+			"registry:5000/taxi",
+			"resources:\n- ../base\nimages:\n- name: \"nginxinc/nginx-unprivileged\"\n  newName: \"registry:5000/taxi\"\n",
+		},
+	}
+
+	for _, tt := range scriptTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			root := rt.TempDir()
+			svc := filepath.Join(root, "environments/dev/apps/app-taxi/services/taxi")
+			writeFile(rt, filepath.Join(svc, "base/config/100-deployment.yaml"), "spec:\n  containers:\n  - image: nginxinc/nginx-unprivileged:latest\n    name: taxi\n")
+			writeFile(rt, filepath.Join(svc, "overlays/kustomization.yaml"), tt.overlay)
+
+			cmd := exec.Command("sh", "-c", strings.ReplaceAll(setImageScript, "$(workspaces.source.path)", root))
+			cmd.Env = append(os.Environ(), "ENV_NAME=dev", "APP_NAME=app-taxi", "SERVICE_NAME=taxi", "IMAGE="+tt.image)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				rt.Fatalf("setImageScript failed: %s: %s", out, err)
+			}
+			got, err := os.ReadFile(filepath.Join(svc, "overlays/kustomization.yaml"))
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				rt.Fatalf("setImageScript failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetImageScriptWithInvalidImage(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	cmd := exec.Command("sh", "-c", setImageScript)
+	cmd.Env = append(os.Environ(), "IMAGE=quay.io/org/taxi;rm -rf /")
+	out, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(out), "invalid image") {
+		t.Fatalf("setImageScript didn't reject the image: %s: %v", out, err)
+	}
+}
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
 	}
-	taskResource := createTaskResource("sample", "git")
-	if diff := cmp.Diff(validTaskResource, taskResource); diff != "" {
-		t.Fatalf("createTaskResource() failed:\n%s", diff)
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
 	}
 }
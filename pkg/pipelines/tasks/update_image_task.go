@@ -0,0 +1,209 @@
+package tasks
+
+import (
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+)
+
+const (
+	// UpdateImageTaskName is the name of the task that updates the image of a
+	// service in a GitOps repository and opens a pull request with the change.
+	UpdateImageTaskName = "update-image-pull-request"
+
+	gitImage    = "gcr.io/tekton-releases/github.com/tektoncd/pipeline/cmd/git-init:v0.21.0"
+	pythonImage = "registry.access.redhat.com/ubi8/python-38"
+)
+
+// The param values are passed to the scripts in environment variables, rather
+// than being substituted into the scripts, so that they're never interpreted
+// as code.
+
+// checkDriverScript fails before anything is pushed if a pull request can't be
+// opened for the driver.
+const checkDriverScript = `#!/usr/bin/env sh
+set -e
+case "${DRIVER}" in
+github|gitlab|gitea) ;;
+*)
+  echo "unsupported driver ${DRIVER}, pull requests can only be opened for github, gitlab and gitea" >&2
+  exit 1
+  ;;
+esac
+`
+
+// setImageScript sets the image in the service's overlay with a kustomize
+// images entry, replacing the image of the first container in the service's
+// base, it only needs a shell, so that nothing is downloaded into the step
+// that runs next to the clone of the GitOps repository.
+const setImageScript = `#!/usr/bin/env sh
+set -e
+case "${IMAGE}" in
+""|*[!A-Za-z0-9._/:@-]*)
+  echo "invalid image ${IMAGE}" >&2
+  exit 1
+  ;;
+esac
+cd "$(workspaces.source.path)/environments/${ENV_NAME}/apps/${APP_NAME}/services/${SERVICE_NAME}"
+name=$(cat base/config/*.yaml | sed -n 's/^[ -]*image: *//p' | tr -d "\"'" | head -n 1)
+name=${name%@*}
+case "${name##*/}" in *:*) name=${name%:*} ;; esac
+if [ -z "${name}" ]; then
+  echo "failed to find the image of service ${SERVICE_NAME}" >&2
+  exit 1
+fi
+repo=${IMAGE%@*}
+digest=""
+tag=""
+if [ "${repo}" != "${IMAGE}" ]; then
+  digest=${IMAGE#*@}
+else
+  case "${IMAGE##*/}" in *:*) repo=${IMAGE%:*}; tag=${IMAGE##*:} ;; esac
+fi
+awk '/^images:/ { skip = 1; next } skip && /^[ -]/ { next } { skip = 0; print }' overlays/kustomization.yaml > /tmp/kustomization.yaml
+{
+  cat /tmp/kustomization.yaml
+  echo "images:"
+  echo "- name: \"${name}\""
+  if [ "${repo}" != "${name}" ]; then echo "  newName: \"${repo}\""; fi
+  if [ -n "${tag}" ]; then echo "  newTag: \"${tag}\""; fi
+  if [ -n "${digest}" ]; then echo "  digest: \"${digest}\""; fi
+} > overlays/kustomization.yaml
+`
+
+const pushBranchScript = `#!/usr/bin/env sh
+set -e
+cd "$(workspaces.source.path)"
+git config user.name "${GIT_USER_NAME}"
+git config user.email "${GIT_USER_EMAIL}"
+git checkout -b "${BRANCH}"
+git add --all
+git commit -m "Update image to ${IMAGE}"
+git push origin "${BRANCH}"
+`
+
+const openPullRequestScript = `#!/usr/bin/env python3
+import json
+import os
+import sys
+import urllib.parse
+import urllib.request
+
+driver = os.environ["DRIVER"]
+repo = os.environ["REPO"]
+url = urllib.parse.urlparse(os.environ["GIT_REPO"])
+token = os.environ["GITHOSTACCESSTOKEN"]
+branch = os.environ["BRANCH"]
+base_branch = os.environ["BASE_BRANCH"]
+title = "Update image to %s" % os.environ["IMAGE"]
+
+if driver == "github":
+    api = "https://api.github.com" if url.netloc == "github.com" else "%s://%s/api/v3" % (url.scheme, url.netloc)
+    endpoint = "%s/repos/%s/pulls" % (api, repo)
+    body = {"title": title, "head": branch, "base": base_branch}
+    headers = {"Authorization": "token " + token, "Accept": "application/vnd.github.v3+json"}
+elif driver == "gitea":
+    endpoint = "%s://%s/api/v1/repos/%s/pulls" % (url.scheme, url.netloc, repo)
+    body = {"title": title, "head": branch, "base": base_branch}
+    headers = {"Authorization": "token " + token}
+elif driver == "gitlab":
+    endpoint = "%s://%s/api/v4/projects/%s/merge_requests" % (url.scheme, url.netloc, urllib.parse.quote(repo, safe=""))
+    body = {"title": title, "source_branch": branch, "target_branch": base_branch}
+    headers = {"PRIVATE-TOKEN": token}
+else:
+    sys.exit("unsupported driver %s" % driver)
+
+headers["Content-Type"] = "application/json"
+request = urllib.request.Request(endpoint, data=json.dumps(body).encode("utf-8"), headers=headers, method="POST")
+with urllib.request.urlopen(request) as response:
+    created = json.load(response)
+print("opened pull request %s" % (created.get("html_url") or created.get("web_url")))
+`
+
+// CreateUpdateImageTask creates a task that sets the image of a service in the
+// GitOps repository in the source workspace, pushes the change to a branch and
+// opens a pull request for it.
+func CreateUpdateImageTask(ns string) *pipelinev1.Task {
+	tokenEnv := corev1.EnvVar{
+		Name: "GITHOSTACCESSTOKEN",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: "$(params.GIT_TOKEN_SECRET_NAME)",
+				},
+				Key: "$(params.GIT_TOKEN_SECRET_KEY)",
+			},
+		},
+	}
+	return &pipelinev1.Task{
+		TypeMeta:   taskTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, UpdateImageTaskName)),
+		Spec: pipelinev1.TaskSpec{
+			Params: []pipelinev1.ParamSpec{
+				createTaskParam("GIT_REPO", "The URL of the GitOps repository.", pipelinev1.ParamTypeString),
+				createTaskParam("REPO", "The full name of the GitOps repository, e.g. org/repo.", pipelinev1.ParamTypeString),
+				createTaskParam("DRIVER", "The driver for the GitOps repository's host, one of github, gitlab or gitea.", pipelinev1.ParamTypeString),
+				createTaskParam("ENV_NAME", "The environment of the service to update.", pipelinev1.ParamTypeString),
+				createTaskParam("APP_NAME", "The application of the service to update.", pipelinev1.ParamTypeString),
+				createTaskParam("SERVICE_NAME", "The name of the service to update.", pipelinev1.ParamTypeString),
+				createTaskParam("IMAGE", "The image to update the service to.", pipelinev1.ParamTypeString),
+				createTaskParam("BRANCH", "The branch to push the change to.", pipelinev1.ParamTypeString),
+				createTaskParamWithDefault("BASE_BRANCH", "The branch to open the pull request against.", pipelinev1.ParamTypeString, "main"),
+				createTaskParamWithDefault("GIT_USER_NAME", "The name of the commit author.", pipelinev1.ParamTypeString, "kam"),
+				createTaskParamWithDefault("GIT_USER_EMAIL", "The email of the commit author.", pipelinev1.ParamTypeString, "kam@redhat.com"),
+				createTaskParamWithDefault("GIT_TOKEN_SECRET_NAME", "", pipelinev1.ParamTypeString, "git-host-access-token"),
+				createTaskParamWithDefault("GIT_TOKEN_SECRET_KEY", "", pipelinev1.ParamTypeString, "token"),
+				createTaskParamWithDefault("GIT_IMAGE", "The image to run git, and to set the image, in.", pipelinev1.ParamTypeString, gitImage),
+				createTaskParamWithDefault("PYTHON_IMAGE", "The image to open the pull request in, with Python 3.", pipelinev1.ParamTypeString, pythonImage),
+			},
+			Workspaces: []pipelinev1.WorkspaceDeclaration{
+				{Name: "source", Description: "The workspace with the clone of the GitOps repository."},
+			},
+			Steps: []pipelinev1.Step{
+				{
+					Container: corev1.Container{
+						Name:  "check-driver",
+						Image: "$(params.GIT_IMAGE)",
+						Env:   paramEnv("DRIVER"),
+					},
+					Script: checkDriverScript,
+				},
+				{
+					Container: corev1.Container{
+						Name:  "set-image",
+						Image: "$(params.GIT_IMAGE)",
+						Env:   paramEnv("ENV_NAME", "APP_NAME", "SERVICE_NAME", "IMAGE"),
+					},
+					Script: setImageScript,
+				},
+				{
+					Container: corev1.Container{
+						Name:  "push-branch",
+						Image: "$(params.GIT_IMAGE)",
+						Env:   paramEnv("GIT_USER_NAME", "GIT_USER_EMAIL", "BRANCH", "IMAGE"),
+					},
+					Script: pushBranchScript,
+				},
+				{
+					Container: corev1.Container{
+						Name:  "open-pull-request",
+						Image: "$(params.PYTHON_IMAGE)",
+						Env:   append(paramEnv("DRIVER", "REPO", "GIT_REPO", "IMAGE", "BRANCH", "BASE_BRANCH"), tokenEnv),
+					},
+					Script: openPullRequestScript,
+				},
+			},
+		},
+	}
+}
+
+// paramEnv returns environment variables with the values of the params with
+// the same names.
+func paramEnv(names ...string) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for _, name := range names {
+		env = append(env, corev1.EnvVar{Name: name, Value: "$(params." + name + ")"})
+	}
+	return env
+}
@@ -0,0 +1,122 @@
+package tekton
+
+import (
+	"encoding/json"
+
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+)
+
+// ClusterTasksNamespace is the namespace that OpenShift Pipelines installs
+// the Tasks that it ships as ClusterTasks in, tekton.dev/v1 Pipelines refer
+// to them with the cluster resolver.
+const ClusterTasksNamespace = "openshift-pipelines"
+
+// ConvertTask converts a tekton.dev/v1beta1 Task to the version.
+//
+// There are no Go types for tekton.dev/v1, so the Task is converted field by
+// field, and returned as an unstructured object.
+func ConvertTask(t *pipelinev1.Task, v Version) interface{} {
+	if v != V1 {
+		return t
+	}
+	task, ok := toObject(t)
+	if !ok {
+		return t
+	}
+	task["apiVersion"] = v.PipelinesAPIVersion()
+	if spec, ok := task["spec"].(map[string]interface{}); ok {
+		convertTaskSpec(spec)
+	}
+	return task
+}
+
+// ConvertPipeline converts a tekton.dev/v1beta1 Pipeline to the version.
+//
+// There are no Go types for tekton.dev/v1, so the Pipeline is converted field
+// by field, and returned as an unstructured object.
+func ConvertPipeline(p *pipelinev1.Pipeline, v Version) interface{} {
+	if v != V1 {
+		return p
+	}
+	pipeline, ok := toObject(p)
+	if !ok {
+		return p
+	}
+	pipeline["apiVersion"] = v.PipelinesAPIVersion()
+	if spec, ok := pipeline["spec"].(map[string]interface{}); ok {
+		for _, key := range []string{"tasks", "finally"} {
+			for _, task := range objects(spec[key]) {
+				convertPipelineTask(task)
+			}
+		}
+	}
+	return pipeline
+}
+
+// convertPipelineTask converts the embedded Task, and replaces the reference
+// to a ClusterTask, which are deprecated with tekton.dev/v1, with a cluster
+// resolver reference to the Task in the ClusterTasksNamespace.
+func convertPipelineTask(task map[string]interface{}) {
+	if spec, ok := task["taskSpec"].(map[string]interface{}); ok {
+		// the spec of custom tasks is always written, even if it's null.
+		if spec["spec"] == nil {
+			delete(spec, "spec")
+		}
+		convertTaskSpec(spec)
+	}
+	ref, ok := task["taskRef"].(map[string]interface{})
+	if !ok || ref["kind"] != string(pipelinev1.ClusterTaskKind) {
+		return
+	}
+	task["taskRef"] = map[string]interface{}{
+		"resolver": "cluster",
+		"params": []interface{}{
+			map[string]interface{}{"name": "kind", "value": "task"},
+			map[string]interface{}{"name": "name", "value": ref["name"]},
+			map[string]interface{}{"name": "namespace", "value": ClusterTasksNamespace},
+		},
+	}
+}
+
+// convertTaskSpec converts the resources of the containers, which are
+// computeResources in tekton.dev/v1.
+func convertTaskSpec(spec map[string]interface{}) {
+	containers := append(objects(spec["steps"]), objects(spec["sidecars"])...)
+	if stepTemplate, ok := spec["stepTemplate"].(map[string]interface{}); ok {
+		containers = append(containers, stepTemplate)
+	}
+	for _, c := range containers {
+		resources, ok := c["resources"]
+		if !ok {
+			continue
+		}
+		delete(c, "resources")
+		if r, ok := resources.(map[string]interface{}); ok && len(r) == 0 {
+			continue
+		}
+		c["computeResources"] = resources
+	}
+}
+
+func toObject(v interface{}) (map[string]interface{}, bool) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+func objects(v interface{}) []map[string]interface{} {
+	items, _ := v.([]interface{})
+	objs := []map[string]interface{}{}
+	for _, item := range items {
+		if obj, ok := item.(map[string]interface{}); ok {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
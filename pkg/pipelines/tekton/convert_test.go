@@ -0,0 +1,126 @@
+package tekton
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvertTask(t *testing.T) {
+	task := &pipelinev1.Task{
+		TypeMeta:   metav1.TypeMeta{Kind: "Task", APIVersion: "tekton.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-task"},
+		Spec: pipelinev1.TaskSpec{
+			Steps: []pipelinev1.Step{
+				{Container: corev1.Container{Name: "build", Image: "golang"}},
+				{Container: corev1.Container{
+					Name:  "test",
+					Image: "golang",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				}},
+			},
+		},
+	}
+
+	got := ConvertTask(task, V1).(map[string]interface{})
+
+	want := map[string]interface{}{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       "Task",
+		"metadata":   map[string]interface{}{"name": "test-task", "creationTimestamp": nil},
+		"spec": map[string]interface{}{
+			"steps": []interface{}{
+				map[string]interface{}{"name": "build", "image": "golang"},
+				map[string]interface{}{
+					"name":             "test",
+					"image":            "golang",
+					"computeResources": map[string]interface{}{"limits": map[string]interface{}{"memory": "1Gi"}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ConvertTask() failed:\n%s", diff)
+	}
+}
+
+func TestConvertPipeline(t *testing.T) {
+	pipeline := &pipelinev1.Pipeline{
+		TypeMeta:   metav1.TypeMeta{Kind: "Pipeline", APIVersion: "tekton.dev/v1beta1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pipeline"},
+		Spec: pipelinev1.PipelineSpec{
+			Tasks: []pipelinev1.PipelineTask{
+				{Name: "clone", TaskRef: &pipelinev1.TaskRef{Name: "git-clone", Kind: pipelinev1.ClusterTaskKind}},
+				{
+					Name: "test",
+					TaskSpec: &pipelinev1.EmbeddedTask{
+						TaskSpec: pipelinev1.TaskSpec{
+							Steps: []pipelinev1.Step{{Container: corev1.Container{Name: "test", Image: "golang"}}},
+						},
+					},
+				},
+			},
+			Finally: []pipelinev1.PipelineTask{
+				{Name: "status", TaskRef: &pipelinev1.TaskRef{Name: "set-commit-status", Kind: pipelinev1.NamespacedTaskKind}},
+			},
+		},
+	}
+
+	got := ConvertPipeline(pipeline, V1).(map[string]interface{})
+
+	want := map[string]interface{}{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       "Pipeline",
+		"metadata":   map[string]interface{}{"name": "test-pipeline", "creationTimestamp": nil},
+		"spec": map[string]interface{}{
+			"tasks": []interface{}{
+				map[string]interface{}{
+					"name": "clone",
+					"taskRef": map[string]interface{}{
+						"resolver": "cluster",
+						"params": []interface{}{
+							map[string]interface{}{"name": "kind", "value": "task"},
+							map[string]interface{}{"name": "name", "value": "git-clone"},
+							map[string]interface{}{"name": "namespace", "value": "openshift-pipelines"},
+						},
+					},
+				},
+				map[string]interface{}{
+					"name": "test",
+					"taskSpec": map[string]interface{}{
+						"metadata": map[string]interface{}{},
+						"steps": []interface{}{
+							map[string]interface{}{"name": "test", "image": "golang"},
+						},
+					},
+				},
+			},
+			"finally": []interface{}{
+				map[string]interface{}{
+					"name":    "status",
+					"taskRef": map[string]interface{}{"name": "set-commit-status", "kind": "Task"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ConvertPipeline() failed:\n%s", diff)
+	}
+}
+
+func TestConvertToV1Beta1(t *testing.T) {
+	task := &pipelinev1.Task{ObjectMeta: metav1.ObjectMeta{Name: "test-task"}}
+	if got := ConvertTask(task, V1Beta1); got != task {
+		t.Fatalf("ConvertTask() converted a v1beta1 Task: %#v", got)
+	}
+	pipeline := &pipelinev1.Pipeline{ObjectMeta: metav1.ObjectMeta{Name: "test-pipeline"}}
+	if got := ConvertPipeline(pipeline, V1Beta1); got != pipeline {
+		t.Fatalf("ConvertPipeline() converted a v1beta1 Pipeline: %#v", got)
+	}
+}
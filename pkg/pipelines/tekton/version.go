@@ -0,0 +1,55 @@
+package tekton
+
+const (
+	pipelinesGroup = "tekton.dev"
+	triggersGroup  = "triggers.tekton.dev"
+)
+
+// Version is the version of the Tekton APIs that the Pipelines, Tasks and
+// Triggers resources are generated for.
+type Version string
+
+const (
+	// V1Beta1 generates tekton.dev/v1beta1 Pipelines, Tasks and PipelineRuns,
+	// and triggers.tekton.dev/v1alpha1 Triggers resources.
+	V1Beta1 Version = "v1beta1"
+
+	// V1 generates tekton.dev/v1 Pipelines, Tasks and PipelineRuns, and
+	// triggers.tekton.dev/v1beta1 Triggers resources.
+	V1 Version = "v1"
+)
+
+// Versions are the versions that resources can be generated for.
+var Versions = []Version{V1Beta1, V1}
+
+// IsValid returns true if resources can be generated for the version, the
+// empty version is V1Beta1.
+func (v Version) IsValid() bool {
+	if v == "" {
+		return true
+	}
+	for _, version := range Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// PipelinesAPIVersion returns the apiVersion of the Pipelines, Tasks and
+// PipelineRuns.
+func (v Version) PipelinesAPIVersion() string {
+	if v == V1 {
+		return pipelinesGroup + "/v1"
+	}
+	return pipelinesGroup + "/v1beta1"
+}
+
+// TriggersAPIVersion returns the apiVersion of the EventListeners,
+// TriggerBindings and TriggerTemplates.
+func (v Version) TriggersAPIVersion() string {
+	if v == V1 {
+		return triggersGroup + "/v1beta1"
+	}
+	return triggersGroup + "/v1alpha1"
+}
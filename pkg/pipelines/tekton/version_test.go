@@ -0,0 +1,33 @@
+package tekton
+
+import (
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	versionTests := []struct {
+		version   Version
+		valid     bool
+		pipelines string
+		triggers  string
+	}{
+		{"", true, "tekton.dev/v1beta1", "triggers.tekton.dev/v1alpha1"},
+		{V1Beta1, true, "tekton.dev/v1beta1", "triggers.tekton.dev/v1alpha1"},
+		{V1, true, "tekton.dev/v1", "triggers.tekton.dev/v1beta1"},
+		{"v1alpha1", false, "tekton.dev/v1beta1", "triggers.tekton.dev/v1alpha1"},
+	}
+
+	for _, tt := range versionTests {
+		t.Run(string(tt.version), func(rt *testing.T) {
+			if tt.version.IsValid() != tt.valid {
+				rt.Fatalf("IsValid() got %v, want %v", !tt.valid, tt.valid)
+			}
+			if got := tt.version.PipelinesAPIVersion(); got != tt.pipelines {
+				rt.Fatalf("PipelinesAPIVersion() got %q, want %q", got, tt.pipelines)
+			}
+			if got := tt.version.TriggersAPIVersion(); got != tt.triggers {
+				rt.Fatalf("TriggersAPIVersion() got %q, want %q", got, tt.triggers)
+			}
+		})
+	}
+}
@@ -2,18 +2,30 @@ package pipelines
 
 import (
 	"fmt"
+	"net/url"
 	"path/filepath"
 
+	"github.com/redhat-developer/kam/pkg/pipelines/chains"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 	"github.com/redhat-developer/kam/pkg/pipelines/eventlisteners"
+	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/pipelines"
 	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
 	"github.com/redhat-developer/kam/pkg/pipelines/scm"
+	"github.com/redhat-developer/kam/pkg/pipelines/tasks"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
+	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	"github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
 )
 
 type tektonBuilder struct {
 	files      res.Resources
 	gitOpsRepo string
+	cicdPath   string
+	cicdNS     string
+	driver     string
+	cfg        *config.PipelinesConfig
 	triggers   []v1alpha1.EventListenerTrigger
 }
 
@@ -26,19 +38,30 @@ func buildEventListenerResources(gitOpsRepo string, m *config.Manifest) (res.Res
 		return nil, nil
 	}
 	files := make(res.Resources)
-	tb := &tektonBuilder{files: files, gitOpsRepo: gitOpsRepo}
+	cicdPath := config.PathForPipelines(cfg)
+	tb := &tektonBuilder{files: files, gitOpsRepo: gitOpsRepo, cicdPath: cicdPath, cicdNS: cfg.Name, driver: gitOpsRepoDriver(m), cfg: cfg}
 	triggers, err := createTriggersForCICD(tb.gitOpsRepo, cfg)
 	if err != nil {
 		return nil, err
 	}
 	tb.triggers = append(tb.triggers, triggers...)
+	repo, err := scm.NewRepository(gitOpsRepo)
+	if err != nil {
+		return nil, err
+	}
+	tb.addPRBinding(repo)
+	for k, v := range res.Merge(createPRResources(cfg.Name, gitOpsRepoDriver(m)), createCDResources(cfg, gitOpsRepoDriver(m))) {
+		files[getCICDBasePath(cicdPath, k)] = v
+	}
+	if cfg.SupplyChain != nil {
+		files[getCICDBasePath(cicdPath, chainsConfigPath)] = chains.CreateConfig(cfg.SupplyChain.Namespace, cfg.SupplyChain.Transparency)
+	}
 	err = m.Walk(tb)
 	if err != nil {
 		return nil, err
 	}
-	cicdPath := config.PathForPipelines(cfg)
 	files[getEventListenerPath(cicdPath)] = eventlisteners.CreateELFromTriggers(cfg.Name, saName, tb.triggers)
-	return files, nil
+	return convertResources(files, cfg.TektonAPIVersion), nil
 }
 
 func (tb *tektonBuilder) Service(app *config.Application, env *config.Environment, svc *config.Service) error {
@@ -50,16 +73,217 @@ func (tb *tektonBuilder) Service(app *config.Application, env *config.Environmen
 		return err
 	}
 	pipelines := getPipelines(env, svc, repo)
-	ciTrigger, err := repo.CreatePushTrigger(triggerName(svc.Name), svc.Webhook.Secret.Name, svc.Webhook.Secret.Namespace, pipelines.Integration.Template, pipelines.Integration.Bindings)
+	template := pipelines.Integration.Template
+	if len(pipelines.Stages) > 0 {
+		files, name, err := stagesResources(tb.cfg, tb.driver, stagesPipelineName(env, svc, template), template, pipelines.Stages)
+		if err != nil {
+			return fmt.Errorf("failed to add the stages for service %s in environment %s: %w", svc.Name, env.Name, err)
+		}
+		for k, v := range files {
+			tb.files[getCICDBasePath(tb.cicdPath, k)] = v
+		}
+		template = name
+	}
+	ciTrigger, err := repo.CreatePushTrigger(triggerName(svc.Name), svc.Webhook.Secret.Name, svc.Webhook.Secret.Namespace, template, pipelines.Integration.Bindings)
+	if err != nil {
+		return err
+	}
+	prTrigger, err := repo.CreatePRTrigger(prTriggerName(svc.Name), svc.Webhook.Secret.Name, svc.Webhook.Secret.Namespace, appCIPRTemplateName, prBindings(repo, pipelines.Integration.Bindings))
 	if err != nil {
 		return err
 	}
-	tb.triggers = append(tb.triggers, ciTrigger)
+	tb.triggers = append(tb.triggers, ciTrigger, prTrigger)
+	tb.addPRBinding(repo)
+	if t := catalogTemplate(pipelines.Integration.Template); t != nil && len(pipelines.Stages) == 0 {
+		for k, v := range pipelineTemplateResources(tb.cfg, tb.driver, t) {
+			tb.files[getCICDBasePath(tb.cicdPath, k)] = v
+		}
+	}
 	return nil
 }
 
+// addPRBinding adds the pull request TriggerBinding for the repository's
+// driver to the CICD base, once per driver.
+func (tb *tektonBuilder) addPRBinding(repo scm.Repository) {
+	binding, name := repo.CreatePRBinding(tb.cicdNS)
+	tb.files[getCICDBasePath(tb.cicdPath, prBindingPath(name))] = binding
+}
+
 func getEventListenerPath(cicdPath string) string {
-	return filepath.ToSlash(filepath.Join(cicdPath, "base", eventListenerPath))
+	return getCICDBasePath(cicdPath, eventListenerPath)
+}
+
+func getCICDBasePath(cicdPath, filename string) string {
+	return filepath.ToSlash(filepath.Join(cicdPath, "base", filename))
+}
+
+func prBindingPath(name string) string {
+	return filepath.ToSlash(filepath.Join("05-bindings", name+".yaml"))
+}
+
+// convertResources converts the Tekton resources, which are generated for
+// v1beta1 of the Tekton APIs, to the version, the converted Tasks and
+// Pipelines are unstructured objects.
+func convertResources(files res.Resources, v tekton.Version) res.Resources {
+	if v == "" || v == tekton.V1Beta1 {
+		return files
+	}
+	for k, r := range files {
+		switch r := r.(type) {
+		case pipelinev1.Task:
+			files[k] = tekton.ConvertTask(&r, v)
+		case *pipelinev1.Task:
+			files[k] = tekton.ConvertTask(r, v)
+		case *pipelinev1.Pipeline:
+			files[k] = tekton.ConvertPipeline(r, v)
+		case v1alpha1.TriggerBinding:
+			r.APIVersion = v.TriggersAPIVersion()
+			files[k] = r
+		case v1alpha1.TriggerTemplate:
+			triggers.ConvertTemplate(&r, v)
+			files[k] = r
+		case v1alpha1.EventListener:
+			r.APIVersion = v.TriggersAPIVersion()
+			files[k] = r
+		case *v1alpha1.EventListener:
+			r.APIVersion = v.TriggersAPIVersion()
+		}
+	}
+	return files
+}
+
+// createPRResources returns the Pipeline and TriggerTemplates that are started
+// by the pull request triggers, keyed by their path in the CICD base.
+func createPRResources(cicdNS, driver string) res.Resources {
+	return res.Resources{
+		appCIPRPipelinesPath: removeCommitStatus(pipelines.CreateAppCIPRPipeline(meta.NamespacedName(cicdNS, "app-ci-pr-pipeline")), driver),
+		prTemplatePath:       triggers.CreateCIDryRunPRTemplate(cicdNS, saName),
+		appCIPRTemplatePath:  triggers.CreateDevCIBuildFromPRTemplate(cicdNS, saName),
+	}
+}
+
+// createCDResources returns the Task, Pipeline and TriggerTemplate that build
+// a service's image and open a pull request to update the GitOps repository
+// with it, keyed by their path in the CICD base.
+func createCDResources(cfg *config.PipelinesConfig, driver string) res.Resources {
+	cicdNS := cfg.Name
+	return res.Resources{
+		updateImageTaskPath: tasks.CreateUpdateImageTask(cicdNS),
+		appCDPipelinesPath:  removeCommitStatus(securePipeline(pipelines.CreateAppCDPipeline(meta.NamespacedName(cicdNS, "app-cd-pipeline")), cfg), driver),
+		appCDTemplatePath:   triggers.CreateDevCDDeployTemplate(cicdNS, saName),
+	}
+}
+
+// pipelineTemplateResources returns the Pipeline and TriggerTemplate for a
+// template in the catalog of pipeline templates, keyed by their path in the
+// CICD base.
+func pipelineTemplateResources(cfg *config.PipelinesConfig, driver string, t *pipelines.Template) res.Resources {
+	cicdNS := cfg.Name
+	pipelineName := fmt.Sprintf("app-cd-%s-pipeline", t.Name)
+	pipelinePath := filepath.ToSlash(filepath.Join("04-pipelines", pipelineName+".yaml"))
+	templatePath := filepath.ToSlash(filepath.Join("06-templates", fmt.Sprintf("app-cd-%s-build-from-push-template.yaml", t.Name)))
+	return res.Resources{
+		pipelinePath: removeCommitStatus(securePipeline(pipelines.CreateTemplatePipeline(meta.NamespacedName(cicdNS, pipelineName), t), cfg), driver),
+		templatePath: triggers.CreateAppCDTemplate(cicdNS, templateTriggerTemplateName(t), pipelineName, saName),
+	}
+}
+
+// stagesResources returns the Pipeline and TriggerTemplate that run a
+// service's pre-build stages before building the image with the pipeline
+// that's started by the template, keyed by their path in the CICD base, and
+// the name of the TriggerTemplate.
+func stagesResources(cfg *config.PipelinesConfig, driver, name, template string, stages []*config.Stage) (res.Resources, string, error) {
+	cicdNS := cfg.Name
+	pipelineName := name + "-pipeline"
+	templateName := name + "-template"
+	var pipeline *pipelinev1.Pipeline
+	var triggerTemplate interface{}
+	switch {
+	case template == appCITemplateName:
+		pipeline = pipelines.CreateAppCIPipeline(meta.NamespacedName(cicdNS, pipelineName))
+		triggerTemplate = triggers.CreateAppCIPipelineTemplate(cicdNS, templateName, pipelineName, saName)
+	case template == appCDTemplateName || catalogTemplate(template) != nil:
+		t := catalogTemplate(template)
+		if t == nil {
+			t = pipelines.DefaultTemplate
+		}
+		pipeline = pipelines.CreateTemplatePipeline(meta.NamespacedName(cicdNS, pipelineName), t)
+		triggerTemplate = triggers.CreateAppCDTemplate(cicdNS, templateName, pipelineName, saName)
+	default:
+		return nil, "", fmt.Errorf("stages can't be added to the pipeline started by template %q, the template must be %s, %s, or the template of a pipeline template", template, appCITemplateName, appCDTemplateName)
+	}
+	if err := pipelines.AddStages(pipeline, stages); err != nil {
+		return nil, "", err
+	}
+	return res.Resources{
+		filepath.ToSlash(filepath.Join("04-pipelines", pipelineName+".yaml")):                  removeCommitStatus(securePipeline(pipeline, cfg), driver),
+		filepath.ToSlash(filepath.Join("06-templates", name+"-build-from-push-template.yaml")): triggerTemplate,
+	}, templateName, nil
+}
+
+// stagesPipelineName returns the name for the pipeline with a service's
+// stages, the environment is in the name as services in different
+// environments can have the same name.
+func stagesPipelineName(env *config.Environment, svc *config.Service, template string) string {
+	prefix := "app-cd"
+	if template == appCITemplateName {
+		prefix = "app-ci"
+	}
+	return fmt.Sprintf("%s-%s-%s", prefix, env.Name, svc.Name)
+}
+
+// templateTriggerTemplateName returns the name of the TriggerTemplate that
+// starts the pipeline for a template in the catalog, the default template's
+// pipeline is started by the app-cd-template.
+func templateTriggerTemplateName(t *pipelines.Template) string {
+	if t == pipelines.DefaultTemplate {
+		return appCDTemplateName
+	}
+	return fmt.Sprintf("app-cd-%s-template", t.Name)
+}
+
+// catalogTemplate returns the template in the catalog that's started by the
+// named TriggerTemplate, or nil if it's not the TriggerTemplate of a template,
+// or it's the default template, which is always generated.
+func catalogTemplate(triggerTemplate string) *pipelines.Template {
+	for _, t := range pipelines.Templates {
+		if t != pipelines.DefaultTemplate && templateTriggerTemplateName(t) == triggerTemplate {
+			return t
+		}
+	}
+	return nil
+}
+
+// gitOpsRepoDriver returns the driver configured for the GitOps repository's
+// host, or "" if the host is a well-known one.
+func gitOpsRepoDriver(m *config.Manifest) string {
+	if m.Config == nil || m.Config.Git == nil {
+		return ""
+	}
+	u, err := url.Parse(m.GitOpsURL)
+	if err != nil {
+		return ""
+	}
+	return m.Config.Git.Drivers[u.Host]
+}
+
+// prBindings replaces the push binding for the repository with the pull
+// request binding, so that the PR trigger gets its git parameters from the
+// pull request payload.
+func prBindings(repo scm.Repository, bindings []string) []string {
+	result := []string{}
+	found := false
+	for _, b := range bindings {
+		if b == repo.PushBindingName() {
+			b = repo.PRBindingName()
+			found = true
+		}
+		result = append(result, b)
+	}
+	if !found {
+		result = append([]string{repo.PRBindingName()}, result...)
+	}
+	return result
 }
 
 func createTriggersForCICD(gitOpsRepo string, cfg *config.PipelinesConfig) ([]v1alpha1.EventListenerTrigger, error) {
@@ -72,7 +296,11 @@ func createTriggersForCICD(gitOpsRepo string, cfg *config.PipelinesConfig) ([]v1
 	if err != nil {
 		return []v1alpha1.EventListenerTrigger{}, err
 	}
-	triggers = append(triggers, ciTrigger)
+	prTrigger, err := repo.CreatePRTrigger("ci-dryrun-from-pr", eventlisteners.GitOpsWebhookSecret, cfg.Name, "ci-dryrun-from-pr-template", []string{repo.PRBindingName()})
+	if err != nil {
+		return []v1alpha1.EventListenerTrigger{}, err
+	}
+	triggers = append(triggers, ciTrigger, prTrigger)
 	return triggers, nil
 }
 
@@ -80,27 +308,43 @@ func getPipelines(env *config.Environment, svc *config.Service, r scm.Repository
 	pipelines := defaultPipelines(r)
 	if env.Pipelines != nil {
 		pipelines = clonePipelines(env.Pipelines)
+		// the environment can have stages without overriding the template.
+		if pipelines.Integration == nil {
+			pipelines.Integration = defaultPipelines(r).Integration
+		}
 	}
 	if svc.Pipelines != nil {
-		if len(svc.Pipelines.Integration.Bindings) > 0 {
-			pipelines.Integration.Bindings = svc.Pipelines.Integration.Bindings
+		if integration := svc.Pipelines.Integration; integration != nil {
+			if len(integration.Bindings) > 0 {
+				pipelines.Integration.Bindings = integration.Bindings
+			}
+			if integration.Template != "" {
+				pipelines.Integration.Template = integration.Template
+			}
 		}
-		if svc.Pipelines.Integration.Template != "" {
-			pipelines.Integration.Template = svc.Pipelines.Integration.Template
+		// the service's stages replace the environment's stages.
+		if len(svc.Pipelines.Stages) > 0 {
+			pipelines.Stages = svc.Pipelines.Stages
 		}
 	}
 	return pipelines
 }
 
 func clonePipelines(p *config.Pipelines) *config.Pipelines {
-	return &config.Pipelines{
-		Integration: &config.TemplateBinding{
+	cloned := &config.Pipelines{Stages: p.Stages}
+	if p.Integration != nil {
+		cloned.Integration = &config.TemplateBinding{
 			Bindings: p.Integration.Bindings,
 			Template: p.Integration.Template,
-		},
+		}
 	}
+	return cloned
 }
 
 func triggerName(svc string) string {
 	return fmt.Sprintf("app-ci-build-from-push-%s", svc)
 }
+
+func prTriggerName(svc string) string {
+	return fmt.Sprintf("app-ci-build-from-pr-%s", svc)
+}
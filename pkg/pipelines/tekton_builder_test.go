@@ -6,10 +6,15 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/redhat-developer/kam/pkg/pipelines/chains"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 	"github.com/redhat-developer/kam/pkg/pipelines/eventlisteners"
+	"github.com/redhat-developer/kam/pkg/pipelines/pipelines"
 	res "github.com/redhat-developer/kam/pkg/pipelines/resources"
 	"github.com/redhat-developer/kam/pkg/pipelines/scm"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
+	"github.com/redhat-developer/kam/pkg/pipelines/triggers"
+	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
 )
 
@@ -34,11 +39,175 @@ func TestBuildEventListener(t *testing.T) {
 	want := res.Resources{
 		getEventListenerPath(cicdPath): eventlisteners.CreateELFromTriggers("test-cicd", saName, fakeTriggers(t, m, testRepoName)),
 	}
+	want = res.Merge(fakePRResources(t, "test-cicd", cicdPath), want)
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Fatalf("resources didn't match:%s\n", diff)
 	}
 }
 
+func TestBuildEventListenerWithPipelineTemplate(t *testing.T) {
+	env := testEnv(testService(), "dev")
+	env.Pipelines.Integration.Template = "app-cd-maven-buildah-template"
+	m := &config.Manifest{
+		Config: &config.Config{
+			Pipelines: &config.PipelinesConfig{
+				Name: "test-cicd",
+			},
+		},
+		Environments: []*config.Environment{env},
+		GitOpsURL:    "http://github.com/org/gitops.git",
+	}
+	got, err := buildEventListenerResources(testRepoName, m)
+	assertNoError(t, err)
+
+	want := pipelineTemplateResources(&config.PipelinesConfig{Name: "test-cicd"}, "", pipelines.LookupTemplate("maven-buildah"))
+	for k, v := range want {
+		if diff := cmp.Diff(v, got[getCICDBasePath("config/test-cicd", k)]); diff != "" {
+			t.Fatalf("%s didn't match:%s\n", k, diff)
+		}
+	}
+	if _, ok := got["config/test-cicd/base/04-pipelines/app-cd-ko-pipeline.yaml"]; ok {
+		t.Fatal("resources for an unused pipeline template were generated")
+	}
+}
+
+func TestBuildEventListenerWithImageSigning(t *testing.T) {
+	cfg := &config.PipelinesConfig{
+		Name:         "test-cicd",
+		ImageSigning: &config.ImageSigning{Secret: "cosign-key"},
+	}
+	m := &config.Manifest{
+		Config: &config.Config{
+			Pipelines: cfg,
+		},
+		Environments: []*config.Environment{testEnv(testService(), "dev")},
+		GitOpsURL:    "http://github.com/org/gitops.git",
+	}
+	got, err := buildEventListenerResources(testRepoName, m)
+	assertNoError(t, err)
+
+	want := createCDResources(cfg, "")
+	for k, v := range want {
+		if diff := cmp.Diff(v, got[getCICDBasePath("config/test-cicd", k)]); diff != "" {
+			t.Fatalf("%s didn't match:%s\n", k, diff)
+		}
+	}
+	if diff := cmp.Diff(createCDResources(&config.PipelinesConfig{Name: "test-cicd"}, "")[appCDPipelinesPath], want[appCDPipelinesPath]); diff == "" {
+		t.Fatal("the app-cd-pipeline doesn't sign the image")
+	}
+}
+
+func TestBuildEventListenerWithSupplyChain(t *testing.T) {
+	m := &config.Manifest{
+		Config: &config.Config{
+			Pipelines: &config.PipelinesConfig{
+				Name:        "test-cicd",
+				SupplyChain: &config.SupplyChain{Namespace: "openshift-pipelines", Transparency: true},
+			},
+		},
+		Environments: []*config.Environment{testEnv(testService(), "dev")},
+		GitOpsURL:    "http://github.com/org/gitops.git",
+	}
+	got, err := buildEventListenerResources(testRepoName, m)
+	assertNoError(t, err)
+
+	if diff := cmp.Diff(chains.CreateConfig("openshift-pipelines", true), got[getCICDBasePath("config/test-cicd", chainsConfigPath)]); diff != "" {
+		t.Fatalf("chains config didn't match:\n%s", diff)
+	}
+	pipeline := got[getCICDBasePath("config/test-cicd", appCDPipelinesPath)].(*pipelinev1.Pipeline)
+	results := []string{}
+	for _, r := range pipeline.Spec.Results {
+		results = append(results, r.Name)
+	}
+	if diff := cmp.Diff([]string{"IMAGE_URL", "IMAGE_DIGEST"}, results); diff != "" {
+		t.Fatalf("the app-cd-pipeline results didn't match:\n%s", diff)
+	}
+}
+
+func TestBuildEventListenerWithTektonV1(t *testing.T) {
+	m := &config.Manifest{
+		Config: &config.Config{
+			Pipelines: &config.PipelinesConfig{
+				Name:             "test-cicd",
+				TektonAPIVersion: tekton.V1,
+			},
+		},
+		Environments: []*config.Environment{testEnv(testService(), "dev")},
+		GitOpsURL:    "http://github.com/org/gitops.git",
+	}
+	got, err := buildEventListenerResources(testRepoName, m)
+	assertNoError(t, err)
+
+	for k, v := range got {
+		var apiVersion string
+		switch r := v.(type) {
+		case map[string]interface{}:
+			apiVersion, _ = r["apiVersion"].(string)
+		case triggersv1.TriggerBinding:
+			apiVersion = r.APIVersion
+		case triggersv1.TriggerTemplate:
+			apiVersion = r.APIVersion
+		case *triggersv1.EventListener:
+			apiVersion = r.APIVersion
+		default:
+			t.Fatalf("%s has an unexpected type %T", k, v)
+		}
+		if apiVersion != "tekton.dev/v1" && apiVersion != "triggers.tekton.dev/v1beta1" {
+			t.Fatalf("%s wasn't converted to the v1 APIs, got %q", k, apiVersion)
+		}
+	}
+	template := got[getCICDBasePath("config/test-cicd", appCDTemplatePath)].(triggersv1.TriggerTemplate)
+	want := triggers.CreateDevCDDeployTemplate("test-cicd", saName)
+	triggers.ConvertTemplate(&want, tekton.V1)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("the app-cd-template wasn't converted:\n%s", diff)
+	}
+}
+
+func TestBuildEventListenerWithStages(t *testing.T) {
+	stages := []*config.Stage{{Name: "unit-tests", Image: "golang:1.17", Script: "go test ./..."}}
+	env := testEnv(testService(), "dev")
+	env.Pipelines.Integration.Template = "app-ci-template"
+	env.Pipelines.Stages = stages
+	m := &config.Manifest{
+		Config: &config.Config{
+			Pipelines: &config.PipelinesConfig{
+				Name: "test-cicd",
+			},
+		},
+		Environments: []*config.Environment{env},
+		GitOpsURL:    "http://github.com/org/gitops.git",
+	}
+	got, err := buildEventListenerResources(testRepoName, m)
+	assertNoError(t, err)
+
+	want, templateName, err := stagesResources(&config.PipelinesConfig{Name: "test-cicd"}, "", "app-ci-test-dev-test-svc", "app-ci-template", stages)
+	assertNoError(t, err)
+	for k, v := range want {
+		if diff := cmp.Diff(v, got[getCICDBasePath("config/test-cicd", k)]); diff != "" {
+			t.Fatalf("%s didn't match:%s\n", k, diff)
+		}
+	}
+	el := got[getEventListenerPath("config/test-cicd")].(*triggersv1.EventListener)
+	started := ""
+	for _, trigger := range el.Spec.Triggers {
+		if trigger.Name == triggerName("test-svc") {
+			started = *trigger.Template.Ref
+		}
+	}
+	if started != templateName {
+		t.Fatalf("push trigger started %q, want %q", started, templateName)
+	}
+}
+
+func TestStagesResourcesWithUnknownTemplate(t *testing.T) {
+	stages := []*config.Stage{{Name: "unit-tests", Image: "golang:1.17", Script: "go test ./..."}}
+	_, _, err := stagesResources(&config.PipelinesConfig{Name: "test-cicd"}, "", "app-cd-test-dev-test-svc", "my-template", stages)
+	if err == nil || err.Error() != `stages can't be added to the pipeline started by template "my-template", the template must be app-ci-template, app-cd-template, or the template of a pipeline template` {
+		t.Fatalf("stagesResources() failed: got %v", err)
+	}
+}
+
 func TestBuildEventListenerWithServiceWithNoURL(t *testing.T) {
 	m := &config.Manifest{
 
@@ -58,6 +227,7 @@ func TestBuildEventListenerWithServiceWithNoURL(t *testing.T) {
 	want := res.Resources{
 		getEventListenerPath(cicdPath): eventlisteners.CreateELFromTriggers("test-cicd", saName, fakeTriggers(t, m, gitOpsRepo)),
 	}
+	want = res.Merge(fakePRResources(t, "test-cicd", cicdPath), want)
 	if diff := cmp.Diff(want, got); diff != "" {
 		t.Fatalf("resources didn't match:%s\n", diff)
 	}
@@ -167,6 +337,48 @@ func TestGetPipelines(t *testing.T) {
 				},
 			},
 		},
+		{
+			"Stages are provided by environment",
+			&config.Environment{
+				Name: "test-env",
+				Pipelines: &config.Pipelines{
+					Stages: []*config.Stage{{Name: "unit-tests", Image: "golang:1.17", Script: "go test ./..."}},
+				},
+			},
+			&config.Service{
+				Name: "test-service",
+			},
+			&config.Pipelines{
+				Integration: &config.TemplateBinding{
+					Template: "app-ci-template",
+					Bindings: []string{"github-push-binding"},
+				},
+				Stages: []*config.Stage{{Name: "unit-tests", Image: "golang:1.17", Script: "go test ./..."}},
+			},
+		},
+		{
+			"Service stages replace the environment's stages",
+			&config.Environment{
+				Name: "test-env",
+				Pipelines: &config.Pipelines{
+					Integration: testPipelines("env").Integration,
+					Stages:      []*config.Stage{{Name: "unit-tests", Image: "golang:1.17", Script: "go test ./..."}},
+				},
+			},
+			&config.Service{
+				Name: "test-service",
+				Pipelines: &config.Pipelines{
+					Stages: []*config.Stage{{Name: "lint", Image: "golangci/golangci-lint:v1.45"}},
+				},
+			},
+			&config.Pipelines{
+				Integration: &config.TemplateBinding{
+					Template: "env-ci-template",
+					Bindings: []string{"env-ci-binding"},
+				},
+				Stages: []*config.Stage{{Name: "lint", Image: "golangci/golangci-lint:v1.45"}},
+			},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.desc, func(rt *testing.T) {
@@ -199,12 +411,47 @@ func fakeTriggers(t *testing.T, m *config.Manifest, gitOpsRepo string) []trigger
 		pipelines := getPipelines(env, svc, repo)
 		devCITrigger, err := repo.CreatePushTrigger(fmt.Sprintf("app-ci-build-from-push-%s", svc.Name), svc.Webhook.Secret.Name, svc.Webhook.Secret.Namespace, pipelines.Integration.Template, pipelines.Integration.Bindings)
 		assertNoError(t, err)
-		triggers = append(triggers, devCITrigger)
+		devCIPRTrigger, err := repo.CreatePRTrigger(fmt.Sprintf("app-ci-build-from-pr-%s", svc.Name), svc.Webhook.Secret.Name, svc.Webhook.Secret.Namespace, "app-ci-pr-template", append([]string{"github-pr-binding"}, pipelines.Integration.Bindings...))
+		assertNoError(t, err)
+		triggers = append(triggers, devCITrigger, devCIPRTrigger)
 	}
 
 	return triggers
 }
 
+func TestPRBindings(t *testing.T) {
+	repo, err := scm.NewRepository("https://github.com/foo/bar")
+	assertNoError(t, err)
+	tests := []struct {
+		bindings []string
+		want     []string
+	}{
+		{[]string{"svc-binding", "github-push-binding"}, []string{"svc-binding", "github-pr-binding"}},
+		{[]string{"svc-binding"}, []string{"github-pr-binding", "svc-binding"}},
+		{[]string{}, []string{"github-pr-binding"}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(rt *testing.T) {
+			if diff := cmp.Diff(tt.want, prBindings(repo, tt.bindings)); diff != "" {
+				rt.Errorf("prBindings() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func fakePRResources(t *testing.T, cicdNS, cicdPath string) res.Resources {
+	repo, err := scm.NewRepository(testRepoName)
+	assertNoError(t, err)
+	binding, name := repo.CreatePRBinding(cicdNS)
+	files := res.Resources{
+		getCICDBasePath(cicdPath, "05-bindings/"+name+".yaml"): binding,
+	}
+	for k, v := range res.Merge(createPRResources(cicdNS, ""), createCDResources(&config.PipelinesConfig{Name: cicdNS}, "")) {
+		files[getCICDBasePath(cicdPath, k)] = v
+	}
+	return files
+}
+
 func testService() *config.Service {
 	return &config.Service{
 		Name:      "test-svc",
@@ -0,0 +1,262 @@
+package pipelines
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+)
+
+// The vendored Tekton has no tekton.dev/v1 Go types, these are the fields of
+// the tekton.dev/v1 Tasks and Pipelines that the converted resources are
+// strictly decoded into, as the admission webhook rejects unknown fields.
+
+type v1Task struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              v1TaskSpec `json:"spec"`
+}
+
+type v1TaskSpec struct {
+	DisplayName  string                   `json:"displayName,omitempty"`
+	Description  string                   `json:"description,omitempty"`
+	Params       []v1ParamSpec            `json:"params,omitempty"`
+	Steps        []v1Step                 `json:"steps,omitempty"`
+	Volumes      []corev1.Volume          `json:"volumes,omitempty"`
+	StepTemplate *v1StepTemplate          `json:"stepTemplate,omitempty"`
+	Sidecars     []v1Sidecar              `json:"sidecars,omitempty"`
+	Workspaces   []v1WorkspaceDeclaration `json:"workspaces,omitempty"`
+	Results      []v1TaskResult           `json:"results,omitempty"`
+}
+
+type v1Step struct {
+	Name             string                      `json:"name"`
+	Image            string                      `json:"image,omitempty"`
+	Command          []string                    `json:"command,omitempty"`
+	Args             []string                    `json:"args,omitempty"`
+	WorkingDir       string                      `json:"workingDir,omitempty"`
+	EnvFrom          []corev1.EnvFromSource      `json:"envFrom,omitempty"`
+	Env              []corev1.EnvVar             `json:"env,omitempty"`
+	ComputeResources corev1.ResourceRequirements `json:"computeResources,omitempty"`
+	VolumeMounts     []corev1.VolumeMount        `json:"volumeMounts,omitempty"`
+	VolumeDevices    []corev1.VolumeDevice       `json:"volumeDevices,omitempty"`
+	ImagePullPolicy  corev1.PullPolicy           `json:"imagePullPolicy,omitempty"`
+	SecurityContext  *corev1.SecurityContext     `json:"securityContext,omitempty"`
+	Script           string                      `json:"script,omitempty"`
+	Timeout          *metav1.Duration            `json:"timeout,omitempty"`
+	OnError          string                      `json:"onError,omitempty"`
+}
+
+type v1StepTemplate struct {
+	Image            string                      `json:"image,omitempty"`
+	Command          []string                    `json:"command,omitempty"`
+	Args             []string                    `json:"args,omitempty"`
+	WorkingDir       string                      `json:"workingDir,omitempty"`
+	EnvFrom          []corev1.EnvFromSource      `json:"envFrom,omitempty"`
+	Env              []corev1.EnvVar             `json:"env,omitempty"`
+	ComputeResources corev1.ResourceRequirements `json:"computeResources,omitempty"`
+	VolumeMounts     []corev1.VolumeMount        `json:"volumeMounts,omitempty"`
+	ImagePullPolicy  corev1.PullPolicy           `json:"imagePullPolicy,omitempty"`
+	SecurityContext  *corev1.SecurityContext     `json:"securityContext,omitempty"`
+}
+
+type v1Sidecar struct {
+	Name             string                      `json:"name"`
+	Image            string                      `json:"image,omitempty"`
+	Command          []string                    `json:"command,omitempty"`
+	Args             []string                    `json:"args,omitempty"`
+	WorkingDir       string                      `json:"workingDir,omitempty"`
+	Env              []corev1.EnvVar             `json:"env,omitempty"`
+	ComputeResources corev1.ResourceRequirements `json:"computeResources,omitempty"`
+	VolumeMounts     []corev1.VolumeMount        `json:"volumeMounts,omitempty"`
+	Script           string                      `json:"script,omitempty"`
+}
+
+type v1ParamSpec struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+}
+
+type v1Param struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+type v1WorkspaceDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MountPath   string `json:"mountPath,omitempty"`
+	ReadOnly    bool   `json:"readOnly,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+}
+
+type v1TaskResult struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type v1Pipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              v1PipelineSpec `json:"spec"`
+}
+
+type v1PipelineSpec struct {
+	DisplayName string                           `json:"displayName,omitempty"`
+	Description string                           `json:"description,omitempty"`
+	Tasks       []v1PipelineTask                 `json:"tasks,omitempty"`
+	Params      []v1ParamSpec                    `json:"params,omitempty"`
+	Workspaces  []v1PipelineWorkspaceDeclaration `json:"workspaces,omitempty"`
+	Results     []v1PipelineResult               `json:"results,omitempty"`
+	Finally     []v1PipelineTask                 `json:"finally,omitempty"`
+}
+
+type v1PipelineTask struct {
+	Name       string                           `json:"name"`
+	TaskRef    *v1TaskRef                       `json:"taskRef,omitempty"`
+	TaskSpec   *v1EmbeddedTask                  `json:"taskSpec,omitempty"`
+	When       []v1WhenExpression               `json:"when,omitempty"`
+	RunAfter   []string                         `json:"runAfter,omitempty"`
+	Retries    int                              `json:"retries,omitempty"`
+	Params     []v1Param                        `json:"params,omitempty"`
+	Workspaces []v1WorkspacePipelineTaskBinding `json:"workspaces,omitempty"`
+	Timeout    *metav1.Duration                 `json:"timeout,omitempty"`
+}
+
+type v1TaskRef struct {
+	Name       string    `json:"name,omitempty"`
+	Kind       string    `json:"kind,omitempty"`
+	APIVersion string    `json:"apiVersion,omitempty"`
+	Resolver   string    `json:"resolver,omitempty"`
+	Params     []v1Param `json:"params,omitempty"`
+}
+
+type v1EmbeddedTask struct {
+	Metadata *v1PipelineTaskMetadata `json:"metadata,omitempty"`
+	v1TaskSpec
+}
+
+type v1PipelineTaskMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type v1WhenExpression struct {
+	Input    string   `json:"input,omitempty"`
+	Operator string   `json:"operator,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+type v1WorkspacePipelineTaskBinding struct {
+	Name      string `json:"name"`
+	Workspace string `json:"workspace,omitempty"`
+	SubPath   string `json:"subPath,omitempty"`
+}
+
+type v1PipelineWorkspaceDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Optional    bool   `json:"optional,omitempty"`
+}
+
+type v1PipelineResult struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Value       interface{} `json:"value"`
+}
+
+func TestBootstrapWithTektonV1(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	assertNoError(t, afero.WriteFile(fakeFs, "/keys/cosign.key", []byte("encrypted key"), 0600))
+	assertNoError(t, Bootstrap(&BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		GitHostAccessToken:   "test-token",
+		OutputPath:           "/gitops",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		CosignKey:            "/keys/cosign.key",
+		CosignPassword:       "secret",
+		TektonAPIVersion:     "v1",
+	}, fakeFs))
+	for _, template := range []string{"ko", "maven-buildah", "s2i-java"} {
+		assertNoError(t, AddService(&AddServiceOptions{
+			AppName:             "new-app",
+			EnvName:             "tst-dev",
+			GitRepoURL:          "http://github.com/org/" + template,
+			PipelinesFolderPath: "/gitops",
+			WebhookSecret:       "123",
+			ServiceName:         template,
+			PipelineTemplate:    template,
+		}, fakeFs))
+	}
+
+	resolved := map[string]bool{}
+	decoded := 0
+	err := afero.Walk(fakeFs, "/gitops/config/tst-cicd/base", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := afero.ReadFile(fakeFs, path)
+		if err != nil {
+			return err
+		}
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal(b, &typeMeta); err != nil {
+			return err
+		}
+		switch typeMeta.Kind {
+		case "Task":
+			if typeMeta.APIVersion != "tekton.dev/v1" {
+				t.Errorf("%s wasn't converted to tekton.dev/v1, got %q", path, typeMeta.APIVersion)
+			}
+			if err := yaml.UnmarshalStrict(b, &v1Task{}); err != nil {
+				t.Errorf("%s isn't a tekton.dev/v1 Task: %v", path, err)
+			}
+			decoded++
+		case "Pipeline":
+			if typeMeta.APIVersion != "tekton.dev/v1" {
+				t.Errorf("%s wasn't converted to tekton.dev/v1, got %q", path, typeMeta.APIVersion)
+			}
+			p := v1Pipeline{}
+			if err := yaml.UnmarshalStrict(b, &p); err != nil {
+				t.Errorf("%s isn't a tekton.dev/v1 Pipeline: %v", path, err)
+			}
+			for _, task := range append(p.Spec.Tasks, p.Spec.Finally...) {
+				if task.TaskRef == nil {
+					continue
+				}
+				if task.TaskRef.Kind == "ClusterTask" {
+					t.Errorf("%s refers to the %s ClusterTask", path, task.TaskRef.Name)
+				}
+				if task.TaskRef.Resolver == "cluster" {
+					resolved[filepath.Base(path)+"/"+task.Name] = true
+				}
+			}
+			decoded++
+		}
+		return nil
+	})
+	assertNoError(t, err)
+
+	if decoded == 0 {
+		t.Fatal("no Tasks or Pipelines were generated")
+	}
+	for _, want := range []string{"app-ci-pipeline.yaml/clone-source", "app-ci-pipeline.yaml/build-image", "app-cd-s2i-java-pipeline.yaml/build-image"} {
+		if !resolved[want] {
+			t.Errorf("%s doesn't refer to the Task with the cluster resolver", want)
+		}
+	}
+}
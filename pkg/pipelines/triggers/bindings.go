@@ -4,11 +4,12 @@ import (
 	triggersv1 "github.com/tektoncd/triggers/pkg/apis/triggers/v1alpha1"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 var (
 	// TriggerBindingTypeMeta is the TypeMeta for v1alpha1 of the Triggers API.
-	TriggerBindingTypeMeta = meta.TypeMeta("TriggerBinding", "triggers.tekton.dev/v1alpha1")
+	TriggerBindingTypeMeta = meta.TypeMeta("TriggerBinding", tekton.V1Beta1.TriggersAPIVersion())
 )
 
 // CreateImageRepoBinding returns a TriggerBinding with the imageRepo.
@@ -25,6 +26,25 @@ func CreateImageRepoBinding(ns, bindingName, imageRepo, tlsVerify string) trigge
 	}
 }
 
+// CreateGitOpsRepoBinding returns a TriggerBinding with the GitOps repository
+// and the service that the app-cd-pipeline updates.
+func CreateGitOpsRepoBinding(ns, bindingName, gitOpsRepoURL, fullName, driver, envName, appName, svcName string) triggersv1.TriggerBinding {
+	return triggersv1.TriggerBinding{
+		TypeMeta:   TriggerBindingTypeMeta,
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, bindingName)),
+		Spec: triggersv1.TriggerBindingSpec{
+			Params: []triggersv1.Param{
+				createBindingParam("gitopsrepositoryurl", gitOpsRepoURL),
+				createBindingParam("gitopsfullname", fullName),
+				createBindingParam("gitopsdriver", driver),
+				createBindingParam("gitopsenv", envName),
+				createBindingParam("gitopsapp", appName),
+				createBindingParam("gitopsservice", svcName),
+			},
+		},
+	}
+}
+
 func createBindingParam(name, value string) triggersv1.Param {
 	return triggersv1.Param{
 		Name:  name,
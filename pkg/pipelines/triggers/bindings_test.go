@@ -48,3 +48,27 @@ func TestCreateImageRepoBinding(t *testing.T) {
 		t.Fatalf("CreateImageRepoBinding() failed:\n%s", diff)
 	}
 }
+
+func TestCreateGitOpsRepoBinding(t *testing.T) {
+	want := triggersv1.TriggerBinding{
+		TypeMeta: TriggerBindingTypeMeta,
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "test-cd-binding",
+			Namespace: "testns",
+		},
+		Spec: triggersv1.TriggerBindingSpec{
+			Params: []triggersv1.Param{
+				{Name: "gitopsrepositoryurl", Value: "https://github.com/org/gitops.git"},
+				{Name: "gitopsfullname", Value: "org/gitops"},
+				{Name: "gitopsdriver", Value: "github"},
+				{Name: "gitopsenv", Value: "dev"},
+				{Name: "gitopsapp", Value: "app"},
+				{Name: "gitopsservice", Value: "svc"},
+			},
+		},
+	}
+	binding := CreateGitOpsRepoBinding("testns", "test-cd-binding", "https://github.com/org/gitops.git", "org/gitops", "github", "dev", "app", "svc")
+	if diff := cmp.Diff(want, binding); diff != "" {
+		t.Fatalf("CreateGitOpsRepoBinding() failed:\n%s", diff)
+	}
+}
@@ -1,38 +1,61 @@
 package triggers
 
 import (
-	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	"strings"
+
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 var (
-	pipelineRunTypeMeta = meta.TypeMeta("PipelineRun", "tekton.dev/v1beta1")
+	pipelineRunTypeMeta = meta.TypeMeta("PipelineRun", tekton.V1Beta1.PipelinesAPIVersion())
 )
 
 func createDevCDPipelineRun(saName string) pipelinev1.PipelineRun {
-	return pipelinev1.PipelineRun{
-		TypeMeta:   pipelineRunTypeMeta,
-		ObjectMeta: meta.ObjectMeta(meta.NamespacedName("", "app-cd-pipeline-run-$(uid)")),
-		Spec: pipelinev1.PipelineRunSpec{
-			ServiceAccountName: saName,
-			PipelineRef:        createPipelineRef("app-cd-pipeline"),
-			Resources:          createDevResource("$(tt.params." + GitCommitID + ")"),
-		},
-	}
+	return createAppCDPipelineRun(saName, "app-cd-pipeline")
+}
+
+// createAppCDPipelineRun returns the PipelineRun for a pipeline that builds
+// the image and updates the GitOps repository, it's named after the pipeline.
+func createAppCDPipelineRun(saName, pipelineName string) pipelinev1.PipelineRun {
+	run := createAppCIPipelineRun(saName, strings.TrimSuffix(pipelineName, "-pipeline")+"-$(uid)", pipelineName)
+	run.Spec.Params = append(run.Spec.Params,
+		createPipelineBindingParam("GITOPS_REPO", "$(tt.params.gitopsrepositoryurl)"),
+		createPipelineBindingParam("GITOPS_FULLNAME", "$(tt.params.gitopsfullname)"),
+		createPipelineBindingParam("GITOPS_DRIVER", "$(tt.params.gitopsdriver)"),
+		createPipelineBindingParam("GITOPS_ENV", "$(tt.params.gitopsenv)"),
+		createPipelineBindingParam("GITOPS_APP", "$(tt.params.gitopsapp)"),
+		createPipelineBindingParam("GITOPS_SERVICE", "$(tt.params.gitopsservice)"),
+	)
+	return run
 }
 
 func createDevCIPipelineRun(saName string) pipelinev1.PipelineRun {
+	return createAppCIPipelineRunFor(saName, "app-ci-pipeline")
+}
+
+// createAppCIPipelineRunFor returns a PipelineRun like the app-ci-pipeline's,
+// that runs the named pipeline.
+func createAppCIPipelineRunFor(saName, pipelineName string) pipelinev1.PipelineRun {
+	return createAppCIPipelineRun(saName, strings.TrimSuffix(pipelineName, "-pipeline")+"-$(uid)", pipelineName)
+}
+
+func createDevCIPRPipelineRun(saName string) pipelinev1.PipelineRun {
+	return createAppCIPipelineRun(saName, "app-ci-pr-$(uid)", "app-ci-pr-pipeline")
+}
+
+func createAppCIPipelineRun(saName, runName, pipelineName string) pipelinev1.PipelineRun {
 	return pipelinev1.PipelineRun{
 		TypeMeta: pipelineRunTypeMeta,
 		ObjectMeta: meta.ObjectMeta(
-			meta.NamespacedName("", "app-ci-$(uid)")),
+			meta.NamespacedName("", runName)),
 		Spec: pipelinev1.PipelineRunSpec{
 			ServiceAccountName: saName,
-			PipelineRef:        createPipelineRef("app-ci-pipeline"),
+			PipelineRef:        createPipelineRef(pipelineName),
 			Params: []pipelinev1.Param{
 				createPipelineBindingParam("REPO", "$(tt.params.fullname)"),
 				createPipelineBindingParam("GIT_REPO", "$(tt.params.gitrepositoryurl)"),
@@ -45,19 +68,7 @@ func createDevCIPipelineRun(saName string) pipelinev1.PipelineRun {
 				createPipelineBindingParam("COMMIT_AUTHOR", "$(tt.params."+GitCommitAuthor+")"),
 				createPipelineBindingParam("COMMIT_MESSAGE", "$(tt.params."+GitCommitMessage+")"),
 			},
-			Workspaces: []pipelinev1.WorkspaceBinding{
-				{
-					Name: "shared-data",
-					VolumeClaimTemplate: &corev1.PersistentVolumeClaim{
-						Spec: corev1.PersistentVolumeClaimSpec{
-							AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{"storage": resource.MustParse("1Gi")},
-							},
-						},
-					},
-				},
-			},
+			Workspaces: createWorkspaces(),
 		},
 	}
 }
@@ -69,65 +80,59 @@ func createCDPipelineRun(saName string) pipelinev1.PipelineRun {
 		Spec: pipelinev1.PipelineRunSpec{
 			ServiceAccountName: saName,
 			PipelineRef:        createPipelineRef("cd-deploy-from-push-pipeline"),
-			Resources:          createResources(),
+			Params: []pipelinev1.Param{
+				createPipelineBindingParam("GIT_REPO", "$(tt.params.gitrepositoryurl)"),
+				createPipelineBindingParam("COMMIT_SHA", "$(tt.params."+GitCommitID+")"),
+			},
+			Workspaces: createWorkspaces(),
 		},
 	}
 }
 
 func createCIPipelineRun(saName string) pipelinev1.PipelineRun {
+	return createCIDryRunPipelineRun(saName, "ci-dryrun-from-push-$(uid)")
+}
+
+func createCIPRPipelineRun(saName string) pipelinev1.PipelineRun {
+	return createCIDryRunPipelineRun(saName, "ci-dryrun-from-pr-$(uid)")
+}
+
+func createCIDryRunPipelineRun(saName, runName string) pipelinev1.PipelineRun {
 	return pipelinev1.PipelineRun{
 		TypeMeta: pipelineRunTypeMeta,
 		ObjectMeta: meta.ObjectMeta(
-			meta.NamespacedName("", "ci-dryrun-from-push-$(uid)")),
+			meta.NamespacedName("", runName)),
 		Spec: pipelinev1.PipelineRunSpec{
 			ServiceAccountName: saName,
 			PipelineRef:        createPipelineRef("ci-dryrun-from-push-pipeline"),
-			Resources:          createResources(),
 			Params: []pipelinev1.Param{
 				createPipelineBindingParam("REPO", "$(tt.params.fullname)"),
 				createPipelineBindingParam("GIT_REPO", "$(tt.params.gitrepositoryurl)"),
 				createPipelineBindingParam("COMMIT_SHA", "$(tt.params.io.openshift.build.commit.id)"),
 			},
+			Workspaces: createWorkspaces(),
 		},
 	}
 }
 
-func createDevResource(revision string) []pipelinev1.PipelineResourceBinding {
-	return []pipelinev1.PipelineResourceBinding{
-		{
-			Name: "source-repo",
-			ResourceSpec: &pipelinev1alpha1.PipelineResourceSpec{
-				Type: "git",
-				Params: []pipelinev1.ResourceParam{
-					createResourceParams("revision", revision),
-					createResourceParams("url", "$(tt.params.gitrepositoryurl)"),
-				},
-			},
-		},
-	}
-}
-
-func createResources() []pipelinev1.PipelineResourceBinding {
-	return []pipelinev1.PipelineResourceBinding{
+// createWorkspaces returns the shared-data workspace for a PipelineRun, with
+// a volume that's claimed for the run.
+func createWorkspaces() []pipelinev1.WorkspaceBinding {
+	return []pipelinev1.WorkspaceBinding{
 		{
-			Name: "source-repo",
-			ResourceSpec: &pipelinev1alpha1.PipelineResourceSpec{
-				Type: "git",
-				Params: []pipelinev1.ResourceParam{
-					createResourceParams("revision", "$(tt.params."+GitCommitID+")"),
-					createResourceParams("url", "$(tt.params.gitrepositoryurl)"),
+			Name: "shared-data",
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaim{
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{"storage": resource.MustParse("1Gi")},
+					},
 				},
 			},
 		},
 	}
 }
 
-func createResourceParams(name, value string) pipelinev1.ResourceParam {
-	return pipelinev1.ResourceParam{
-		Name:  name,
-		Value: value,
-	}
-}
 func createPipelineRef(name string) *pipelinev1.PipelineRef {
 	return &pipelinev1.PipelineRef{
 		Name: name,
@@ -4,7 +4,6 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
-	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	pipelinev1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
@@ -18,17 +17,20 @@ var (
 )
 
 func TestCreateDevCDPipelineRun(t *testing.T) {
-	validDevCDPipeline := pipelinev1.PipelineRun{
-		TypeMeta:   pipelineRunTypeMeta,
-		ObjectMeta: meta.ObjectMeta(meta.NamespacedName("", "app-cd-pipeline-run-$(uid)")),
-		Spec: pipelinev1.PipelineRunSpec{
-			ServiceAccountName: sName,
-			PipelineRef:        createPipelineRef("app-cd-pipeline"),
-			Resources:          createDevResource("$(tt.params.io.openshift.build.commit.id)"),
-		},
-	}
+	want := createDevCIPipelineRun(sName)
+	want.ObjectMeta.Name = "app-cd-$(uid)"
+	want.Spec.PipelineRef = createPipelineRef("app-cd-pipeline")
+	want.Spec.Params = append(want.Spec.Params,
+		createPipelineBindingParam("GITOPS_REPO", "$(tt.params.gitopsrepositoryurl)"),
+		createPipelineBindingParam("GITOPS_FULLNAME", "$(tt.params.gitopsfullname)"),
+		createPipelineBindingParam("GITOPS_DRIVER", "$(tt.params.gitopsdriver)"),
+		createPipelineBindingParam("GITOPS_ENV", "$(tt.params.gitopsenv)"),
+		createPipelineBindingParam("GITOPS_APP", "$(tt.params.gitopsapp)"),
+		createPipelineBindingParam("GITOPS_SERVICE", "$(tt.params.gitopsservice)"),
+	)
+
 	template := createDevCDPipelineRun(sName)
-	if diff := cmp.Diff(validDevCDPipeline, template); diff != "" {
+	if diff := cmp.Diff(want, template); diff != "" {
 		t.Fatalf("createDevCDPipelineRun failed:\n%s", diff)
 	}
 }
@@ -81,7 +83,11 @@ func TestCreateCDPipelineRun(t *testing.T) {
 		Spec: pipelinev1.PipelineRunSpec{
 			ServiceAccountName: sName,
 			PipelineRef:        createPipelineRef("cd-deploy-from-push-pipeline"),
-			Resources:          createResources(),
+			Params: []v1beta1.Param{
+				createPipelineBindingParam("GIT_REPO", "$(tt.params.gitrepositoryurl)"),
+				createPipelineBindingParam("COMMIT_SHA", "$(tt.params.io.openshift.build.commit.id)"),
+			},
+			Workspaces: createWorkspaces(),
 		},
 	}
 	template := createCDPipelineRun(sName)
@@ -98,12 +104,12 @@ func TestCreateStageCIPipelineRun(t *testing.T) {
 		Spec: pipelinev1.PipelineRunSpec{
 			ServiceAccountName: sName,
 			PipelineRef:        createPipelineRef("ci-dryrun-from-push-pipeline"),
-			Resources:          createResources(),
 			Params: []v1beta1.Param{
 				createPipelineBindingParam("REPO", "$(tt.params.fullname)"),
 				createPipelineBindingParam("GIT_REPO", "$(tt.params.gitrepositoryurl)"),
 				createPipelineBindingParam("COMMIT_SHA", "$(tt.params.io.openshift.build.commit.id)"),
 			},
+			Workspaces: createWorkspaces(),
 		},
 	}
 	template := createCIPipelineRun(sName)
@@ -112,21 +118,35 @@ func TestCreateStageCIPipelineRun(t *testing.T) {
 	}
 }
 
-func TestCreateDevResource(t *testing.T) {
-	want := []pipelinev1.PipelineResourceBinding{
-		{
-			Name: "source-repo",
-			ResourceSpec: &pipelinev1alpha1.PipelineResourceSpec{
-				Type: "git",
-				Params: []pipelinev1.ResourceParam{
-					createResourceParams("revision", "test"),
-					createResourceParams("url", "$(tt.params.gitrepositoryurl)"),
-				},
+func TestCreateCIPRPipelineRun(t *testing.T) {
+	want := pipelinev1.PipelineRun{
+		TypeMeta: pipelineRunTypeMeta,
+		ObjectMeta: meta.ObjectMeta(
+			meta.NamespacedName("", "ci-dryrun-from-pr-$(uid)")),
+		Spec: pipelinev1.PipelineRunSpec{
+			ServiceAccountName: sName,
+			PipelineRef:        createPipelineRef("ci-dryrun-from-push-pipeline"),
+			Params: []v1beta1.Param{
+				createPipelineBindingParam("REPO", "$(tt.params.fullname)"),
+				createPipelineBindingParam("GIT_REPO", "$(tt.params.gitrepositoryurl)"),
+				createPipelineBindingParam("COMMIT_SHA", "$(tt.params.io.openshift.build.commit.id)"),
 			},
+			Workspaces: createWorkspaces(),
 		},
 	}
-	got := createDevResource("test")
-	if diff := cmp.Diff(got, want); diff != "" {
-		t.Fatalf("createDevResource() failed: \n%s", diff)
+	template := createCIPRPipelineRun(sName)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("createCIPRPipelineRun failed:\n%s", diff)
+	}
+}
+
+func TestCreateDevCIPRPipelineRun(t *testing.T) {
+	want := createDevCIPipelineRun(sName)
+	want.ObjectMeta.Name = "app-ci-pr-$(uid)"
+	want.Spec.PipelineRef = createPipelineRef("app-ci-pr-pipeline")
+
+	template := createDevCIPRPipelineRun(sName)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("createDevCIPRPipelineRun failed:\n%s", diff)
 	}
 }
@@ -7,10 +7,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 var (
-	triggerTemplateTypeMeta = meta.TypeMeta("TriggerTemplate", "triggers.tekton.dev/v1alpha1")
+	triggerTemplateTypeMeta = meta.TypeMeta("TriggerTemplate", tekton.V1Beta1.TriggersAPIVersion())
 )
 
 const (
@@ -35,38 +36,58 @@ func GenerateTemplates(ns, saName string) []triggersv1.TriggerTemplate {
 		CreateDevCIBuildPRTemplate(ns, saName),
 		CreateCDPushTemplate(ns, saName),
 		CreateCIDryRunTemplate(ns, saName),
+		CreateDevCIBuildFromPRTemplate(ns, saName),
+		CreateCIDryRunPRTemplate(ns, saName),
 	}
 }
 
-// CreateDevCDDeployTemplate creates DevCDDeployTemplate
+// CreateDevCDDeployTemplate returns the TriggerTemplate that starts the
+// app-cd-pipeline, which builds the image for a push to a service's source
+// repository, and opens a pull request to update the image in the GitOps
+// repository.
 func CreateDevCDDeployTemplate(ns, saName string) triggersv1.TriggerTemplate {
-	return triggersv1.TriggerTemplate{
-		TypeMeta:   triggerTemplateTypeMeta,
-		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, "app-cd-template")),
-		Spec: triggersv1.TriggerTemplateSpec{
-			Params: []triggersv1.ParamSpec{
-				createTemplateParamSpec(GitCommitID, "The specific commit SHA."),
-				createTemplateParamSpec("gitrepositoryurl", "The git repository url"),
-			},
-			ResourceTemplates: []triggersv1.TriggerResourceTemplate{
-				{
-					RawExtension: runtime.RawExtension{
-						Raw: createDevCDResourceTemplate(saName),
-					},
-				},
-			},
-		},
-	}
+	return CreateAppCDTemplate(ns, "app-cd-template", "app-cd-pipeline", saName)
+}
+
+// CreateAppCDTemplate returns a TriggerTemplate with the same parameters as
+// the app-cd-template, that starts the named pipeline, for the pipelines in
+// the catalog of pipeline templates.
+func CreateAppCDTemplate(ns, name, pipelineName, saName string) triggersv1.TriggerTemplate {
+	return createAppCITemplate(ns, name, createAppCDResourceTemplate(saName, pipelineName),
+		createTemplateParamSpec("gitopsrepositoryurl", "The GitOps repository URL."),
+		createTemplateParamSpec("gitopsfullname", "The GitOps repository name."),
+		createTemplateParamSpec("gitopsdriver", "The driver for the GitOps repository's host."),
+		createTemplateParamSpec("gitopsenv", "The environment of the service to update in the GitOps repository."),
+		createTemplateParamSpec("gitopsapp", "The application of the service to update in the GitOps repository."),
+		createTemplateParamSpec("gitopsservice", "The name of the service to update in the GitOps repository."),
+	)
 }
 
 // CreateDevCIBuildPRTemplate creates DevCIBuildPRTemplate
 func CreateDevCIBuildPRTemplate(ns, saName string) triggersv1.TriggerTemplate {
+	return CreateAppCIPipelineTemplate(ns, "app-ci-template", "app-ci-pipeline", saName)
+}
+
+// CreateAppCIPipelineTemplate returns a TriggerTemplate with the same
+// parameters as the app-ci-template, that starts the named pipeline, for the
+// pipelines that are generated with a service's pre-build stages.
+func CreateAppCIPipelineTemplate(ns, name, pipelineName, saName string) triggersv1.TriggerTemplate {
+	return createAppCITemplate(ns, name, createAppCIResourceTemplate(saName, pipelineName))
+}
+
+// CreateDevCIBuildFromPRTemplate returns the TriggerTemplate that starts the
+// app-ci-pr-pipeline for pull requests against a service's source repository.
+func CreateDevCIBuildFromPRTemplate(ns, saName string) triggersv1.TriggerTemplate {
+	return createAppCITemplate(ns, "app-ci-pr-template", createDevCIPRResourceTemplate(saName))
+}
+
+func createAppCITemplate(ns, name string, resourceTemplate []byte, extraParams ...triggersv1.ParamSpec) triggersv1.TriggerTemplate {
 	return triggersv1.TriggerTemplate{
 		TypeMeta: triggerTemplateTypeMeta,
 		ObjectMeta: meta.ObjectMeta(
-			meta.NamespacedName(ns, "app-ci-template")),
+			meta.NamespacedName(ns, name)),
 		Spec: triggersv1.TriggerTemplateSpec{
-			Params: []triggersv1.ParamSpec{
+			Params: append([]triggersv1.ParamSpec{
 				createTemplateParamSpec(GitRef, "The git branch for this PR."),
 				createTemplateParamSpec(GitCommitID, "the specific commit SHA."),
 				createTemplateParamSpec(GitCommitDate, "The date at which the commit was made"),
@@ -77,11 +98,11 @@ func CreateDevCIBuildPRTemplate(ns, saName string) triggersv1.TriggerTemplate {
 				createTemplateParamSpec("imageRepo", "The repository to push built images to."),
 				createTemplateParamSpec("tlsVerify", "Enable image repository TLS certification verification."),
 				createTemplateParamSpec("build_extra_args", "Extra parameters passed for the push command when pushing images."),
-			},
+			}, extraParams...),
 			ResourceTemplates: []triggersv1.TriggerResourceTemplate{
 				{
 					RawExtension: runtime.RawExtension{
-						Raw: createDevCIResourceTemplate(saName),
+						Raw: resourceTemplate,
 					},
 				},
 			},
@@ -116,9 +137,19 @@ func CreateCDPushTemplate(ns, saName string) triggersv1.TriggerTemplate {
 
 // CreateCIDryRunTemplate returns TriggerTemplate for CI Dry Try
 func CreateCIDryRunTemplate(ns, saName string) triggersv1.TriggerTemplate {
+	return createCIDryRunTemplate(ns, "ci-dryrun-from-push-template", createCIResourceTemplate(saName))
+}
+
+// CreateCIDryRunPRTemplate returns TriggerTemplate for CI Dry Run of a Pull
+// Request against the GitOps repository.
+func CreateCIDryRunPRTemplate(ns, saName string) triggersv1.TriggerTemplate {
+	return createCIDryRunTemplate(ns, "ci-dryrun-from-pr-template", createCIPRResourceTemplate(saName))
+}
+
+func createCIDryRunTemplate(ns, name string, resourceTemplate []byte) triggersv1.TriggerTemplate {
 	return triggersv1.TriggerTemplate{
 		TypeMeta:   triggerTemplateTypeMeta,
-		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, "ci-dryrun-from-push-template")),
+		ObjectMeta: meta.ObjectMeta(meta.NamespacedName(ns, name)),
 		Spec: triggersv1.TriggerTemplateSpec{
 			Params: []triggersv1.ParamSpec{
 				createTemplateParamSpecDefault(GitRef, "The git revision", "master"),
@@ -129,7 +160,7 @@ func CreateCIDryRunTemplate(ns, saName string) triggersv1.TriggerTemplate {
 			ResourceTemplates: []triggersv1.TriggerResourceTemplate{
 				{
 					RawExtension: runtime.RawExtension{
-						Raw: createCIResourceTemplate(saName),
+						Raw: resourceTemplate,
 					},
 				},
 			},
@@ -153,12 +184,21 @@ func createTemplateParamSpec(name, description string) triggersv1.ParamSpec {
 }
 
 func createDevCDResourceTemplate(saName string) []byte {
-	byteTemplate, _ := json.Marshal(createDevCDPipelineRun(saName))
+	return createAppCDResourceTemplate(saName, "app-cd-pipeline")
+}
+
+func createAppCDResourceTemplate(saName, pipelineName string) []byte {
+	byteTemplate, _ := json.Marshal(createAppCDPipelineRun(saName, pipelineName))
 	return byteTemplate
 }
 
-func createDevCIResourceTemplate(saName string) []byte {
-	byteTemplateCI, _ := json.Marshal(createDevCIPipelineRun(saName))
+func createAppCIResourceTemplate(saName, pipelineName string) []byte {
+	byteTemplateCI, _ := json.Marshal(createAppCIPipelineRunFor(saName, pipelineName))
+	return byteTemplateCI
+}
+
+func createDevCIPRResourceTemplate(saName string) []byte {
+	byteTemplateCI, _ := json.Marshal(createDevCIPRPipelineRun(saName))
 	return byteTemplateCI
 }
 
@@ -172,6 +212,46 @@ func createCIResourceTemplate(saName string) []byte {
 	return byteStageCI
 }
 
+func createCIPRResourceTemplate(saName string) []byte {
+	byteStageCI, _ := json.Marshal(createCIPRPipelineRun(saName))
+	return byteStageCI
+}
+
+// ConvertTemplate converts a TriggerTemplate, and the PipelineRuns that it
+// creates, to the version of the Tekton APIs.
+func ConvertTemplate(t *triggersv1.TriggerTemplate, v tekton.Version) {
+	t.APIVersion = v.TriggersAPIVersion()
+	for i := range t.Spec.ResourceTemplates {
+		t.Spec.ResourceTemplates[i].Raw = convertPipelineRun(t.Spec.ResourceTemplates[i].Raw, v)
+	}
+}
+
+// convertPipelineRun converts a PipelineRun to the version, the service
+// account of a tekton.dev/v1 PipelineRun is set in its taskRunTemplate.
+//
+// Resource templates that aren't PipelineRuns are returned unchanged.
+func convertPipelineRun(raw []byte, v tekton.Version) []byte {
+	if v != tekton.V1 {
+		return raw
+	}
+	run := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &run); err != nil || run["kind"] != "PipelineRun" {
+		return raw
+	}
+	run["apiVersion"] = v.PipelinesAPIVersion()
+	if spec, ok := run["spec"].(map[string]interface{}); ok {
+		if saName, ok := spec["serviceAccountName"]; ok {
+			delete(spec, "serviceAccountName")
+			spec["taskRunTemplate"] = map[string]interface{}{"serviceAccountName": saName}
+		}
+	}
+	converted, err := json.Marshal(run)
+	if err != nil {
+		return raw
+	}
+	return converted
+}
+
 func strPtr(s string) *string {
 	return &s
 }
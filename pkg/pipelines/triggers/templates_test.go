@@ -1,6 +1,7 @@
 package triggers
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -8,6 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/redhat-developer/kam/pkg/pipelines/meta"
+	"github.com/redhat-developer/kam/pkg/pipelines/tekton"
 )
 
 const (
@@ -15,37 +17,66 @@ const (
 )
 
 func TestCreateDevCDDeployTemplate(t *testing.T) {
-	validDevCDTemplate := triggersv1.TriggerTemplate{
-		TypeMeta:   triggerTemplateTypeMeta,
-		ObjectMeta: meta.ObjectMeta(meta.NamespacedName("testns", "app-cd-template")),
-		Spec: triggersv1.TriggerTemplateSpec{
-			Params: []triggersv1.ParamSpec{
-				{
-					Name:        GitCommitID,
-					Description: "The specific commit SHA.",
-				},
-				{
-					Name:        "gitrepositoryurl",
-					Description: "The git repository url",
-				},
-			},
-
-			ResourceTemplates: []triggersv1.TriggerResourceTemplate{
-				{
-					RawExtension: runtime.RawExtension{
-						Raw: createDevCDResourceTemplate(serviceAccName),
-					},
-				},
+	want := CreateDevCIBuildPRTemplate("testns", serviceAccName)
+	want.ObjectMeta.Name = "app-cd-template"
+	want.Spec.Params = append(want.Spec.Params,
+		triggersv1.ParamSpec{Name: "gitopsrepositoryurl", Description: "The GitOps repository URL."},
+		triggersv1.ParamSpec{Name: "gitopsfullname", Description: "The GitOps repository name."},
+		triggersv1.ParamSpec{Name: "gitopsdriver", Description: "The driver for the GitOps repository's host."},
+		triggersv1.ParamSpec{Name: "gitopsenv", Description: "The environment of the service to update in the GitOps repository."},
+		triggersv1.ParamSpec{Name: "gitopsapp", Description: "The application of the service to update in the GitOps repository."},
+		triggersv1.ParamSpec{Name: "gitopsservice", Description: "The name of the service to update in the GitOps repository."},
+	)
+	want.Spec.ResourceTemplates = []triggersv1.TriggerResourceTemplate{
+		{
+			RawExtension: runtime.RawExtension{
+				Raw: createDevCDResourceTemplate(serviceAccName),
 			},
 		},
 	}
 
 	template := CreateDevCDDeployTemplate("testns", serviceAccName)
-	if diff := cmp.Diff(validDevCDTemplate, template); diff != "" {
+	if diff := cmp.Diff(want, template); diff != "" {
 		t.Fatalf("CreateDevCDDeployTemplate failed:\n%s", diff)
 	}
 }
 
+func TestCreateAppCDTemplate(t *testing.T) {
+	want := CreateDevCDDeployTemplate("testns", serviceAccName)
+	want.ObjectMeta.Name = "app-cd-ko-template"
+	run := createDevCDPipelineRun(serviceAccName)
+	run.ObjectMeta.Name = "app-cd-ko-$(uid)"
+	run.Spec.PipelineRef = createPipelineRef("app-cd-ko-pipeline")
+	raw, err := json.Marshal(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Spec.ResourceTemplates[0].RawExtension.Raw = raw
+
+	template := CreateAppCDTemplate("testns", "app-cd-ko-template", "app-cd-ko-pipeline", serviceAccName)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("CreateAppCDTemplate failed:\n%s", diff)
+	}
+}
+
+func TestCreateAppCIPipelineTemplate(t *testing.T) {
+	want := CreateDevCIBuildPRTemplate("testns", serviceAccName)
+	want.ObjectMeta.Name = "app-ci-dev-http-api-template"
+	run := createDevCIPipelineRun(serviceAccName)
+	run.ObjectMeta.Name = "app-ci-dev-http-api-$(uid)"
+	run.Spec.PipelineRef = createPipelineRef("app-ci-dev-http-api-pipeline")
+	raw, err := json.Marshal(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Spec.ResourceTemplates[0].RawExtension.Raw = raw
+
+	template := CreateAppCIPipelineTemplate("testns", "app-ci-dev-http-api-template", "app-ci-dev-http-api-pipeline", serviceAccName)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("CreateAppCIPipelineTemplate failed:\n%s", diff)
+	}
+}
+
 func TestCreateDevCIBuildPRTemplate(t *testing.T) {
 	validdevCIPRTemplate := triggersv1.TriggerTemplate{
 		TypeMeta:   triggerTemplateTypeMeta,
@@ -96,7 +127,7 @@ func TestCreateDevCIBuildPRTemplate(t *testing.T) {
 			ResourceTemplates: []triggersv1.TriggerResourceTemplate{
 				{
 					RawExtension: runtime.RawExtension{
-						Raw: createDevCIResourceTemplate(serviceAccName),
+						Raw: createAppCIResourceTemplate(serviceAccName, "app-ci-pipeline"),
 					},
 				},
 			},
@@ -177,3 +208,59 @@ func TestCreateCIDryRunTemplate(t *testing.T) {
 		t.Fatalf("createCIdryrunptemplate failed:\n%s", diff)
 	}
 }
+
+func TestCreateCIDryRunPRTemplate(t *testing.T) {
+	want := CreateCIDryRunTemplate("testns", serviceAccName)
+	want.ObjectMeta.Name = "ci-dryrun-from-pr-template"
+	want.Spec.ResourceTemplates[0].RawExtension.Raw = createCIPRResourceTemplate(serviceAccName)
+
+	template := CreateCIDryRunPRTemplate("testns", serviceAccName)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("CreateCIDryRunPRTemplate failed:\n%s", diff)
+	}
+}
+
+func TestCreateDevCIBuildFromPRTemplate(t *testing.T) {
+	want := CreateDevCIBuildPRTemplate("testns", serviceAccName)
+	want.ObjectMeta.Name = "app-ci-pr-template"
+	want.Spec.ResourceTemplates[0].RawExtension.Raw = createDevCIPRResourceTemplate(serviceAccName)
+
+	template := CreateDevCIBuildFromPRTemplate("testns", serviceAccName)
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("CreateDevCIBuildFromPRTemplate failed:\n%s", diff)
+	}
+}
+
+func TestConvertTemplate(t *testing.T) {
+	template := CreateCIDryRunTemplate("testns", serviceAccName)
+	ConvertTemplate(&template, tekton.V1)
+
+	if diff := cmp.Diff("triggers.tekton.dev/v1beta1", template.APIVersion); diff != "" {
+		t.Fatalf("ConvertTemplate() apiVersion failed:\n%s", diff)
+	}
+	run := map[string]interface{}{}
+	if err := json.Unmarshal(template.Spec.ResourceTemplates[0].Raw, &run); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff("tekton.dev/v1", run["apiVersion"]); diff != "" {
+		t.Fatalf("ConvertTemplate() PipelineRun apiVersion failed:\n%s", diff)
+	}
+	spec := run["spec"].(map[string]interface{})
+	if _, ok := spec["serviceAccountName"]; ok {
+		t.Fatal("ConvertTemplate() didn't remove the v1beta1 serviceAccountName")
+	}
+	want := map[string]interface{}{"serviceAccountName": serviceAccName}
+	if diff := cmp.Diff(want, spec["taskRunTemplate"]); diff != "" {
+		t.Fatalf("ConvertTemplate() taskRunTemplate failed:\n%s", diff)
+	}
+}
+
+func TestConvertTemplateToV1Beta1(t *testing.T) {
+	want := CreateCIDryRunTemplate("testns", serviceAccName)
+	template := CreateCIDryRunTemplate("testns", serviceAccName)
+	ConvertTemplate(&template, tekton.V1Beta1)
+
+	if diff := cmp.Diff(want, template); diff != "" {
+		t.Fatalf("ConvertTemplate() changed the template:\n%s", diff)
+	}
+}
@@ -32,15 +32,16 @@ func BootstrapRepository(o *BootstrapOptions, f clientFactory, e executor, appFs
 	if err != nil {
 		return fmt.Errorf("failed to parse GitOps repo URL %q: %w", o.GitOpsRepoURL, err)
 	}
-	parts := strings.Split(u.Path, "/")
-	org := parts[1]
-	repoName := strings.TrimSuffix(strings.Join(parts[2:], "/"), ".git")
 	u.User = url.UserPassword("", o.GitHostAccessToken)
 
 	client, err := f(u.String())
 	if err != nil {
 		return fmt.Errorf("failed to create a client to access %q: %w", o.GitOpsRepoURL, err)
 	}
+	org, repoName, err := repositoryNamespace(client.Driver, u.Path)
+	if err != nil {
+		return fmt.Errorf("failed to parse GitOps repo URL %q: %w", o.GitOpsRepoURL, err)
+	}
 	ctx := context.Background()
 	// If we're creating the repository in a personal user's account, it's a
 	// different API call that's made, clearing the org triggers go-scm to use
@@ -76,6 +77,28 @@ func BootstrapRepository(o *BootstrapOptions, f clientFactory, e executor, appFs
 	return err
 }
 
+// repositoryNamespace splits the path of a repository URL into the namespace
+// the repository is created in, and the repository name.
+func repositoryNamespace(driver scm.Driver, path string) (string, string, error) {
+	parts := strings.Split(path, "/")
+	if driver == scm.DriverStash {
+		return stashRepositoryNamespace(parts)
+	}
+	if len(parts) < 3 {
+		return "", "", fmt.Errorf("missing repository name in path %q", path)
+	}
+	return parts[1], strings.TrimSuffix(strings.Join(parts[2:], "/"), ".git"), nil
+}
+
+// Bitbucket Server clone URLs are of the form /scm/<project>/<repo>.git, the
+// repository is created in the project.
+func stashRepositoryNamespace(parts []string) (string, string, error) {
+	if len(parts) != 4 || parts[0] != "" || parts[1] != "scm" || parts[2] == "" || parts[3] == "" {
+		return "", "", fmt.Errorf("expected a Bitbucket Server path of the form /scm/<project>/<repo>.git, got %q", strings.Join(parts, "/"))
+	}
+	return parts[2], strings.TrimSuffix(parts[3], ".git"), nil
+}
+
 func pushRepository(o *BootstrapOptions, remote string, e executor, appFs afero.Fs) error {
 	if exists, _ := ioutils.IsExisting(appFs, filepath.Join(o.OutputPath, ".git")); exists {
 		if err := appFs.RemoveAll(filepath.Join(o.OutputPath, ".git")); err != nil {
@@ -10,8 +10,10 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/h2non/gock"
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/go-scm/scm/factory"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
 	"github.com/redhat-developer/kam/test"
 )
@@ -70,6 +72,68 @@ func TestBootstrapRepository_with_no_access_token(t *testing.T) {
 	refuteRepositoryCreated(t, fakeData)
 }
 
+func TestBootstrapRepository_with_stash(t *testing.T) {
+	token := "this-is-a-test-token"
+	factory, fakeData := newMockClientFactory(t, token)
+	fakeData.CurrentUser = scm.User{Login: "test-user"}
+	factory = withDriver(factory, scm.DriverStash)
+
+	err := BootstrapRepository(
+		&BootstrapOptions{
+			GitOpsRepoURL:      "https://bitbucket.example.com/scm/testing/test-repo.git",
+			GitHostAccessToken: token,
+		},
+		factory,
+		newMockExecutor(),
+		ioutils.NewMemoryFilesystem(),
+	)
+	assertNoError(t, err)
+	assertRepositoryCreated(t, fakeData, "testing", "test-repo")
+}
+
+func TestBootstrapRepository_with_gitea(t *testing.T) {
+	defer gock.Off()
+	defer func(f factory.HostDriverIdentifier) {
+		factory.DefaultIdentifier = f
+	}(factory.DefaultIdentifier)
+	factory.DefaultIdentifier = factory.NewDriverIdentifier(factory.Mapping("gitea.example.com", "gitea"))
+
+	gock.New("https://gitea.example.com").
+		Get("/api/v1/version").
+		Reply(200).
+		Type("application/json").
+		BodyString(`{"version":"1.15.0"}`)
+	gock.New("https://gitea.example.com").
+		Get("/api/v1/user").
+		Reply(200).
+		Type("application/json").
+		BodyString(`{"id":1,"login":"test-user"}`)
+	gock.New("https://gitea.example.com").
+		Post("/api/v1/org/testing/repos").
+		Reply(201).
+		Type("application/json").
+		BodyString(`{"id":1,"owner":{"login":"testing"},"name":"test-repo","full_name":"testing/test-repo","ssh_url":"git@gitea.example.com:testing/test-repo.git"}`)
+
+	e := newMockExecutor()
+	err := BootstrapRepository(
+		&BootstrapOptions{
+			GitOpsRepoURL:      "https://gitea.example.com/testing/test-repo.git",
+			GitHostAccessToken: "this-is-a-test-token",
+			OutputPath:         "/tmp",
+		},
+		factory.FromRepoURL,
+		e,
+		ioutils.NewMemoryFilesystem(),
+	)
+	assertNoError(t, err)
+	if !gock.IsDone() {
+		t.Fatalf("failed to create the Gitea repository: %v", gock.Pending())
+	}
+	if remote := e.executed[4].Args[3]; remote != "git@gitea.example.com:testing/test-repo.git" {
+		t.Fatalf("failed to push to the Gitea repository, got remote %q", remote)
+	}
+}
+
 func TestPushRepository(t *testing.T) {
 	repo := "git@github.com:testing/testing.git"
 	opts := &BootstrapOptions{
@@ -232,6 +296,39 @@ func TestRepoURL(t *testing.T) {
 	}
 }
 
+func TestRepositoryNamespace(t *testing.T) {
+	namespaceTests := []struct {
+		driver   scm.Driver
+		path     string
+		wantOrg  string
+		wantRepo string
+		wantErr  string
+	}{
+		{scm.DriverGithub, "/my-org/my-repo.git", "my-org", "my-repo", ""},
+		{scm.DriverGitlab, "/my-org/my-group/my-repo.git", "my-org", "my-group/my-repo", ""},
+		{scm.DriverGithub, "/my-repo", "", "", `missing repository name in path "/my-repo"`},
+		{scm.DriverStash, "/scm/project/my-repo.git", "project", "my-repo", ""},
+		{scm.DriverStash, "/project/my-repo.git", "", "", `expected a Bitbucket Server path of the form /scm/<project>/<repo>.git, got "/project/my-repo.git"`},
+		{scm.DriverStash, "/scm/project/sub/my-repo.git", "", "", `expected a Bitbucket Server path of the form /scm/<project>/<repo>.git, got "/scm/project/sub/my-repo.git"`},
+		{scm.DriverStash, "/scm//my-repo.git", "", "", `expected a Bitbucket Server path of the form /scm/<project>/<repo>.git, got "/scm//my-repo.git"`},
+	}
+
+	for _, tt := range namespaceTests {
+		t.Run(tt.path, func(rt *testing.T) {
+			org, repo, err := repositoryNamespace(tt.driver, tt.path)
+			if tt.wantErr == "" && err != nil {
+				rt.Fatal(err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				rt.Fatalf("got error %v, want %s", err, tt.wantErr)
+			}
+			if org != tt.wantOrg || repo != tt.wantRepo {
+				rt.Errorf("got %q/%q, want %q/%q", org, repo, tt.wantOrg, tt.wantRepo)
+			}
+		})
+	}
+}
+
 func newMockClientFactory(t *testing.T, authToken string) (clientFactory, *fake.Data) {
 	client, data := fake.NewDefault()
 	f := func(repoURL string) (*scm.Client, error) {
@@ -249,6 +346,17 @@ func newMockClientFactory(t *testing.T, authToken string) (clientFactory, *fake.
 	return f, data
 }
 
+func withDriver(f clientFactory, driver scm.Driver) clientFactory {
+	return func(repoURL string) (*scm.Client, error) {
+		client, err := f(repoURL)
+		if err != nil {
+			return nil, err
+		}
+		client.Driver = driver
+		return client, nil
+	}
+}
+
 func newMockExecutor(outputs ...[]byte) *mockExecutor {
 	return &mockExecutor{
 		outputs:  newOutputs(outputs...),
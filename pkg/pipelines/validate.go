@@ -0,0 +1,364 @@
+package pipelines
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/mkmik/multierror"
+	"github.com/spf13/afero"
+	yamlv3 "gopkg.in/yaml.v3"
+	"knative.dev/pkg/apis"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+)
+
+// The rules that are checked by Validate.
+const (
+	RuleManifestSyntax        = "manifest-syntax"
+	RuleManifestUnknownField  = "manifest-unknown-field"
+	RuleManifestInvalid       = "manifest-invalid"
+	RuleKustomizationMissing  = "kustomization-missing-file"
+	RuleArgoCDPathMissing     = "argocd-missing-path"
+	RuleTriggerUnknownRef     = "trigger-unknown-reference"
+	triggerBindingKind        = "TriggerBinding"
+	clusterTriggerBindingKind = "ClusterTriggerBinding"
+	triggerTemplateKind       = "TriggerTemplate"
+)
+
+// ValidationRule describes a rule that is checked by Validate.
+type ValidationRule struct {
+	ID          string
+	Description string
+}
+
+// ValidationRules are the rules that are checked by Validate.
+var ValidationRules = []ValidationRule{
+	{RuleManifestSyntax, "The manifest must be a valid YAML pipelines.yaml."},
+	{RuleManifestUnknownField, "The manifest must only have the fields of the Pipelines Model."},
+	{RuleManifestInvalid, "The manifest must be a valid Pipelines Model."},
+	{RuleKustomizationMissing, "The files and folders referenced by kustomizations must exist."},
+	{RuleArgoCDPathMissing, "The paths of Argo CD Applications in the GitOps repository must exist."},
+	{RuleTriggerUnknownRef, "The templates and bindings referenced by EventListener triggers must exist."},
+}
+
+// ValidateParameters is a struct that provides flags for the Validate
+// command.
+type ValidateParameters struct {
+	PipelinesFolderPath string
+	OutputPath          string
+}
+
+// Problem is a problem found by Validate.
+type Problem struct {
+	Rule      string     `json:"rule"`
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations"`
+}
+
+// Location is a location in a file, the line is 0 if it's not known.
+type Location struct {
+	File  string `json:"file"`
+	Line  int    `json:"line,omitempty"`
+	Field string `json:"field,omitempty"`
+}
+
+// Validate validates the manifest in the pipelines folder, and the files that
+// were generated from it.
+//
+// The generated files are checked for kustomizations that reference missing
+// files, Argo CD Applications with paths that don't exist in the GitOps
+// repository, and EventListener triggers that reference unknown templates or
+// bindings.
+//
+// It returns the problems that were found, the generated files are not
+// checked if the manifest can't be parsed.
+func Validate(o *ValidateParameters, fs afero.Fs) ([]Problem, error) {
+	pipelinesFolder, err := homedir.Expand(o.PipelinesFolderPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	root, err := homedir.Expand(o.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	manifestPath := filepath.Join(pipelinesFolder, pipelinesFile)
+	body, err := afero.ReadFile(fs, manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the manifest: %w", err)
+	}
+	// unknown fields are only warnings in older manifests, but they're still
+	// reported as problems.
+	problems := []Problem{}
+	m, err := config.ParseWithWarnings(bytes.NewReader(body), func(err error) {
+		problems = append(problems, parseProblems(manifestPath, err)...)
+	})
+	if err != nil {
+		return parseProblems(manifestPath, err), nil
+	}
+	config.ConfigureDrivers(m)
+	problems = append(problems, manifestProblems(manifestPath, body, m.Validate())...)
+
+	files, err := readFiles(fs, root)
+	if err != nil {
+		return nil, err
+	}
+	tree := parseTree(fs, root, files)
+	problems = append(problems, tree.kustomizationProblems()...)
+	problems = append(problems, tree.argoCDProblems(m.GitOpsURL)...)
+	problems = append(problems, tree.triggerProblems()...)
+	return problems, nil
+}
+
+func parseProblems(manifestPath string, err error) []Problem {
+	problems := []Problem{}
+	for _, err := range multierror.Split(err) {
+		var unknown *config.UnknownFieldError
+		if errors.As(err, &unknown) {
+			problems = append(problems, Problem{
+				Rule:      RuleManifestUnknownField,
+				Message:   fmt.Sprintf("unknown field %q", unknown.Path),
+				Locations: []Location{{File: manifestPath, Line: unknown.Line, Field: unknown.Path}},
+			})
+			continue
+		}
+		problems = append(problems, Problem{
+			Rule:      RuleManifestSyntax,
+			Message:   err.Error(),
+			Locations: []Location{{File: manifestPath}},
+		})
+	}
+	return problems
+}
+
+func manifestProblems(manifestPath string, body []byte, err error) []Problem {
+	problems := []Problem{}
+	if err == nil {
+		return problems
+	}
+	for _, err := range multierror.Split(err) {
+		var fieldErr *apis.FieldError
+		if !errors.As(err, &fieldErr) || len(fieldErr.Paths) == 0 {
+			problems = append(problems, Problem{
+				Rule:      RuleManifestInvalid,
+				Message:   err.Error(),
+				Locations: []Location{{File: manifestPath}},
+			})
+			continue
+		}
+		message := fieldErr.Message
+		if fieldErr.Details != "" {
+			message = message + ": " + fieldErr.Details
+		}
+		locations := []Location{}
+		for _, field := range fieldErr.Paths {
+			locations = append(locations, Location{File: manifestPath, Line: config.FieldLine(body, field), Field: field})
+		}
+		problems = append(problems, Problem{Rule: RuleManifestInvalid, Message: message, Locations: locations})
+	}
+	return problems
+}
+
+// treeDocument is a YAML document in a file in the generated files.
+type treeDocument struct {
+	file string // slash separated, relative to the root
+	node *yamlv3.Node
+	kind string
+	name string
+}
+
+type tree struct {
+	fs        afero.Fs
+	root      string
+	documents []*treeDocument
+}
+
+// parseTree parses the YAML files, files that are not valid YAML are skipped,
+// they're not necessarily Kubernetes resources.
+func parseTree(fs afero.Fs, root string, files map[string][]byte) *tree {
+	t := &tree{fs: fs, root: root}
+	paths := []string{}
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if ext := path.Ext(p); ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+		decoder := yamlv3.NewDecoder(bytes.NewReader(files[p]))
+		for {
+			var doc yamlv3.Node
+			if err := decoder.Decode(&doc); err != nil {
+				break
+			}
+			if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+				continue
+			}
+			node := doc.Content[0]
+			t.documents = append(t.documents, &treeDocument{
+				file: p,
+				node: node,
+				kind: scalarValue(node, "kind"),
+				name: scalarValue(node, "metadata", "name"),
+			})
+		}
+	}
+	return t
+}
+
+// exists returns true if the slash separated path, relative to the root,
+// exists, it can be outside of the root.
+func (t *tree) exists(p string) bool {
+	ok, err := afero.Exists(t.fs, t.filePath(p))
+	return err == nil && ok
+}
+
+func (t *tree) filePath(p string) string {
+	return filepath.Join(t.root, filepath.FromSlash(p))
+}
+
+func (t *tree) kustomizationProblems() []Problem {
+	problems := []Problem{}
+	for _, doc := range t.documents {
+		if path.Base(doc.file) != Kustomize {
+			continue
+		}
+		dir := path.Dir(doc.file)
+		for _, field := range []string{"resources", "bases", "components", "patchesStrategicMerge"} {
+			for _, item := range sequenceItems(valueNode(doc.node, field)) {
+				if item.Kind != yamlv3.ScalarNode || isRemoteResource(item.Value) {
+					continue
+				}
+				ref := path.Join(dir, item.Value)
+				if t.exists(ref) {
+					continue
+				}
+				problems = append(problems, Problem{
+					Rule:      RuleKustomizationMissing,
+					Message:   fmt.Sprintf("%s %q does not exist", field, item.Value),
+					Locations: []Location{{File: t.filePath(doc.file), Line: item.Line, Field: field}},
+				})
+			}
+		}
+	}
+	return problems
+}
+
+func (t *tree) argoCDProblems(gitOpsURL string) []Problem {
+	problems := []Problem{}
+	for _, doc := range t.documents {
+		if doc.kind != "Application" || !strings.HasPrefix(scalarValue(doc.node, "apiVersion"), "argoproj.io/") {
+			continue
+		}
+		if gitOpsURL == "" || scalarValue(doc.node, "spec", "source", "repoURL") != gitOpsURL {
+			continue
+		}
+		appPath := valueNode(doc.node, "spec", "source", "path")
+		if appPath == nil || t.exists(appPath.Value) {
+			continue
+		}
+		problems = append(problems, Problem{
+			Rule:      RuleArgoCDPathMissing,
+			Message:   fmt.Sprintf("Application %q path %q does not exist in the GitOps repository", doc.name, appPath.Value),
+			Locations: []Location{{File: t.filePath(doc.file), Line: appPath.Line, Field: "spec.source.path"}},
+		})
+	}
+	return problems
+}
+
+func (t *tree) triggerProblems() []Problem {
+	names := map[string]map[string]bool{
+		triggerBindingKind:        {},
+		clusterTriggerBindingKind: {},
+		triggerTemplateKind:       {},
+	}
+	for _, doc := range t.documents {
+		if known, ok := names[doc.kind]; ok {
+			known[doc.name] = true
+		}
+	}
+	problems := []Problem{}
+	for _, doc := range t.documents {
+		if doc.kind != "EventListener" {
+			continue
+		}
+		for _, trigger := range sequenceItems(valueNode(doc.node, "spec", "triggers")) {
+			triggerName := scalarValue(trigger, "name")
+			for _, binding := range sequenceItems(valueNode(trigger, "bindings")) {
+				ref := valueNode(binding, "ref")
+				if ref == nil {
+					continue
+				}
+				kind := scalarValue(binding, "kind")
+				if kind == "" {
+					kind = triggerBindingKind
+				}
+				if known, ok := names[kind]; ok && !known[ref.Value] {
+					problems = append(problems, unknownTriggerRef(t.filePath(doc.file), doc, triggerName, kind, ref))
+				}
+			}
+			template := valueNode(trigger, "template", "ref")
+			if template == nil {
+				template = valueNode(trigger, "template", "name")
+			}
+			if template != nil && !names[triggerTemplateKind][template.Value] {
+				problems = append(problems, unknownTriggerRef(t.filePath(doc.file), doc, triggerName, triggerTemplateKind, template))
+			}
+		}
+	}
+	return problems
+}
+
+func unknownTriggerRef(file string, doc *treeDocument, triggerName, kind string, ref *yamlv3.Node) Problem {
+	return Problem{
+		Rule:      RuleTriggerUnknownRef,
+		Message:   fmt.Sprintf("EventListener %q trigger %q references unknown %s %q", doc.name, triggerName, kind, ref.Value),
+		Locations: []Location{{File: file, Line: ref.Line, Field: "spec.triggers." + triggerName}},
+	}
+}
+
+// isRemoteResource returns true if the kustomization resource is fetched
+// rather than being a file or folder.
+func isRemoteResource(resource string) bool {
+	return strings.Contains(resource, "://") || strings.HasPrefix(resource, "github.com/") || strings.HasPrefix(resource, "git@")
+}
+
+// valueNode returns the value of the nested mapping keys, or nil if any of the
+// keys don't exist.
+func valueNode(node *yamlv3.Node, keys ...string) *yamlv3.Node {
+	for _, key := range keys {
+		if node == nil || node.Kind != yamlv3.MappingNode {
+			return nil
+		}
+		var next *yamlv3.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		node = next
+	}
+	return node
+}
+
+func scalarValue(node *yamlv3.Node, keys ...string) string {
+	value := valueNode(node, keys...)
+	if value == nil || value.Kind != yamlv3.ScalarNode {
+		return ""
+	}
+	return value.Value
+}
+
+func sequenceItems(node *yamlv3.Node) []*yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.SequenceNode {
+		return nil
+	}
+	return node.Content
+}
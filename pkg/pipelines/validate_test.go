@@ -0,0 +1,177 @@
+package pipelines
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+)
+
+func TestValidateBootstrapped(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		GitHostAccessToken:   "test-token",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		OutputPath:           "/gitops",
+	}
+	fatalIfError(t, Bootstrap(params, fakeFs))
+
+	problems, err := Validate(&ValidateParameters{PipelinesFolderPath: "/gitops", OutputPath: "/gitops"}, fakeFs)
+	assertNoError(t, err)
+	if diff := cmp.Diff([]Problem{}, problems); diff != "" {
+		t.Fatalf("Validate() of a bootstrapped repository failed:\n%s", diff)
+	}
+}
+
+func TestValidateManifest(t *testing.T) {
+	validateTests := []struct {
+		name     string
+		manifest string
+		want     []Problem
+	}{
+		{
+			"unknown fields",
+			"environments:\n- name: dev\n  pipelines:\n    integration:\n      binding: dev-binding\n",
+			[]Problem{
+				{
+					Rule:      RuleManifestUnknownField,
+					Message:   `unknown field "environments.dev.pipelines.integration.binding"`,
+					Locations: []Location{{File: "/gitops/pipelines.yaml", Line: 5, Field: "environments.dev.pipelines.integration.binding"}},
+				},
+			},
+		},
+		{
+			"invalid YAML",
+			"environments: [\n",
+			[]Problem{
+				{
+					Rule:      RuleManifestSyntax,
+					Message:   "error converting YAML to JSON: yaml: line 1: did not find expected node content",
+					Locations: []Location{{File: "/gitops/pipelines.yaml"}},
+				},
+			},
+		},
+		{
+			"invalid manifest",
+			"environments:\n- name: dev\n  apps:\n  - name: app-1\n",
+			[]Problem{
+				{
+					Rule:    RuleManifestInvalid,
+					Message: `missing field(s) "services","config_repo"`,
+					Locations: []Location{
+						{File: "/gitops/pipelines.yaml", Line: 4, Field: "environments.dev.apps.app-1"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range validateTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			fakeFs := ioutils.NewMemoryFilesystem()
+			assertNoError(rt, afero.WriteFile(fakeFs, "/gitops/pipelines.yaml", []byte(tt.manifest), 0644))
+
+			problems, err := Validate(&ValidateParameters{PipelinesFolderPath: "/gitops", OutputPath: "/gitops"}, fakeFs)
+			assertNoError(rt, err)
+			if diff := cmp.Diff(tt.want, problems); diff != "" {
+				rt.Fatalf("Validate() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateGeneratedFiles(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	files := map[string]string{
+		"pipelines.yaml": "gitops_url: https://github.com/org/gitops.git\nenvironments:\n- name: dev\n",
+		"environments/dev/env/base/kustomization.yaml": `resources:
+- dev-environment.yaml
+- missing.yaml
+- github.com/org/repo/config?ref=main
+bases:
+- ../../apps
+`,
+		"environments/dev/env/base/dev-environment.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: dev\n",
+		"environments/dev/apps/kustomization.yaml":       "resources: []\n",
+		"config/argocd/apps.yaml": `apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: dev-env
+spec:
+  source:
+    repoURL: https://github.com/org/gitops.git
+    path: environments/dev/env/overlays
+---
+apiVersion: argoproj.io/v1alpha1
+kind: Application
+metadata:
+  name: dev-app
+spec:
+  source:
+    repoURL: https://github.com/org/config.git
+    path: environments/dev/app
+`,
+		"config/cicd/event-listener.yaml": `apiVersion: triggers.tekton.dev/v1alpha1
+kind: EventListener
+metadata:
+  name: cicd-event-listener
+spec:
+  triggers:
+  - name: push
+    bindings:
+    - ref: push-binding
+    - ref: missing-binding
+    - kind: ClusterTriggerBinding
+      ref: github-push
+    - name: static
+      value: test
+    template:
+      ref: missing-template
+`,
+		"config/cicd/push-binding.yaml": "apiVersion: triggers.tekton.dev/v1alpha1\nkind: TriggerBinding\nmetadata:\n  name: push-binding\n",
+	}
+	for name, body := range files {
+		assertNoError(t, afero.WriteFile(fakeFs, "/gitops/"+name, []byte(body), 0644))
+	}
+
+	problems, err := Validate(&ValidateParameters{PipelinesFolderPath: "/gitops", OutputPath: "/gitops"}, fakeFs)
+	assertNoError(t, err)
+
+	want := []Problem{
+		{
+			Rule:      RuleKustomizationMissing,
+			Message:   `resources "missing.yaml" does not exist`,
+			Locations: []Location{{File: "/gitops/environments/dev/env/base/kustomization.yaml", Line: 3, Field: "resources"}},
+		},
+		{
+			Rule:      RuleArgoCDPathMissing,
+			Message:   `Application "dev-env" path "environments/dev/env/overlays" does not exist in the GitOps repository`,
+			Locations: []Location{{File: "/gitops/config/argocd/apps.yaml", Line: 8, Field: "spec.source.path"}},
+		},
+		{
+			Rule:      RuleTriggerUnknownRef,
+			Message:   `EventListener "cicd-event-listener" trigger "push" references unknown TriggerBinding "missing-binding"`,
+			Locations: []Location{{File: "/gitops/config/cicd/event-listener.yaml", Line: 10, Field: "spec.triggers.push"}},
+		},
+		{
+			Rule:      RuleTriggerUnknownRef,
+			Message:   `EventListener "cicd-event-listener" trigger "push" references unknown ClusterTriggerBinding "github-push"`,
+			Locations: []Location{{File: "/gitops/config/cicd/event-listener.yaml", Line: 12, Field: "spec.triggers.push"}},
+		},
+		{
+			Rule:      RuleTriggerUnknownRef,
+			Message:   `EventListener "cicd-event-listener" trigger "push" references unknown TriggerTemplate "missing-template"`,
+			Locations: []Location{{File: "/gitops/config/cicd/event-listener.yaml", Line: 16, Field: "spec.triggers.push"}},
+		},
+	}
+	if diff := cmp.Diff(want, problems); diff != "" {
+		t.Fatalf("Validate() failed:\n%s", diff)
+	}
+}
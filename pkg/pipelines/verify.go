@@ -0,0 +1,108 @@
+package pipelines
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/config"
+)
+
+// VerifyParameters is a struct that provides flags for the VerifyResources
+// command.
+type VerifyParameters struct {
+	PipelinesFolderPath string
+	OutputPath          string
+}
+
+// RenderError is a kustomization that failed to render.
+type RenderError struct {
+	// Path is the slash separated path to the kustomization's folder,
+	// relative to the output path.
+	Path string
+	Err  error
+}
+
+func (e RenderError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+// VerifyResources renders the kustomizations that are deployed from the
+// GitOps repository with kustomize, without a cluster, so that broken
+// references, and resources with duplicate IDs, are found before Argo CD syncs
+// them.
+//
+// The kustomizations for the CI/CD environment, the Argo CD configuration, and
+// every environment and application are rendered, the kustomizations that fail
+// to render are returned, sorted by path.
+func VerifyResources(o *VerifyParameters, appFs afero.Fs) ([]RenderError, error) {
+	m, err := config.LoadManifest(appFs, o.PipelinesFolderPath)
+	if err != nil {
+		return nil, err
+	}
+	root, err := homedir.Expand(o.OutputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path to file: %v", err)
+	}
+	fSys, err := kustomizeFs(appFs, root)
+	if err != nil {
+		return nil, err
+	}
+
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	renderErrs := []RenderError{}
+	for _, target := range kustomizeTargets(m) {
+		if _, err := kustomizer.Run(fSys, path.Join("/", target)); err != nil {
+			renderErrs = append(renderErrs, RenderError{Path: target, Err: err})
+		}
+	}
+	return renderErrs, nil
+}
+
+// kustomizeTargets returns the paths of the kustomizations that are deployed
+// from the GitOps repository, sorted.
+func kustomizeTargets(m *config.Manifest) []string {
+	targets := []string{}
+	if cfg := m.GetPipelinesConfig(); cfg != nil {
+		targets = append(targets, filepath.ToSlash(filepath.Join(config.PathForPipelines(cfg), "overlays")))
+	}
+	if m.GetArgoCDConfig() != nil {
+		targets = append(targets, filepath.ToSlash(config.PathForArgoCD()))
+	}
+	for _, env := range m.Environments {
+		targets = append(targets, filepath.ToSlash(filepath.Join(config.PathForEnvironment(env), "env", "overlays")))
+		for _, app := range env.Apps {
+			// the configuration for these applications is in another
+			// repository.
+			if app.ConfigRepo != nil {
+				continue
+			}
+			targets = append(targets, filepath.ToSlash(config.PathForApplication(env, app)))
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// kustomizeFs copies the GitOps repository to an in-memory filesystem for
+// rendering, as kustomize has its own filesystem, the repository is at the
+// root of the copy.
+func kustomizeFs(appFs afero.Fs, root string) (filesys.FileSystem, error) {
+	files, err := readFiles(appFs, root)
+	if err != nil {
+		return nil, err
+	}
+	fSys := filesys.MakeFsInMemory()
+	for name, body := range files {
+		if err := fSys.WriteFile(path.Join("/", name), body); err != nil {
+			return nil, fmt.Errorf("failed to copy %s for rendering: %w", name, err)
+		}
+	}
+	return fSys, nil
+}
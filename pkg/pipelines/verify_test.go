@@ -0,0 +1,56 @@
+package pipelines
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/afero"
+
+	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+)
+
+func TestVerifyResources(t *testing.T) {
+	fakeFs := ioutils.NewMemoryFilesystem()
+	params := &BootstrapOptions{
+		Prefix:               "tst-",
+		GitOpsRepoURL:        testGitOpsRepo,
+		ImageRepo:            "image/repo",
+		GitOpsWebhookSecret:  "123",
+		GitHostAccessToken:   "test-token",
+		ServiceRepoURL:       testSvcRepo,
+		ServiceWebhookSecret: "456",
+		OutputPath:           "/gitops",
+	}
+	fatalIfError(t, Bootstrap(params, fakeFs))
+	o := &VerifyParameters{PipelinesFolderPath: "/gitops", OutputPath: "/gitops"}
+
+	renderErrs, err := VerifyResources(o, fakeFs)
+	assertNoError(t, err)
+	if diff := cmp.Diff([]RenderError{}, renderErrs); diff != "" {
+		t.Fatalf("VerifyResources() of a bootstrapped repository failed:\n%s", diff)
+	}
+
+	assertNoError(t, afero.WriteFile(fakeFs, "/gitops/environments/tst-stage/env/base/kustomization.yaml",
+		[]byte("resources:\n- tst-stage-environment.yaml\n- tst-stage-environment.yaml\n"), 0644))
+	assertNoError(t, afero.WriteFile(fakeFs, "/gitops/environments/tst-dev/apps/app-http-api/overlays/kustomization.yaml",
+		[]byte("bases:\n- ../missing\n"), 0644))
+
+	renderErrs, err = VerifyResources(o, fakeFs)
+	assertNoError(t, err)
+	want := []struct {
+		path string
+		err  string
+	}{
+		{"environments/tst-dev/apps/app-http-api", "missing"},
+		{"environments/tst-stage/env/overlays", "already registered id"},
+	}
+	if len(renderErrs) != len(want) {
+		t.Fatalf("VerifyResources() got %d errors, want %d: %v", len(renderErrs), len(want), renderErrs)
+	}
+	for i, w := range want {
+		if renderErrs[i].Path != w.path || !regexp.MustCompile(w.err).MatchString(renderErrs[i].Err.Error()) {
+			t.Errorf("VerifyResources() got error %q, want %s: %s", renderErrs[i], w.path, w.err)
+		}
+	}
+}
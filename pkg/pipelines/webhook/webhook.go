@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/jenkins-x/go-scm/scm/factory"
+
 	"github.com/redhat-developer/kam/pkg/pipelines/accesstoken"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 	"github.com/redhat-developer/kam/pkg/pipelines/eventlisteners"
 	"github.com/redhat-developer/kam/pkg/pipelines/git"
 	"github.com/redhat-developer/kam/pkg/pipelines/ioutils"
+	"github.com/redhat-developer/kam/pkg/pipelines/scm"
 	"github.com/redhat-developer/kam/pkg/pipelines/secrets"
 )
 
@@ -31,8 +34,8 @@ type QualifiedServiceName struct {
 
 // Create creates a new webhook on the target Git Repository
 // It returns the ID of created webhook.
-func Create(accessToken, pipelinesFile string, serviceName *QualifiedServiceName, isCICD bool) (string, error) {
-	webhook, err := newWebhookInfo(accessToken, pipelinesFile, serviceName, isCICD)
+func Create(accessToken, pipelinesFile, privateRepoDriver string, serviceName *QualifiedServiceName, isCICD bool) (string, error) {
+	webhook, err := newWebhookInfo(accessToken, pipelinesFile, privateRepoDriver, serviceName, isCICD)
 	if err != nil {
 		return "", err
 	}
@@ -51,8 +54,31 @@ func Create(accessToken, pipelinesFile string, serviceName *QualifiedServiceName
 
 // Delete deletes webhooks on the target Git Repository that match the listener address
 // It returns the IDs of deleted webhooks.
-func Delete(accessToken, pipelinesFile string, serviceName *QualifiedServiceName, isCICD bool) ([]string, error) {
-	webhook, err := newWebhookInfo(accessToken, pipelinesFile, serviceName, isCICD)
+func Delete(accessToken, pipelinesFile, privateRepoDriver string, serviceName *QualifiedServiceName, isCICD bool) ([]string, error) {
+	webhook, err := newWebhookInfo(accessToken, pipelinesFile, privateRepoDriver, serviceName, isCICD)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := webhook.list()
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook.delete(ids)
+}
+
+// DeleteForRepository deletes webhooks on the Git repository that match the
+// listener address, the repository doesn't have to be in the manifest, e.g.
+// for a service that was removed from it, the driver is identified with the
+// drivers configured in the manifest.
+// It returns the IDs of deleted webhooks.
+func DeleteForRepository(accessToken, pipelinesFile, gitRepoURL string) ([]string, error) {
+	manifest, err := config.LoadManifest(ioutils.NewFilesystem(), pipelinesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pipelines: %v", err)
+	}
+	webhook, err := newRepositoryWebhookInfo(accessToken, manifest, gitRepoURL, "", nil, false)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +92,8 @@ func Delete(accessToken, pipelinesFile string, serviceName *QualifiedServiceName
 }
 
 // List returns an array of webhook IDs for the target Git repository/listeners
-func List(accessToken, pipelinesFile string, serviceName *QualifiedServiceName, isCICD bool) ([]string, error) {
-	webhook, err := newWebhookInfo(accessToken, pipelinesFile, serviceName, isCICD)
+func List(accessToken, pipelinesFile, privateRepoDriver string, serviceName *QualifiedServiceName, isCICD bool) ([]string, error) {
+	webhook, err := newWebhookInfo(accessToken, pipelinesFile, privateRepoDriver, serviceName, isCICD)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +101,7 @@ func List(accessToken, pipelinesFile string, serviceName *QualifiedServiceName,
 	return webhook.list()
 }
 
-func newWebhookInfo(accessToken, pipelinesFile string, serviceName *QualifiedServiceName, isCICD bool) (*webhookInfo, error) {
+func newWebhookInfo(accessToken, pipelinesFile, privateRepoDriver string, serviceName *QualifiedServiceName, isCICD bool) (*webhookInfo, error) {
 	manifest, err := config.LoadManifest(ioutils.NewFilesystem(), pipelinesFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse pipelines: %v", err)
@@ -85,6 +111,14 @@ func newWebhookInfo(accessToken, pipelinesFile string, serviceName *QualifiedSer
 	if gitRepoURL == "" {
 		return nil, errors.New("failed to find Git repository URL in manifest")
 	}
+	return newRepositoryWebhookInfo(accessToken, manifest, gitRepoURL, privateRepoDriver, serviceName, isCICD)
+}
+
+func newRepositoryWebhookInfo(accessToken string, manifest *config.Manifest, gitRepoURL, privateRepoDriver string, serviceName *QualifiedServiceName, isCICD bool) (*webhookInfo, error) {
+	identifier, err := newDriverIdentifier(manifest, gitRepoURL, privateRepoDriver)
+	if err != nil {
+		return nil, err
+	}
 
 	cfg := manifest.GetPipelinesConfig()
 	if cfg == nil {
@@ -107,7 +141,7 @@ func newWebhookInfo(accessToken, pipelinesFile string, serviceName *QualifiedSer
 			return nil, fmt.Errorf("unable to use access-token from keyring/env-var: %v, please pass a valid token to --save-token-keyring", err)
 		}
 	}
-	repository, err := git.NewRepository(gitRepoURL, accessToken)
+	repository, err := git.NewRepositoryWithIdentifier(gitRepoURL, accessToken, identifier)
 	if err != nil {
 		return nil, err
 	}
@@ -166,6 +200,29 @@ func getSourceRepoURL(manifest *config.Manifest, service *QualifiedServiceName)
 	return ""
 }
 
+// newDriverIdentifier returns a copy of the go-scm default identifier, with the
+// drivers configured in the manifest, and the host of the repository mapped to
+// the private repository driver if provided.
+func newDriverIdentifier(manifest *config.Manifest, gitRepoURL, privateRepoDriver string) (factory.HostDriverIdentifier, error) {
+	identifier := factory.HostDriverIdentifier{}
+	for k, v := range factory.DefaultIdentifier {
+		identifier[k] = v
+	}
+	if manifest.Config != nil && manifest.Config.Git != nil {
+		for host, driver := range manifest.Config.Git.Drivers {
+			factory.Mapping(host, driver)(identifier)
+		}
+	}
+	if privateRepoDriver != "" {
+		host, err := scm.HostnameFromURL(gitRepoURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname from URL %q: %w", gitRepoURL, err)
+		}
+		factory.Mapping(host, privateRepoDriver)(identifier)
+	}
+	return identifier, nil
+}
+
 func getListenerURL(r *resources, cicdNamespace string) (string, error) {
 	hasTLS, host, err := r.getListenerAddress(cicdNamespace,
 		eventlisteners.GitOpsWebhookEventListenerRouteName)
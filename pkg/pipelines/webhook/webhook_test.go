@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm/factory"
 	"github.com/redhat-developer/kam/pkg/pipelines/config"
 )
 
@@ -127,3 +128,25 @@ func TestGetGitRepoURL(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDriverIdentifier(t *testing.T) {
+	manifest := &config.Manifest{
+		Config: &config.Config{Git: &config.GitConfig{Drivers: map[string]string{"gitea.example.com": "gitea"}}},
+	}
+	identifier, err := newDriverIdentifier(manifest, "https://bitbucket.example.com/scm/project/testing.git", "stash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for host, want := range map[string]string{"bitbucket.example.com": "stash", "gitea.example.com": "gitea", "github.com": "github"} {
+		driver, err := identifier.Identify(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if driver != want {
+			t.Fatalf("got driver %q for %s, want %q", driver, host, want)
+		}
+	}
+	if _, err := factory.DefaultIdentifier.Identify("bitbucket.example.com"); err == nil {
+		t.Fatal("default identifier was changed")
+	}
+}